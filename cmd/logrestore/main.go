@@ -0,0 +1,61 @@
+// Command logrestore imports a server log archive written by log retention
+// back into the database, for investigation. Restored entries are checked
+// against the audit chain and stored apart from the live log; read them with
+// GET /api/servers/:id/logs?restored=true.
+//
+//	go run ./cmd/logrestore log-archive/server-logs-20260101T000000Z-1a2b3c4d.jsonl.gz
+//	go run ./cmd/logrestore -clear [archive]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gitshubham45/virtualServer/internal/db"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	clearRestored := flag.Bool("clear", false, "remove restored entries, of the given archive or of all archives")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: logrestore [-clear] <archive>...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if !*clearRestored && flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file loaded: %v", err)
+	}
+	db.InitDB()
+	defer db.CloseDB()
+
+	if *clearRestored {
+		archives := flag.Args()
+		if len(archives) == 0 {
+			archives = []string{""}
+		}
+		for _, archive := range archives {
+			removed, err := logger.ClearRestored(db.DB, archive)
+			if err != nil {
+				log.Fatalf("Failed to clear restored entries: %v", err)
+			}
+			fmt.Printf("Removed %d restored entries.\n", removed)
+		}
+		return
+	}
+
+	for _, archive := range flag.Args() {
+		restored, err := logger.RestoreArchive(db.DB, archive)
+		if err != nil {
+			log.Fatalf("Failed to restore %s: %v", archive, err)
+		}
+		fmt.Printf("Restored %d entries from %s.\n", restored, archive)
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
 	"github.com/gitshubham45/virtualServer/internal/db"
+	"github.com/gitshubham45/virtualServer/internal/dnsserver"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/repository"
 	"github.com/gitshubham45/virtualServer/internal/routers"
 	"github.com/joho/godotenv"
 )
@@ -34,9 +41,57 @@ func main() {
 		})
 	})
 
-	api := router.Group("/api")
+	api := router.Group("/api", middleware.RequestMetadata, middleware.AccessLog(db.DB), middleware.Authenticate(db.DB))
 
-	routers.ServerRouter(api)
+	events := logger.New(repository.NewGormLogRepository(db.DB))
+	handler := controller.NewServerHandler(db.DB, repository.NewGormServerRepository(db.DB), events)
+	routers.ServerRouter(api, handler)
+	routers.SecurityGroupRouter(api, handler)
+	routers.KeyPairRouter(api, handler)
+	routers.LoadBalancerRouter(api, handler)
+	routers.DNSRouter(api, handler)
+	routers.BillingRouter(api, handler)
+	routers.AdminRouter(api, handler)
+	routers.ProjectRouter(api, handler)
+	routers.AuditRouter(api, handler)
+
+	if metadataPort := os.Getenv("METADATA_PORT"); metadataPort != "" {
+		metadataRouter := gin.Default()
+		routers.MetadataRouter(metadataRouter, handler)
+		go func() {
+			if err := metadataRouter.Run(":" + metadataPort); err != nil {
+				log.Fatalf("Metadata service stopped: %v", err)
+			}
+		}()
+	}
+
+	if dnsPort := os.Getenv("DNS_PORT"); dnsPort != "" {
+		go func() {
+			if err := dnsserver.ListenAndServe(":"+dnsPort, dnsserver.DBSource{DB: db.DB}); err != nil {
+				log.Fatalf("DNS responder stopped: %v", err)
+			}
+		}()
+	}
+
+	schedulerInterval := 30 * time.Second
+	if seconds, err := strconv.Atoi(os.Getenv("SCHEDULER_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		schedulerInterval = time.Duration(seconds) * time.Second
+	}
+	go handler.RunScheduler(schedulerInterval)
+
+	reaperInterval := time.Minute
+	if seconds, err := strconv.Atoi(os.Getenv("REAPER_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		reaperInterval = time.Duration(seconds) * time.Second
+	}
+	go handler.RunReaper(reaperInterval)
+
+	go handler.RunAccessLogPruner(time.Hour)
+
+	retentionInterval := time.Hour
+	if seconds, err := strconv.Atoi(os.Getenv("LOG_RETENTION_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		retentionInterval = time.Duration(seconds) * time.Second
+	}
+	go handler.RunLogRetention(retentionInterval)
 
 	router.Run(":" + port)
 }
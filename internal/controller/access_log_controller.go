@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+const (
+	defaultAccessLogRetention = 30 * 24 * time.Hour
+	defaultAccessLogLimit     = 100
+	maxAccessLogLimit         = 1000
+)
+
+// ListAccessLogs returns the newest access log entries, optionally filtered by
+// server, actor, route and time window. Credentials bound to a project only
+// see reads within it.
+func (h *ServerHandler) ListAccessLogs(c *gin.Context) {
+	if !h.authorize(c, service.PermissionAccessLogsRead, "") {
+		return
+	}
+
+	query := scopeToProject(h.db, projectOf(c))
+	for param, column := range map[string]string{"serverId": "server_id", "actor": "actor", "route": "route"} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	for param, condition := range map[string]string{"from": "created_at >= ?", "to": "created_at < ?"} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"message": "Query parameter '" + param + "' must be an RFC 3339 timestamp.",
+				})
+				return
+			}
+			query = query.Where(condition, parsed)
+		}
+	}
+
+	limit := defaultAccessLogLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxAccessLogLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Query parameter 'limit' must be between 1 and " + strconv.Itoa(maxAccessLogLimit) + ".",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	var entries []models.AccessLog
+	if err := query.Order("created_at DESC").Limit(limit).Find(&entries).Error; err != nil {
+		log.Printf("Error fetching access logs: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching access logs",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Access logs fetched successfully",
+		"logs":    entries,
+	})
+}
+
+// RunAccessLogPruner removes expired access log entries every interval.
+func (h *ServerHandler) RunAccessLogPruner(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.PruneAccessLog(time.Now())
+		<-ticker.C
+	}
+}
+
+// PruneAccessLog removes the entries older than the retention period,
+// ACCESS_LOG_RETENTION_DAYS (30 by default).
+func (h *ServerHandler) PruneAccessLog(now time.Time) {
+	removed, err := logger.PruneAccessLog(h.db, now.Add(-accessLogRetention()))
+	if err != nil {
+		log.Printf("WARNING: Failed to prune access log: %v\n", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Pruned %d access log entries.\n", removed)
+	}
+}
+
+func accessLogRetention() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("ACCESS_LOG_RETENTION_DAYS")); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return defaultAccessLogRetention
+}
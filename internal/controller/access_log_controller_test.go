@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testDB, h := setupTestDB(t)
+
+	t.Setenv("AUTH_DISABLED", "true")
+
+	router := gin.Default()
+	api := router.Group("/api", middleware.RequestMetadata, middleware.AccessLog(testDB), middleware.Authenticate(testDB))
+	api.POST("/server", h.CreateServer)
+	api.GET("/servers/:id", h.GetServer)
+	api.GET("/servers/:id/logs", h.GetLogs)
+	api.GET("/access-logs", h.ListAccessLogs)
+
+	id := createTestServer(t, router, `{"region": "India", "type": "basic", "name": "polled"}`)
+
+	for i := 0; i < 3; i++ {
+		sendJSON(t, router, http.MethodGet, "/api/servers/polled", "")
+		sendJSON(t, router, http.MethodGet, "/api/servers/"+id+"/logs", "")
+	}
+	sendJSON(t, router, http.MethodGet, "/api/servers/missing", "")
+
+	t.Run("Reads stay out of the lifecycle log", func(t *testing.T) {
+		var count int64
+		testDB.Model(&models.ServerLog{}).Where("server_id = ?", id).Count(&count)
+		if count != 1 {
+			t.Errorf("Expected only SERVER_CREATED in the lifecycle log, got %d entries", count)
+		}
+	})
+
+	t.Run("Reads are in the access log", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodGet, "/api/access-logs?serverId="+id, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Logs []models.AccessLog `json:"logs"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if len(body.Logs) != 6 {
+			t.Fatalf("Expected 6 reads of the server, got %d", len(body.Logs))
+		}
+		routes := map[string]int{}
+		for _, entry := range body.Logs {
+			routes[entry.Route]++
+			if entry.Status != http.StatusOK || entry.Actor != "anonymous" || entry.RequestID == "" {
+				t.Errorf("Unexpected entry %+v", entry)
+			}
+		}
+		if routes["/api/servers/:id"] != 3 || routes["/api/servers/:id/logs"] != 3 {
+			t.Errorf("Unexpected routes %v", routes)
+		}
+
+		var missing models.AccessLog
+		testDB.Where("path = ?", "/api/servers/missing").First(&missing)
+		if missing.Status != http.StatusNotFound || missing.ServerID != "" {
+			t.Errorf("Expected the failed read to be logged without a server, got %+v", missing)
+		}
+	})
+
+	t.Run("Invalid filters", func(t *testing.T) {
+		for _, query := range []string{"from=yesterday", "limit=0", "limit=5000"} {
+			if rec := sendJSON(t, router, http.MethodGet, "/api/access-logs?"+query, ""); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, rec.Code)
+			}
+		}
+	})
+
+	t.Run("Old entries are pruned", func(t *testing.T) {
+		t.Setenv("ACCESS_LOG_RETENTION_DAYS", "7")
+		var before int64
+		testDB.Model(&models.AccessLog{}).Count(&before)
+
+		h.PruneAccessLog(time.Now().Add(6 * 24 * time.Hour))
+		var count int64
+		testDB.Model(&models.AccessLog{}).Count(&count)
+		if count != before {
+			t.Errorf("Expected entries within retention to be kept, got %d of %d", count, before)
+		}
+
+		h.PruneAccessLog(time.Now().Add(8 * 24 * time.Hour))
+		testDB.Model(&models.AccessLog{}).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected expired entries to be removed, got %d", count)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey issues a new API key. The key itself is only returned here;
+// afterwards only its hash is kept. Credentials bound to a project can only
+// issue keys bound to the same project, and no caller can issue a key with a
+// scope or role it does not hold itself.
+func (h *ServerHandler) CreateAPIKey(c *gin.Context) {
+	var req struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		Roles     []string `json:"roles"`
+		ProjectID string   `json:"projectId"`
+		TTL       string   `json:"ttl"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !h.authorize(c, service.PermissionAPIKeysWrite, "") {
+		return
+	}
+
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "API key name is required."})
+		return
+	}
+	if errorMessage := service.ValidateScopes(req.Scopes); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+	policy, err := loadPolicy()
+	if err != nil {
+		log.Printf("ERROR: Cannot load access policy: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error loading access policy",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if errorMessage := policy.ValidateRoles(req.Roles); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+	if principal := middleware.PrincipalFrom(c); principal != nil {
+		for _, scope := range req.Scopes {
+			if !principal.HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"message": fmt.Sprintf("Cannot issue a key with the '%s' scope, which %s does not hold.", scope, principal.Actor()),
+					"code":    service.CodePermissionDenied,
+				})
+				return
+			}
+		}
+		// Keys issued without roles get the role of their scopes.
+		roles := req.Roles
+		if len(roles) == 0 {
+			roles = service.RolesForScopes(req.Scopes)
+		}
+		for _, role := range roles {
+			if !policy.CanGrant(principal, role) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"message": fmt.Sprintf("Cannot issue a key with the '%s' role, which %s does not hold.", role, principal.Actor()),
+					"code":    service.CodePermissionDenied,
+				})
+				return
+			}
+		}
+	}
+	if projectId := projectOf(c); projectId != "" {
+		req.ProjectID = projectId
+	}
+	if req.ProjectID != "" {
+		if _, err := h.lookupProject(req.ProjectID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Project '%s' does not exist.", req.ProjectID),
+			})
+			return
+		}
+	}
+	expiresAt, errorMessage := service.ResolveExpiry(nil, req.TTL, time.Now())
+	if errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	var existing int64
+	h.db.Model(&models.APIKey{}).Where("name = ?", req.Name).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("API key with name '%s' already exists.", req.Name),
+		})
+		return
+	}
+
+	secret, _, err := service.NewSecretToken()
+	if err != nil {
+		log.Printf("Error generating API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error generating API key",
+			"error":   err.Error(),
+		})
+		return
+	}
+	token := service.APIKeyPrefix + secret
+
+	key := models.APIKey{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Prefix:    token[:len(service.APIKeyPrefix)+6],
+		KeyHash:   service.HashToken(token),
+		Scopes:    req.Scopes,
+		Roles:     req.Roles,
+		ProjectID: req.ProjectID,
+		CreatedBy: middleware.PrincipalFrom(c).Actor(),
+		ExpiresAt: expiresAt,
+	}
+	if err := h.db.Create(&key).Error; err != nil {
+		log.Printf("Error saving API key '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error saving API key",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("API key '%s' created by %s with scopes %v and roles %v.\n", key.Name, key.CreatedBy, key.Scopes, key.Roles)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created successfully. Store the key now; it cannot be shown again.",
+		"apiKey":  key,
+		"key":     token,
+	})
+}
+
+func (h *ServerHandler) ListAPIKeys(c *gin.Context) {
+	var keys []models.APIKey
+	if err := scopeToProject(h.db.Order("created_at"), projectOf(c)).Find(&keys).Error; err != nil {
+		log.Printf("Error fetching API keys: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching API keys",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API keys fetched successfully",
+		"apiKeys": keys,
+	})
+}
+
+// RevokeAPIKey disables a key immediately. Revoked keys are kept so their
+// names stay reserved and past log entries remain attributable.
+func (h *ServerHandler) RevokeAPIKey(c *gin.Context) {
+	if !h.authorize(c, service.PermissionAPIKeysWrite, "") {
+		return
+	}
+	keyId := c.Param("id")
+	now := time.Now()
+	result := scopeToProject(h.db.Model(&models.APIKey{}), projectOf(c)).
+		Where("id = ? AND revoked_at IS NULL", keyId).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		log.Printf("Error revoking API key '%s': %v\n", keyId, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error revoking API key",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("Active API key with ID '%s' not found.", keyId),
+		})
+		return
+	}
+
+	log.Printf("API key '%s' revoked by %s.\n", keyId, middleware.PrincipalFrom(c).Actor())
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testDB, h := setupTestDB(t)
+
+	t.Setenv("AUTH_DISABLED", "")
+	t.Setenv("ADMIN_API_KEY", "vs_bootstrap-secret")
+
+	router := gin.Default()
+	api := router.Group("/api", middleware.Authenticate(testDB))
+	api.POST("/server", h.CreateServer)
+	api.GET("/servers", h.ListServers)
+	admin := api.Group("/admin", middleware.RequireScope("admin"))
+	admin.POST("/api-keys", h.CreateAPIKey)
+	admin.DELETE("/api-keys/:id", h.RevokeAPIKey)
+
+	bootstrap := []string{"X-API-Key", "vs_bootstrap-secret"}
+	issue := func(name, scopes string) (string, string) {
+		rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", fmt.Sprintf(`{"name": "%s", "scopes": %s}`, name, scopes), bootstrap...)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var created struct {
+			Key    string        `json:"key"`
+			APIKey models.APIKey `json:"apiKey"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &created)
+		return created.APIKey.ID, created.Key
+	}
+
+	t.Run("Missing credentials", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodGet, "/api/servers", "")
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Error("Expected a WWW-Authenticate header")
+		}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", "X-API-Key", "vs_unknown"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d for an unknown key, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("API key scopes", func(t *testing.T) {
+		_, readKey := issue("reader", `["read"]`)
+		var stored models.APIKey
+		testDB.First(&stored, "name = ?", "reader")
+		if stored.KeyHash == "" || stored.KeyHash == readKey {
+			t.Error("Expected only the hash of the key to be stored")
+		}
+
+		reader := []string{"X-API-Key", readKey}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", reader...); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		rec := sendJSON(t, router, http.MethodPost, "/api/server", `{"region": "India", "type": "basic"}`, reader...)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+		var denied struct {
+			Code string `json:"code"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &denied)
+		if denied.Code != "INSUFFICIENT_SCOPE" {
+			t.Errorf("Expected code INSUFFICIENT_SCOPE, got %q", denied.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", `{"name": "x", "scopes": ["admin"]}`, reader...); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for the admin routes, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("Actor recorded on server logs", func(t *testing.T) {
+		_, writeKey := issue("deployer", `["write"]`)
+		id := createTestServer(t, router, `{"region": "India", "type": "basic"}`, "Authorization", "Bearer "+writeKey)
+
+		var entry models.ServerLog
+		testDB.Where("server_id = ?", id).First(&entry)
+		if entry.Actor != "api-key:deployer" {
+			t.Errorf("Expected actor 'api-key:deployer', got %q", entry.Actor)
+		}
+	})
+
+	t.Run("Revoked key", func(t *testing.T) {
+		id, key := issue("temporary", `["read"]`)
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/admin/api-keys/"+id, "", bootstrap...); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", "X-API-Key", key); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/admin/api-keys/"+id, "", bootstrap...); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d when revoking twice, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("JWT bearer token", func(t *testing.T) {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		encode := base64.RawURLEncoding.EncodeToString
+		jwks := fmt.Sprintf(`{"keys": [{"kty": "OKP", "crv": "Ed25519", "kid": "k1", "x": "%s"}]}`, encode(public))
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		if err := os.WriteFile(path, []byte(jwks), 0o600); err != nil {
+			t.Fatalf("Failed to write JWKS: %v", err)
+		}
+		t.Setenv("JWKS_FILE", path)
+		t.Setenv("JWT_ISSUER", "https://issuer.example")
+
+		sign := func(claims string) string {
+			signed := encode([]byte(`{"alg": "EdDSA", "kid": "k1"}`)) + "." + encode([]byte(claims))
+			return signed + "." + encode(ed25519.Sign(private, []byte(signed)))
+		}
+		exp := time.Now().Add(time.Hour).Unix()
+
+		valid := sign(fmt.Sprintf(`{"sub": "alice", "iss": "https://issuer.example", "exp": %d, "scope": "read"}`, exp))
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", "Authorization", "Bearer "+valid); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		wrongIssuer := sign(fmt.Sprintf(`{"sub": "alice", "iss": "https://other.example", "exp": %d, "scope": "read"}`, exp))
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", "Authorization", "Bearer "+wrongIssuer); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d for the wrong issuer, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("Authentication disabled", func(t *testing.T) {
+		t.Setenv("AUTH_DISABLED", "true")
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", ""); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+}
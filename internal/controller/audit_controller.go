@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+const auditBatchSize = 500
+
+// auditExportSignature is the last line of an export. Signature is the
+// Ed25519 signature of Digest, the SHA-256 of every byte before this line.
+type auditExportSignature struct {
+	Algorithm  string    `json:"algorithm"`
+	Entries    int64     `json:"entries"`
+	LastHash   string    `json:"lastHash"`
+	Digest     string    `json:"digest"`
+	PublicKey  string    `json:"publicKey"`
+	Signature  string    `json:"signature"`
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+// VerifyAuditLog walks the whole log in sequence order and reports the first
+// entry at which the hash chain breaks.
+func (h *ServerHandler) VerifyAuditLog(c *gin.Context) {
+	if !h.authorizeAudit(c) {
+		return
+	}
+
+	var verifier service.AuditChainVerifier
+	err := h.eachAuditEntry(func(entry *models.ServerLog, archived *models.ArchivedServerLog) bool {
+		if archived != nil {
+			return verifier.CheckArchived(archived)
+		}
+		return verifier.Check(entry)
+	})
+	if err != nil {
+		log.Printf("Error verifying audit log: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error verifying audit log",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := "Audit log verified successfully"
+	if verifier.Problem != nil {
+		message = fmt.Sprintf("Audit log chain broken at sequence %d", verifier.Problem.Sequence)
+		log.Printf("WARNING: %s: %s\n", message, verifier.Problem.Reason)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      message,
+		"valid":        verifier.Problem == nil,
+		"entries":      verifier.Entries,
+		"archived":     verifier.Archived,
+		"lastSequence": verifier.LastSeq,
+		"lastHash":     verifier.LastHash,
+		"problem":      verifier.Problem,
+	})
+}
+
+// ExportAuditLog streams the log as JSON lines, one entry per line in
+// sequence order, followed by a line holding the signature of everything
+// before it. Archived entries are exported as their ArchivedServerLog. An export that fails part way has no signature line.
+func (h *ServerHandler) ExportAuditLog(c *gin.Context) {
+	if !h.authorizeAudit(c) {
+		return
+	}
+	key, err := loadAuditSigningKey()
+	if err != nil {
+		log.Printf("Cannot export audit log: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Audit log signing is not configured",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.jsonl"`, time.Now().UTC().Format("20060102T150405Z")))
+	c.Status(http.StatusOK)
+
+	digest := sha256.New()
+	encoder := json.NewEncoder(io.MultiWriter(c.Writer, digest))
+	var entries int64
+	var lastHash string
+	var writeErr error
+	err = h.eachAuditEntry(func(entry *models.ServerLog, archived *models.ArchivedServerLog) bool {
+		if archived != nil {
+			writeErr = encoder.Encode(archived)
+			lastHash = archived.Hash
+		} else {
+			writeErr = encoder.Encode(entry)
+			lastHash = entry.Hash
+		}
+		entries++
+		return writeErr == nil
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		log.Printf("Error exporting audit log after %d entries: %v\n", entries, err)
+		return
+	}
+
+	sum := digest.Sum(nil)
+	json.NewEncoder(c.Writer).Encode(map[string]auditExportSignature{"signature": {
+		Algorithm:  "ed25519-sha256",
+		Entries:    entries,
+		LastHash:   lastHash,
+		Digest:     hex.EncodeToString(sum),
+		PublicKey:  base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature:  base64.StdEncoding.EncodeToString(ed25519.Sign(key, sum)),
+		ExportedAt: time.Now().UTC(),
+	}})
+	log.Printf("Audit log exported by %s: %d entries.\n", actorOf(c), entries)
+}
+
+// authorizeAudit admits global callers holding audit:read. The chain spans
+// every project, so credentials bound to one project cannot see it.
+func (h *ServerHandler) authorizeAudit(c *gin.Context) bool {
+	if !h.authorize(c, service.PermissionAuditRead, "") {
+		return false
+	}
+	if projectOf(c) != "" {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Credentials bound to a project cannot read the audit log."})
+		return false
+	}
+	return true
+}
+
+// eachAuditEntry calls fn with every entry of the chain in sequence order
+// until fn returns false. Entries moved to an archive by log retention are
+// passed as their ArchivedServerLog, with a nil entry. Entries written before
+// the log was chained have no sequence and are skipped.
+func (h *ServerHandler) eachAuditEntry(fn func(*models.ServerLog, *models.ArchivedServerLog) bool) error {
+	var after int64
+	for {
+		var live []models.ServerLog
+		if err := h.db.Where("sequence > ?", after).Order("sequence").Limit(auditBatchSize).Find(&live).Error; err != nil {
+			return err
+		}
+		var archived []models.ArchivedServerLog
+		if err := h.db.Where("sequence > ?", after).Order("sequence").Limit(auditBatchSize).Find(&archived).Error; err != nil {
+			return err
+		}
+		if len(live) == 0 && len(archived) == 0 {
+			return nil
+		}
+
+		// A full batch may continue past its last entry, so entries of the
+		// other batch beyond it wait for the next round.
+		bound := int64(math.MaxInt64)
+		if len(live) == auditBatchSize {
+			bound = live[len(live)-1].Sequence
+		}
+		if len(archived) == auditBatchSize {
+			bound = min(bound, archived[len(archived)-1].Sequence)
+		}
+
+		i, j := 0, 0
+		for {
+			liveNext := i < len(live) && live[i].Sequence <= bound
+			archivedNext := j < len(archived) && archived[j].Sequence <= bound
+			if liveNext && archivedNext {
+				liveNext = live[i].Sequence <= archived[j].Sequence
+				archivedNext = !liveNext
+			}
+			switch {
+			case liveNext:
+				if !fn(&live[i], nil) {
+					return nil
+				}
+				after = live[i].Sequence
+				i++
+			case archivedNext:
+				if !fn(nil, &archived[j]) {
+					return nil
+				}
+				after = archived[j].Sequence
+				j++
+			}
+			if !liveNext && !archivedNext {
+				break
+			}
+		}
+		if bound == math.MaxInt64 {
+			return nil
+		}
+	}
+}
+
+// loadAuditSigningKey reads AUDIT_SIGNING_KEY, a base64-encoded 32-byte
+// Ed25519 seed.
+func loadAuditSigningKey() (ed25519.PrivateKey, error) {
+	encoded := os.Getenv("AUDIT_SIGNING_KEY")
+	if encoded == "" {
+		return nil, errors.New("AUDIT_SIGNING_KEY is not set")
+	}
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("AUDIT_SIGNING_KEY must be a base64-encoded %d-byte seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestAuditLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testDB, h := setupTestDB(t)
+
+	t.Setenv("AUTH_DISABLED", "true")
+	t.Setenv("AUDIT_SIGNING_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, ed25519.SeedSize)))
+
+	router := gin.Default()
+	api := router.Group("/api", middleware.RequestMetadata, middleware.Authenticate(testDB))
+	api.POST("/server", h.CreateServer)
+	api.POST("/servers/:id/action", h.CompleteAction)
+	api.GET("/audit/verify", h.VerifyAuditLog)
+	api.GET("/audit/export", h.ExportAuditLog)
+
+	verify := func() (bool, int64) {
+		rec := sendJSON(t, router, http.MethodGet, "/api/audit/verify", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Valid   bool  `json:"valid"`
+			Entries int64 `json:"entries"`
+			Problem *struct {
+				Sequence int64 `json:"sequence"`
+			} `json:"problem"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if body.Problem != nil {
+			return body.Valid, body.Problem.Sequence
+		}
+		return body.Valid, body.Entries
+	}
+
+	createBody := `{"region": "India", "type": "basic"}`
+	id := createTestServer(t, router, createBody, middleware.RequestIDHeader, "req-create")
+	sendJSON(t, router, http.MethodPost, "/api/servers/"+id+"/action", `{"action": "stop"}`)
+	sendJSON(t, router, http.MethodPost, "/api/servers/"+id+"/action", `{"action": "start"}`)
+
+	t.Run("Entries record the request", func(t *testing.T) {
+		var entry models.ServerLog
+		testDB.Where("server_id = ?", id).Order("sequence").First(&entry)
+		digest := sha256.Sum256([]byte(createBody))
+		if entry.Sequence != 1 || entry.PrevHash != "" || entry.Hash == "" {
+			t.Errorf("Expected the first entry of the chain, got %+v", entry)
+		}
+		if entry.RequestID != "req-create" || entry.SourceIP != "192.0.2.1" || entry.BodyDigest != hex.EncodeToString(digest[:]) {
+			t.Errorf("Expected the request to be recorded, got %+v", entry)
+		}
+	})
+
+	t.Run("Intact chain verifies", func(t *testing.T) {
+		if valid, entries := verify(); !valid || entries < 3 {
+			t.Errorf("Expected a valid chain of at least 3 entries, got valid=%v entries=%d", valid, entries)
+		}
+	})
+
+	t.Run("Export is signed", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodGet, "/api/audit/export", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		data := rec.Body.Bytes()
+		end := bytes.LastIndexByte(data[:len(data)-1], '\n') + 1
+		var trailer struct {
+			Signature auditExportSignature `json:"signature"`
+		}
+		if err := json.Unmarshal(data[end:], &trailer); err != nil {
+			t.Fatalf("Cannot read signature line: %v", err)
+		}
+		lines := 0
+		for scanner := bufio.NewScanner(bytes.NewReader(data[:end])); scanner.Scan(); lines++ {
+		}
+		if int64(lines) != trailer.Signature.Entries {
+			t.Errorf("Expected %d entries, got %d lines", trailer.Signature.Entries, lines)
+		}
+		publicKey, _ := base64.StdEncoding.DecodeString(trailer.Signature.PublicKey)
+		signature, _ := base64.StdEncoding.DecodeString(trailer.Signature.Signature)
+		sum := sha256.Sum256(data[:end])
+		if !ed25519.Verify(publicKey, sum[:], signature) {
+			t.Error("Export signature does not verify")
+		}
+		sum = sha256.Sum256(append([]byte(" "), data[:end]...))
+		if ed25519.Verify(publicKey, sum[:], signature) {
+			t.Error("Signature verifies for altered content")
+		}
+	})
+
+	t.Run("Model refuses changes", func(t *testing.T) {
+		var entry models.ServerLog
+		testDB.Where("sequence = ?", 2).First(&entry)
+		if err := testDB.Model(&entry).Update("message", "edited").Error; !errors.Is(err, models.ErrServerLogImmutable) {
+			t.Errorf("Expected update to be refused, got %v", err)
+		}
+		if err := testDB.Delete(&entry).Error; !errors.Is(err, models.ErrServerLogImmutable) {
+			t.Errorf("Expected delete to be refused, got %v", err)
+		}
+	})
+
+	t.Run("Tampering is detected", func(t *testing.T) {
+		testDB.Exec("UPDATE server_logs SET message = ? WHERE sequence = ?", "nothing happened", 2)
+		if valid, sequence := verify(); valid || sequence != 2 {
+			t.Errorf("Expected the chain to break at sequence 2, got valid=%v sequence=%d", valid, sequence)
+		}
+	})
+}
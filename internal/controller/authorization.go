@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+// authorize checks that the caller's roles grant permission. A denial is
+// answered with 403 and recorded as PERMISSION_DENIED against serverId, which
+// is empty when the request does not target an existing server. Requests that
+// did not pass through middleware.Authenticate carry no principal and are not
+// checked; every /api route does.
+func (h *ServerHandler) authorize(c *gin.Context, permission, serverId string) bool {
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		return true
+	}
+
+	policy, err := loadPolicy()
+	if err != nil {
+		log.Printf("ERROR: Cannot load access policy: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error loading access policy",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	if policy.Allows(principal, permission) {
+		return true
+	}
+
+	roles := policy.RolesOf(principal)
+	message := fmt.Sprintf("Permission '%s' denied to %s (roles: %s).", permission, principal.Actor(), strings.Join(roles, ", "))
+	if len(roles) == 0 {
+		message = fmt.Sprintf("Permission '%s' denied to %s (no roles).", permission, principal.Actor())
+	}
+	h.events.LogServerEventAs(originOf(c), serverId, service.CodePermissionDenied, message, nil, nil)
+	c.JSON(http.StatusForbidden, gin.H{
+		"message": message,
+		"code":    service.CodePermissionDenied,
+	})
+	return false
+}
+
+var policyCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	policy  *service.Policy
+}
+
+// loadPolicy reads POLICY_FILE, re-reading it whenever it changes. Without a
+// file the default viewer/operator/admin policy applies.
+func loadPolicy() (*service.Policy, error) {
+	path := os.Getenv("POLICY_FILE")
+	if path == "" {
+		return service.DefaultPolicy(), nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policyCache.Lock()
+	defer policyCache.Unlock()
+	if policyCache.policy != nil && policyCache.path == path && policyCache.modTime.Equal(info.ModTime()) {
+		return policyCache.policy, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := service.ParsePolicy(data)
+	if err != nil {
+		return nil, err
+	}
+	policyCache.path, policyCache.modTime, policyCache.policy = path, info.ModTime(), policy
+	return policy, nil
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+func TestAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testDB, h := setupTestDB(t)
+
+	t.Setenv("AUTH_DISABLED", "")
+	t.Setenv("ADMIN_API_KEY", "vs_bootstrap-secret")
+	t.Setenv("POLICY_FILE", "")
+
+	router := gin.Default()
+	api := router.Group("/api", middleware.Authenticate(testDB))
+	api.POST("/server", h.CreateServer)
+	api.POST("/servers/:id/action", h.CompleteAction)
+	api.GET("/servers/:id/logs", h.GetLogs)
+	api.POST("/admin/api-keys", h.CreateAPIKey)
+	api.POST("/security-groups", h.CreateSecurityGroup)
+	api.GET("/security-groups", h.ListSecurityGroups)
+	api.POST("/load-balancers", h.CreateLoadBalancer)
+	api.GET("/load-balancers", h.ListLoadBalancers)
+	api.POST("/dns/zones", h.CreateDNSZone)
+	api.POST("/key-pairs", h.CreateKeyPair)
+	api.GET("/key-pairs", h.ListKeyPairs)
+	api.GET("/billing/report", h.GetBillingReport)
+
+	issue := func(body string) string {
+		rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", body, "X-API-Key", "vs_bootstrap-secret")
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var created struct {
+			Key string `json:"key"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &created)
+		return created.Key
+	}
+	action := func(key, id, action string) *httptest.ResponseRecorder {
+		return sendJSON(t, router, http.MethodPost, "/api/servers/"+id+"/action", fmt.Sprintf(`{"action": "%s"}`, action), "X-API-Key", key)
+	}
+
+	operator := issue(`{"name": "junior", "scopes": ["write"], "roles": ["operator"]}`)
+	viewer := issue(`{"name": "auditor", "scopes": ["write"], "roles": ["viewer"]}`)
+
+	t.Run("Unknown role", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", `{"name": "x", "scopes": ["read"], "roles": ["owner"]}`, "X-API-Key", "vs_bootstrap-secret")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Keys cannot grant more than their issuer holds", func(t *testing.T) {
+		// An admin-scoped key whose role is only viewer.
+		limited := issue(`{"name": "limited-admin", "scopes": ["admin"], "roles": ["viewer"]}`)
+		for _, body := range []string{
+			`{"name": "escalated", "scopes": ["admin"], "roles": ["admin"]}`,
+			`{"name": "escalated", "scopes": ["admin"]}`,
+		} {
+			if rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", body, "X-API-Key", limited); rec.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d issuing %s, got %d: %s", http.StatusForbidden, body, rec.Code, rec.Body.String())
+			}
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", `{"name": "promoted", "scopes": ["write"], "roles": ["operator"]}`, "X-API-Key", operator); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for a write-scoped caller issuing keys, got %d", http.StatusForbidden, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", `{"name": "read-only", "scopes": ["read"], "roles": ["viewer"]}`, "X-API-Key", "vs_bootstrap-secret"); rec.Code != http.StatusCreated {
+			t.Errorf("Expected status %d for the bootstrap key, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("Operator starts and stops but cannot terminate", func(t *testing.T) {
+		id := createTestServer(t, router, `{"region": "India", "type": "basic"}`, "X-API-Key", operator)
+		if rec := action(operator, id, "stop"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for stop, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if rec := action(operator, id, "start"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for start, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		rec := action(operator, id, "terminate")
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d for terminate, got %d", http.StatusForbidden, rec.Code)
+		}
+		var denied struct {
+			Code string `json:"code"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &denied)
+		if denied.Code != service.CodePermissionDenied {
+			t.Errorf("Expected code %s, got %q", service.CodePermissionDenied, denied.Code)
+		}
+
+		var server models.Server
+		testDB.First(&server, "id = ?", id)
+		if server.Status != service.StatusRunning {
+			t.Errorf("Expected the server to keep running, got %s", server.Status)
+		}
+		var entry models.ServerLog
+		testDB.Where("server_id = ? AND event_type = ?", id, service.CodePermissionDenied).First(&entry)
+		if entry.Actor != "api-key:junior" {
+			t.Errorf("Expected the denial to be logged for api-key:junior, got %q", entry.Actor)
+		}
+
+		if rec := sendJSON(t, router, http.MethodPost, "/api/server", `{"region": "India", "type": "basic", "ttl": "1h"}`, "X-API-Key", operator); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for a server with an expiry, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("Viewer reads logs only", func(t *testing.T) {
+		id := createTestServer(t, router, `{"region": "India", "type": "basic"}`, "X-API-Key", operator)
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers/"+id+"/logs", "", "X-API-Key", viewer); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/server", `{"region": "India", "type": "basic"}`, "X-API-Key", viewer); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for create, got %d", http.StatusForbidden, rec.Code)
+		}
+		if rec := action(viewer, id, "stop"); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for stop, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("Viewer cannot change networking", func(t *testing.T) {
+		for _, tt := range []struct{ path, body string }{
+			{"/api/security-groups", `{"name": "web"}`},
+			{"/api/load-balancers", `{"name": "lb"}`},
+			{"/api/dns/zones", `{"name": "example.com"}`},
+			{"/api/key-pairs", `{"name": "laptop"}`},
+		} {
+			if rec := sendJSON(t, router, http.MethodPost, tt.path, tt.body, "X-API-Key", viewer); rec.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusForbidden, tt.path, rec.Code)
+			}
+		}
+		for _, path := range []string{"/api/security-groups", "/api/load-balancers", "/api/key-pairs", "/api/billing/report"} {
+			if rec := sendJSON(t, router, http.MethodGet, path, "", "X-API-Key", viewer); rec.Code != http.StatusOK {
+				t.Errorf("Expected status %d for %s, got %d: %s", http.StatusOK, path, rec.Code, rec.Body.String())
+			}
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/security-groups", `{"name": "web"}`, "X-API-Key", operator); rec.Code != http.StatusCreated {
+			t.Errorf("Expected status %d for an operator, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("Policy file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		policy := `
+roles:
+  operator: ["servers:create", "servers:action:*"]
+  viewer: ["servers:read"]
+bindings:
+  "api-key:auditor": [operator]
+`
+		if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+		t.Setenv("POLICY_FILE", path)
+
+		id := createTestServer(t, router, `{"region": "India", "type": "basic"}`, "X-API-Key", viewer)
+		if rec := action(operator, id, "terminate"); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d for terminate, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers/"+id+"/logs", "", "X-API-Key", viewer); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for logs without logs:read, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+)
+
+const untaggedGroup = "(untagged)"
+
+type billingLine struct {
+	ServerID    string            `json:"serverId"`
+	Name        string            `json:"name,omitempty"`
+	Region      string            `json:"region"`
+	Type        string            `json:"type"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	BilledHours float64           `json:"billedHours"`
+	Cost        float64           `json:"cost"`
+}
+
+// recordBillingTransition closes the server's open billing period and opens a
+// new one reflecting its current status, type and rate.
+func (h *ServerHandler) recordBillingTransition(server *models.Server) {
+	now := time.Now()
+	if err := h.db.Model(&models.BillingPeriod{}).
+		Where("server_id = ? AND ended_at IS NULL", server.ID).
+		Update("ended_at", now).Error; err != nil {
+		log.Printf("WARNING: Failed to close billing period for server %s: %v\n", server.ID, err)
+	}
+
+	if server.Status == service.StatusTerminated {
+		return
+	}
+
+	period := models.BillingPeriod{
+		ID:        uuid.New().String(),
+		ServerID:  server.ID,
+		ProjectID: server.ProjectID,
+		Type:      server.Type,
+		Status:    server.Status,
+		Rate:      service.BilledRate(server.Status, server.BillingRate),
+		StartedAt: now,
+	}
+	if err := h.db.Create(&period).Error; err != nil {
+		log.Printf("WARNING: Failed to open billing period for server %s: %v\n", server.ID, err)
+	}
+}
+
+// GetBillingReport totals the cost of every server over a window (the current
+// month by default), optionally filtered by a tag selector and grouped by the
+// value of one tag key for cost allocation.
+func (h *ServerHandler) GetBillingReport(c *gin.Context) {
+	if !h.authorize(c, service.PermissionBillingRead, "") {
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := now
+
+	for param, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"message": "Query parameter '" + param + "' must be an RFC 3339 timestamp.",
+				})
+				return
+			}
+			*target = parsed
+		}
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "'to' must be after 'from'."})
+		return
+	}
+
+	selector, err := service.ParseSelector(c.Query("selector"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid selector",
+			"error":   err.Error(),
+		})
+		return
+	}
+	groupBy := c.Query("groupBy")
+
+	var periods []models.BillingPeriod
+	if err := scopeToProject(h.db, projectOf(c)).Where("started_at < ? AND (ended_at IS NULL OR ended_at > ?)", to, from).
+		Find(&periods).Error; err != nil {
+		log.Printf("Error fetching billing periods: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching billing data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	serverIds := make([]string, 0, len(periods))
+	for _, period := range periods {
+		serverIds = append(serverIds, period.ServerID)
+	}
+
+	servers, err := h.servers.ListByIDs(serverIds)
+	if err != nil {
+		log.Printf("Error fetching servers for billing: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching billing data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	lines := make(map[string]*billingLine)
+	for _, server := range servers {
+		if !selector.Matches(server.Tags) {
+			continue
+		}
+		lines[server.ID] = &billingLine{
+			ServerID: server.ID,
+			Name:     server.Name,
+			Region:   server.Region,
+			Type:     server.Type,
+			Tags:     server.Tags,
+		}
+	}
+
+	for _, period := range periods {
+		line, ok := lines[period.ServerID]
+		if !ok {
+			continue
+		}
+		hours, cost := service.PeriodCost(period.Rate, period.StartedAt, period.EndedAt, from, to)
+		if period.Rate > 0 {
+			line.BilledHours += hours
+		}
+		line.Cost += cost
+	}
+
+	report := make([]billingLine, 0, len(lines))
+	groups := make(map[string]float64)
+	total := 0.0
+	for _, line := range lines {
+		line.BilledHours = roundCents(line.BilledHours)
+		line.Cost = roundCents(line.Cost)
+		report = append(report, *line)
+		total += line.Cost
+
+		if groupBy != "" {
+			group, ok := line.Tags[groupBy]
+			if !ok {
+				group = untaggedGroup
+			}
+			groups[group] = roundCents(groups[group] + line.Cost)
+		}
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Cost > report[j].Cost })
+
+	response := gin.H{
+		"message": "Billing report generated successfully",
+		"from":    from,
+		"to":      to,
+		"total":   roundCents(total),
+		"servers": report,
+	}
+	if groupBy != "" {
+		response["groupBy"] = groupBy
+		response["groups"] = groups
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (h *ServerHandler) CreateDNSZone(c *gin.Context) {
+	if !h.authorize(c, service.PermissionDNSWrite, "") {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	name := service.NormalizeDNSName(req.Name)
+	if errorMessage := service.ValidateDNSName(name); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	var existing int64
+	h.db.Model(&models.DNSZone{}).Where("name = ?", name).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("DNS zone '%s' already exists.", name),
+		})
+		return
+	}
+
+	zone := models.DNSZone{ID: uuid.New().String(), ProjectID: projectOf(c), Name: name}
+	if err := h.db.Create(&zone).Error; err != nil {
+		log.Printf("Error creating DNS zone '%s': %v\n", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error creating DNS zone",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "DNS zone created successfully",
+		"zone":    zone,
+	})
+}
+
+func (h *ServerHandler) ListDNSZones(c *gin.Context) {
+	if !h.authorize(c, service.PermissionDNSRead, "") {
+		return
+	}
+
+	var zones []models.DNSZone
+	if err := scopeToProject(h.db.Order("name"), projectOf(c)).Find(&zones).Error; err != nil {
+		log.Printf("Error fetching DNS zones: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching DNS zones",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "DNS zones fetched successfully",
+		"zones":   zones,
+	})
+}
+
+func (h *ServerHandler) GetDNSZone(c *gin.Context) {
+	if !h.authorize(c, service.PermissionDNSRead, "") {
+		return
+	}
+
+	zone, ok := h.findDNSZone(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "DNS zone fetched successfully",
+		"zone":    zone,
+	})
+}
+
+func (h *ServerHandler) DeleteDNSZone(c *gin.Context) {
+	if !h.authorize(c, service.PermissionDNSWrite, "") {
+		return
+	}
+
+	zone, ok := h.findDNSZone(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("zone_id = ?", zone.ID).Delete(&models.DNSRecord{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(zone).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting DNS zone '%s': %v\n", zone.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting DNS zone",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "DNS zone deleted successfully"})
+}
+
+func (h *ServerHandler) CreateDNSRecord(c *gin.Context) {
+	if !h.authorize(c, service.PermissionDNSWrite, "") {
+		return
+	}
+
+	zone, ok := h.findDNSZone(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name  string `json:"name"`
+		Type  string `json:"type"`
+		Value string `json:"value"`
+		TTL   int    `json:"ttl"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if errorMessage := service.ValidateDNSRecord(&req.Type, &req.Name, &req.Value, &req.TTL, zone.Name); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	if errorMessage := h.checkCNAMEConflict(zone.ID, req.Name, req.Type); errorMessage != "" {
+		c.JSON(http.StatusConflict, gin.H{"message": errorMessage})
+		return
+	}
+
+	record := models.DNSRecord{
+		ID:     uuid.New().String(),
+		ZoneID: zone.ID,
+		Name:   req.Name,
+		Type:   req.Type,
+		Value:  req.Value,
+		TTL:    req.TTL,
+	}
+	if err := h.db.Create(&record).Error; err != nil {
+		log.Printf("Error creating DNS record '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error creating DNS record",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "DNS record created successfully",
+		"record":  record,
+	})
+}
+
+func (h *ServerHandler) DeleteDNSRecord(c *gin.Context) {
+	if !h.authorize(c, service.PermissionDNSWrite, "") {
+		return
+	}
+
+	zone, ok := h.findDNSZone(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	recordId := c.Param("recordId")
+	result := h.db.Where("id = ? AND zone_id = ?", recordId, zone.ID).Delete(&models.DNSRecord{})
+	if result.Error != nil {
+		log.Printf("Error deleting DNS record '%s': %v\n", recordId, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting DNS record",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("DNS record with ID '%s' not found.", recordId),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "DNS record deleted successfully"})
+}
+
+// checkCNAMEConflict enforces that a CNAME is the only record at its name.
+func (h *ServerHandler) checkCNAMEConflict(zoneId, name, recordType string) string {
+	query := h.db.Model(&models.DNSRecord{}).Where("zone_id = ? AND name = ?", zoneId, name)
+	if recordType != service.RecordTypeCNAME {
+		query = query.Where("type = ?", service.RecordTypeCNAME)
+	}
+
+	var count int64
+	query.Count(&count)
+	if count > 0 {
+		return fmt.Sprintf("'%s' already has a CNAME record or other records that conflict with one.", name)
+	}
+	return ""
+}
+
+// serverFQDN qualifies a short hostname with DNS_DEFAULT_ZONE.
+func serverFQDN(hostname string) string {
+	name := service.NormalizeDNSName(hostname)
+	if !strings.Contains(name, ".") {
+		if zone := service.NormalizeDNSName(os.Getenv("DNS_DEFAULT_ZONE")); zone != "" {
+			name += "." + zone
+		}
+	}
+	return name
+}
+
+// registerServerDNS creates the A record for a server's hostname in the most
+// specific zone of its project containing it. Servers outside every zone are
+// left alone.
+func (h *ServerHandler) registerServerDNS(server *models.Server) {
+	if server.Hostname == "" || server.PrivateIP == "" {
+		return
+	}
+
+	name := serverFQDN(server.Hostname)
+
+	var zones []models.DNSZone
+	if err := scopeToProject(h.db, server.ProjectID).Find(&zones).Error; err != nil {
+		log.Printf("WARNING: Failed to load DNS zones for server %s: %v\n", server.ID, err)
+		return
+	}
+
+	var zone *models.DNSZone
+	for i := range zones {
+		if service.InZone(name, zones[i].Name) && (zone == nil || len(zones[i].Name) > len(zone.Name)) {
+			zone = &zones[i]
+		}
+	}
+	if zone == nil {
+		log.Printf("No DNS zone contains '%s'; skipping registration for server %s\n", name, server.ID)
+		return
+	}
+
+	if errorMessage := h.checkCNAMEConflict(zone.ID, name, service.RecordTypeA); errorMessage != "" {
+		h.events.LogServerEvent(server.ID, "DNS_REGISTRATION_FAILED", errorMessage, nil, nil)
+		return
+	}
+
+	record := models.DNSRecord{
+		ID:       uuid.New().String(),
+		ZoneID:   zone.ID,
+		Name:     name,
+		Type:     service.RecordTypeA,
+		Value:    server.PrivateIP,
+		TTL:      service.DefaultRecordTTL,
+		ServerID: server.ID,
+	}
+	if err := h.db.Create(&record).Error; err != nil {
+		log.Printf("WARNING: Failed to register DNS for server %s: %v\n", server.ID, err)
+		return
+	}
+
+	h.events.LogServerEvent(server.ID, "DNS_RECORD_REGISTERED",
+		fmt.Sprintf("Registered %s A %s.", record.Name, record.Value), nil, nil)
+}
+
+func (h *ServerHandler) deregisterServerDNS(serverId string) {
+	var records []models.DNSRecord
+	if err := h.db.Where("server_id = ?", serverId).Find(&records).Error; err != nil {
+		log.Printf("WARNING: Failed to load DNS records for server %s: %v\n", serverId, err)
+		return
+	}
+
+	for _, record := range records {
+		if err := h.db.Delete(&record).Error; err != nil {
+			log.Printf("WARNING: Failed to remove DNS record %s: %v\n", record.ID, err)
+			continue
+		}
+		h.events.LogServerEvent(serverId, "DNS_RECORD_REMOVED",
+			fmt.Sprintf("Removed %s %s %s.", record.Name, record.Type, record.Value), nil, nil)
+	}
+}
+
+func (h *ServerHandler) findDNSZone(c *gin.Context, zoneId string) (*models.DNSZone, bool) {
+	var zone models.DNSZone
+	result := scopeToProject(h.db.Preload("Records"), projectOf(c)).First(&zone, "id = ?", zoneId)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("DNS zone with ID '%s' not found.", zoneId),
+			})
+			return nil, false
+		}
+		log.Printf("Error fetching DNS zone '%s': %v\n", zoneId, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching DNS zone",
+			"error":   result.Error.Error(),
+		})
+		return nil, false
+	}
+	return &zone, true
+}
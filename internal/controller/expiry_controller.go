@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+const (
+	defaultExpiryWarningLead = 15 * time.Minute
+	reaperBatchSize          = 500
+)
+
+var reaperOrigin = logger.System("reaper")
+
+// ExtendServerExpiry moves a server's expiry. extendBy pushes the current
+// expiry back, ttl and expiresAt replace it, and clear removes it altogether.
+func (h *ServerHandler) ExtendServerExpiry(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.ActionPermission(service.ActionTerminate), server.ID) {
+		return
+	}
+
+	var req struct {
+		ExtendBy  string     `json:"extendBy"`
+		TTL       string     `json:"ttl"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+		Clear     bool       `json:"clear"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if server.Status == service.StatusTerminated {
+		c.JSON(http.StatusConflict, gin.H{"message": "Cannot change the expiry of a terminated server."})
+		return
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	switch {
+	case req.Clear:
+		if req.ExtendBy != "" || req.TTL != "" || req.ExpiresAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "clear cannot be combined with a new expiry."})
+			return
+		}
+	case req.ExtendBy != "":
+		if req.TTL != "" || req.ExpiresAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Specify only one of extendBy, ttl or expiresAt."})
+			return
+		}
+		if server.ExpiresAt == nil {
+			c.JSON(http.StatusConflict, gin.H{"message": "Server has no expiry to extend. Set ttl or expiresAt instead."})
+			return
+		}
+		extendBy, err := time.ParseDuration(req.ExtendBy)
+		if err != nil || extendBy <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Invalid extendBy '%s'. Use a positive duration such as '2h'.", req.ExtendBy),
+			})
+			return
+		}
+		extended := server.ExpiresAt.Add(extendBy)
+		var errorMessage string
+		if expiresAt, errorMessage = service.ResolveExpiry(&extended, "", now); errorMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+			return
+		}
+	default:
+		var errorMessage string
+		expiresAt, errorMessage = service.ResolveExpiry(req.ExpiresAt, req.TTL, now)
+		if errorMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+			return
+		}
+		if expiresAt == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Specify extendBy, ttl, expiresAt or clear."})
+			return
+		}
+	}
+
+	// A new expiry deserves a new warning, and a new denial if it passes.
+	server.ExpiresAt = expiresAt
+	server.ExpiryWarnedAt = nil
+	server.ExpiryDeniedAt = nil
+	if err := h.servers.Update(server, "expires_at", "expiry_warned_at", "expiry_denied_at"); err != nil {
+		log.Printf("Error updating expiry for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error updating server expiry",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := "Expiry removed."
+	if expiresAt != nil {
+		message = fmt.Sprintf("Server expires at %s.", expiresAt.Format(time.RFC3339))
+	}
+	h.events.LogServerChange(originOf(c), server.ID, "EXPIRY_SET", message, nil, nil,
+		map[string]string{service.FieldExpiresAt: service.EncodeExpiry(expiresAt)})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Server expiry updated successfully",
+		"id":        server.ID,
+		"expiresAt": expiresAt,
+	})
+}
+
+// RunReaper warns about and terminates expiring servers every interval until
+// the process exits.
+func (h *ServerHandler) RunReaper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.ReapExpiredServers(time.Now())
+		<-ticker.C
+	}
+}
+
+// ReapExpiredServers logs EXPIRY_WARNING for servers expiring within the lead
+// time and terminates servers whose expiry has passed, with the EXPIRED
+// termination reason. Each server is claimed with a conditional update so the
+// warning is only emitted once. Setting the expiry counts as confirming the
+// termination of a production server; termination protection still applies,
+// and a protected server is logged as denied once for each expiry.
+func (h *ServerHandler) ReapExpiredServers(now time.Time) {
+	now = now.UTC()
+	lead := expiryWarningLead()
+
+	expiring, err := h.servers.FindExpiring(now, now.Add(lead), reaperBatchSize)
+	if err != nil {
+		log.Printf("WARNING: Failed to load expiring servers: %v\n", err)
+	}
+	for _, server := range expiring {
+		if claimed, err := h.servers.ClaimExpiryWarning(server.ID, now); err != nil || !claimed {
+			continue
+		}
+		h.events.LogServerEventAs(reaperOrigin, server.ID, "EXPIRY_WARNING",
+			fmt.Sprintf("Server will be terminated at %s (in %s).", server.ExpiresAt.Format(time.RFC3339), server.ExpiresAt.Sub(now).Round(time.Second)), nil, nil)
+	}
+
+	// A protected server is denied termination once for each expiry rather
+	// than on every pass.
+	protected, err := h.servers.FindExpiredProtected(now, reaperBatchSize)
+	if err != nil {
+		log.Printf("WARNING: Failed to load expired protected servers: %v\n", err)
+	}
+	for i := range protected {
+		server := &protected[i]
+		if claimed, err := h.servers.ClaimExpiryDenial(server.ID, now); err != nil || !claimed {
+			continue
+		}
+		h.applyServerAction(server, service.ActionTerminate, actionOptions{
+			Reason:    service.TerminationReasonExpired,
+			Confirmed: true,
+			Origin:    reaperOrigin,
+		})
+	}
+
+	expired, err := h.servers.FindExpired(now, reaperBatchSize)
+	if err != nil {
+		log.Printf("WARNING: Failed to load expired servers: %v\n", err)
+		return
+	}
+	for i := range expired {
+		server := &expired[i]
+		result := h.applyServerAction(server, service.ActionTerminate, actionOptions{
+			Reason:    service.TerminationReasonExpired,
+			Confirmed: true,
+			Origin:    reaperOrigin,
+		})
+		if result.Outcome != OutcomeChanged {
+			log.Printf("WARNING: Failed to terminate expired server %s: %s\n", server.ID, result.Reason)
+			continue
+		}
+		h.events.LogServerEventAs(reaperOrigin, server.ID, "SERVER_EXPIRED",
+			fmt.Sprintf("Server expired at %s and was terminated.", server.ExpiresAt.Format(time.RFC3339)), nil, nil)
+	}
+}
+
+func expiryWarningLead() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("EXPIRY_WARNING_MINUTES")); err == nil && minutes >= 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultExpiryWarningLead
+}
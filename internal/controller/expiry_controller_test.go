@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+func TestServerExpiry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.PUT("/api/servers/:id/expiry", h.ExtendServerExpiry)
+
+	load := func(id string) models.Server {
+		var server models.Server
+		testDB.First(&server, "id = ?", id)
+		return server
+	}
+	events := func(id, eventType string) int64 {
+		var count int64
+		testDB.Model(&models.ServerLog{}).Where("server_id = ? AND event_type = ?", id, eventType).Count(&count)
+		return count
+	}
+
+	t.Run("Invalid expiry", func(t *testing.T) {
+		for _, body := range []string{
+			`{"region": "India", "type": "basic", "ttl": "soon"}`,
+			`{"region": "India", "type": "basic", "ttl": "-1h"}`,
+			`{"region": "India", "type": "basic", "ttl": "1h", "expiresAt": "2099-01-01T00:00:00Z"}`,
+		} {
+			if rec := sendJSON(t, router, http.MethodPost, "/api/server", body); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, rec.Code)
+			}
+		}
+	})
+
+	ci := createTestServer(t, router, `{"region": "India", "type": "basic", "name": "ci-1", "ttl": "1h"}`)
+	keep := createTestServer(t, router, `{"region": "India", "type": "basic", "name": "keep"}`)
+	expiresAt := *load(ci).ExpiresAt
+
+	t.Run("Warning before expiry", func(t *testing.T) {
+		h.ReapExpiredServers(expiresAt.Add(-time.Hour + time.Minute))
+		if events(ci, "EXPIRY_WARNING") != 0 {
+			t.Fatalf("Warning emitted too early")
+		}
+		h.ReapExpiredServers(expiresAt.Add(-10 * time.Minute))
+		h.ReapExpiredServers(expiresAt.Add(-5 * time.Minute))
+		if n := events(ci, "EXPIRY_WARNING"); n != 1 {
+			t.Errorf("Expected one warning, got %d", n)
+		}
+	})
+
+	t.Run("Extend", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodPut, "/api/servers/ci-1/expiry", `{"extendBy": "30m"}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if got := *load(ci).ExpiresAt; !got.Equal(expiresAt.Add(30 * time.Minute)) {
+			t.Errorf("Expected expiry %s, got %s", expiresAt.Add(30*time.Minute), got)
+		}
+		h.ReapExpiredServers(expiresAt.Add(20 * time.Minute))
+		if load(ci).Status != service.StatusRunning {
+			t.Errorf("Extended server was reaped")
+		}
+		if n := events(ci, "EXPIRY_WARNING"); n != 2 {
+			t.Errorf("Expected a new warning after extending, got %d warnings", n)
+		}
+		if rec := sendJSON(t, router, http.MethodPut, "/api/servers/keep/expiry", `{"extendBy": "30m"}`); rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d extending a server without expiry, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("Reap", func(t *testing.T) {
+		h.ReapExpiredServers(expiresAt.Add(31 * time.Minute))
+		server := load(ci)
+		if server.Status != service.StatusTerminated || server.TerminationReason != service.TerminationReasonExpired {
+			t.Errorf("Expected server terminated as EXPIRED, got %s (%s)", server.Status, server.TerminationReason)
+		}
+		if events(ci, "SERVER_EXPIRED") != 1 {
+			t.Errorf("Expected SERVER_EXPIRED to be logged")
+		}
+		if load(keep).Status != service.StatusRunning {
+			t.Errorf("Server without expiry was reaped")
+		}
+	})
+
+	t.Run("Protected", func(t *testing.T) {
+		id := createTestServer(t, router, `{"region": "India", "type": "basic", "name": "guarded", "ttl": "1h", "terminationProtection": true}`)
+		expiresAt := *load(id).ExpiresAt
+		h.ReapExpiredServers(expiresAt.Add(time.Minute))
+		h.ReapExpiredServers(expiresAt.Add(2 * time.Minute))
+		if load(id).Status != service.StatusRunning {
+			t.Fatalf("Protected server was reaped")
+		}
+		if n := events(id, "ACTION_DENIED"); n != 1 {
+			t.Errorf("Expected one denial for the expiry, got %d", n)
+		}
+
+		// A new expiry is denied again once it passes.
+		if rec := sendJSON(t, router, http.MethodPut, "/api/servers/guarded/expiry", `{"extendBy": "1h"}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		h.ReapExpiredServers(expiresAt.Add(time.Hour + time.Minute))
+		if n := events(id, "ACTION_DENIED"); n != 2 {
+			t.Errorf("Expected a second denial for the new expiry, got %d", n)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateKeyPair imports the supplied public key, or generates a new pair when
+// none is given. A generated private key is only ever returned here.
+func (h *ServerHandler) CreateKeyPair(c *gin.Context) {
+	if !h.authorize(c, service.PermissionKeyPairsWrite, "") {
+		return
+	}
+
+	var req struct {
+		Name      string `json:"name"`
+		PublicKey string `json:"publicKey"`
+		Type      string `json:"type"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if errorMessage := service.ValidateKeyPairName(req.Name); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	var existing int64
+	scopeToProject(h.db.Model(&models.KeyPair{}), projectOf(c)).Where("name = ?", req.Name).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Key pair with name '%s' already exists.", req.Name),
+		})
+		return
+	}
+
+	var parsed *service.ParsedPublicKey
+	var privateKey string
+
+	if req.PublicKey != "" {
+		var errorMessage string
+		parsed, errorMessage = service.ParsePublicKey(req.PublicKey)
+		if errorMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+			return
+		}
+	} else {
+		var err error
+		parsed, privateKey, err = service.GenerateKeyPair(req.Type, req.Name)
+		if err != nil {
+			log.Printf("Error generating key pair '%s': %v\n", req.Name, err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Error generating key pair",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	keyPair := models.KeyPair{
+		ID:          uuid.New().String(),
+		ProjectID:   projectOf(c),
+		Name:        req.Name,
+		Type:        parsed.Type,
+		PublicKey:   parsed.PublicKey,
+		Fingerprint: parsed.Fingerprint,
+	}
+
+	if err := h.db.Create(&keyPair).Error; err != nil {
+		log.Printf("Error saving key pair '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error saving key pair",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response := gin.H{
+		"message": "Key pair created successfully",
+		"keyPair": keyPair,
+	}
+	if privateKey != "" {
+		response["privateKey"] = privateKey
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *ServerHandler) ListKeyPairs(c *gin.Context) {
+	if !h.authorize(c, service.PermissionKeyPairsRead, "") {
+		return
+	}
+
+	var keyPairs []models.KeyPair
+	if err := scopeToProject(h.db.Order("name"), projectOf(c)).Find(&keyPairs).Error; err != nil {
+		log.Printf("Error fetching key pairs: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching key pairs",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Key pairs fetched successfully",
+		"keyPairs": keyPairs,
+	})
+}
+
+func (h *ServerHandler) GetKeyPair(c *gin.Context) {
+	if !h.authorize(c, service.PermissionKeyPairsRead, "") {
+		return
+	}
+
+	keyPair, ok := h.findKeyPair(c, c.Param("name"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Key pair fetched successfully",
+		"keyPair": keyPair,
+	})
+}
+
+func (h *ServerHandler) DeleteKeyPair(c *gin.Context) {
+	if !h.authorize(c, service.PermissionKeyPairsWrite, "") {
+		return
+	}
+
+	keyPair, ok := h.findKeyPair(c, c.Param("name"))
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(keyPair).Error; err != nil {
+		log.Printf("Error deleting key pair '%s': %v\n", keyPair.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting key pair",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Key pair deleted successfully"})
+}
+
+func (h *ServerHandler) findKeyPair(c *gin.Context, name string) (*models.KeyPair, bool) {
+	var keyPair models.KeyPair
+	result := scopeToProject(h.db, projectOf(c)).First(&keyPair, "name = ?", name)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Key pair '%s' not found.", name),
+			})
+			return nil, false
+		}
+		log.Printf("Error fetching key pair '%s': %v\n", name, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching key pair",
+			"error":   result.Error.Error(),
+		})
+		return nil, false
+	}
+	return &keyPair, true
+}
+
+// resolveKeyNames looks up every requested key pair of the project, returning
+// an error message naming the first one that does not exist.
+func (h *ServerHandler) resolveKeyNames(keyNames []string, projectId string) ([]models.KeyPair, string, error) {
+	keyPairs := make([]models.KeyPair, 0, len(keyNames))
+	seen := make(map[string]bool, len(keyNames))
+	for _, name := range keyNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var keyPair models.KeyPair
+		result := scopeToProject(h.db, projectId).First(&keyPair, "name = ?", name)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil, fmt.Sprintf("Key pair '%s' not found.", name), nil
+			}
+			return nil, "", result.Error
+		}
+		keyPairs = append(keyPairs, keyPair)
+	}
+	return keyPairs, "", nil
+}
@@ -0,0 +1,601 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type listenerRequest struct {
+	Protocol      string `json:"protocol"`
+	Port          int    `json:"port"`
+	TargetGroupID string `json:"targetGroupId"`
+}
+
+// toModel validates the listener and checks that its target group exists in
+// the project.
+func (r listenerRequest) toModel(database *gorm.DB, loadBalancerId, projectId string) (models.Listener, string) {
+	if errorMessage := service.ValidateListener(&r.Protocol, r.Port); errorMessage != "" {
+		return models.Listener{}, errorMessage
+	}
+
+	var count int64
+	scopeToProject(database.Model(&models.TargetGroup{}), projectId).Where("id = ?", r.TargetGroupID).Count(&count)
+	if count == 0 {
+		return models.Listener{}, fmt.Sprintf("Target group with ID '%s' not found.", r.TargetGroupID)
+	}
+
+	return models.Listener{
+		ID:             uuid.New().String(),
+		LoadBalancerID: loadBalancerId,
+		Protocol:       r.Protocol,
+		Port:           r.Port,
+		TargetGroupID:  r.TargetGroupID,
+	}, ""
+}
+
+func (h *ServerHandler) CreateLoadBalancer(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	var req struct {
+		Name      string            `json:"name"`
+		Region    string            `json:"region"`
+		Listeners []listenerRequest `json:"listeners"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Load balancer name is required."})
+		return
+	}
+
+	loadBalancer := models.LoadBalancer{
+		ID:        uuid.New().String(),
+		ProjectID: projectOf(c),
+		Name:      req.Name,
+		Region:    req.Region,
+	}
+
+	ports := make(map[int]bool)
+	for _, r := range req.Listeners {
+		listener, errorMessage := r.toModel(h.db, loadBalancer.ID, loadBalancer.ProjectID)
+		if errorMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+			return
+		}
+		if ports[listener.Port] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("More than one listener on port %d.", listener.Port),
+			})
+			return
+		}
+		ports[listener.Port] = true
+		loadBalancer.Listeners = append(loadBalancer.Listeners, listener)
+	}
+
+	if err := h.db.Create(&loadBalancer).Error; err != nil {
+		log.Printf("Error creating load balancer '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error creating load balancer",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Load balancer created successfully",
+		"loadBalancer": loadBalancer,
+	})
+}
+
+func (h *ServerHandler) ListLoadBalancers(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersRead, "") {
+		return
+	}
+
+	var loadBalancers []models.LoadBalancer
+	if err := scopeToProject(h.db.Preload("Listeners").Order("created_at"), projectOf(c)).Find(&loadBalancers).Error; err != nil {
+		log.Printf("Error fetching load balancers: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching load balancers",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Load balancers fetched successfully",
+		"loadBalancers": loadBalancers,
+	})
+}
+
+func (h *ServerHandler) GetLoadBalancer(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersRead, "") {
+		return
+	}
+
+	loadBalancer, ok := h.findLoadBalancer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Load balancer fetched successfully",
+		"loadBalancer": loadBalancer,
+	})
+}
+
+func (h *ServerHandler) DeleteLoadBalancer(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	loadBalancer, ok := h.findLoadBalancer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("load_balancer_id = ?", loadBalancer.ID).Delete(&models.Listener{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(loadBalancer).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting load balancer '%s': %v\n", loadBalancer.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting load balancer",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Load balancer deleted successfully"})
+}
+
+func (h *ServerHandler) AddListener(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	loadBalancer, ok := h.findLoadBalancer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req listenerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	listener, errorMessage := req.toModel(h.db, loadBalancer.ID, loadBalancer.ProjectID)
+	if errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	for _, existing := range loadBalancer.Listeners {
+		if existing.Port == listener.Port {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": fmt.Sprintf("A listener on port %d already exists.", listener.Port),
+			})
+			return
+		}
+	}
+
+	if err := h.db.Create(&listener).Error; err != nil {
+		log.Printf("Error adding listener to load balancer '%s': %v\n", loadBalancer.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error adding listener",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Listener added successfully",
+		"listener": listener,
+	})
+}
+
+func (h *ServerHandler) CreateTargetGroup(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	var req struct {
+		Name     string `json:"name"`
+		Protocol string `json:"protocol"`
+		Port     int    `json:"port"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Target group name is required."})
+		return
+	}
+	if errorMessage := service.ValidateListener(&req.Protocol, req.Port); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	targetGroup := models.TargetGroup{
+		ID:        uuid.New().String(),
+		ProjectID: projectOf(c),
+		Name:      req.Name,
+		Protocol:  req.Protocol,
+		Port:      req.Port,
+	}
+
+	if err := h.db.Create(&targetGroup).Error; err != nil {
+		log.Printf("Error creating target group '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error creating target group",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Target group created successfully",
+		"targetGroup": targetGroup,
+	})
+}
+
+func (h *ServerHandler) ListTargetGroups(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersRead, "") {
+		return
+	}
+
+	var targetGroups []models.TargetGroup
+	if err := scopeToProject(h.db.Preload("Targets").Order("created_at"), projectOf(c)).Find(&targetGroups).Error; err != nil {
+		log.Printf("Error fetching target groups: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching target groups",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Target groups fetched successfully",
+		"targetGroups": targetGroups,
+	})
+}
+
+func (h *ServerHandler) GetTargetGroup(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersRead, "") {
+		return
+	}
+
+	targetGroup, ok := h.findTargetGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Target group fetched successfully",
+		"targetGroup": targetGroup,
+	})
+}
+
+func (h *ServerHandler) DeleteTargetGroup(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	targetGroup, ok := h.findTargetGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var listeners int64
+	h.db.Model(&models.Listener{}).Where("target_group_id = ?", targetGroup.ID).Count(&listeners)
+	if listeners > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Target group '%s' is used by %d listener(s).", targetGroup.ID, listeners),
+		})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("target_group_id = ?", targetGroup.ID).Delete(&models.Target{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(targetGroup).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting target group '%s': %v\n", targetGroup.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting target group",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for _, target := range targetGroup.Targets {
+		h.events.LogServerEventAs(originOf(c), target.ServerID, "TARGET_DEREGISTERED",
+			fmt.Sprintf("Deregistered from target group '%s' (group deleted).", targetGroup.Name), nil, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Target group deleted successfully"})
+}
+
+func (h *ServerHandler) RegisterTarget(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	targetGroup, ok := h.findTargetGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ServerID string `json:"serverId"`
+		Port     int    `json:"port"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	server, ok := h.findServer(c, req.ServerID)
+	if !ok {
+		return
+	}
+
+	if server.ProjectID != targetGroup.ProjectID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Target group and server belong to different projects."})
+		return
+	}
+	if server.Status == service.StatusTerminated {
+		c.JSON(http.StatusConflict, gin.H{"message": "Cannot register a terminated server."})
+		return
+	}
+
+	for _, existing := range targetGroup.Targets {
+		if existing.ServerID == server.ID {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": fmt.Sprintf("Server '%s' is already registered.", server.ID),
+			})
+			return
+		}
+	}
+
+	port := req.Port
+	if port == 0 {
+		port = targetGroup.Port
+	}
+	if port < 1 || port > 65535 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Port %d is out of range.", port)})
+		return
+	}
+
+	health, reason := service.TargetHealth(server.Status, false)
+	target := models.Target{
+		ID:            uuid.New().String(),
+		TargetGroupID: targetGroup.ID,
+		ServerID:      server.ID,
+		Port:          port,
+		Health:        health,
+		HealthReason:  reason,
+	}
+
+	if err := h.db.Create(&target).Error; err != nil {
+		log.Printf("Error registering server '%s' with target group '%s': %v\n", server.ID, targetGroup.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error registering target",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.events.LogServerEventAs(originOf(c), server.ID, "TARGET_REGISTERED",
+		fmt.Sprintf("Registered with target group '%s' on port %d as %s.", targetGroup.Name, port, health), nil, nil)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Target registered successfully",
+		"target":  target,
+	})
+}
+
+func (h *ServerHandler) DeregisterTarget(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	targetGroup, ok := h.findTargetGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	serverId := c.Param("serverId")
+	result := h.db.Where("target_group_id = ? AND server_id = ?", targetGroup.ID, serverId).Delete(&models.Target{})
+	if result.Error != nil {
+		log.Printf("Error deregistering server '%s' from target group '%s': %v\n", serverId, targetGroup.ID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deregistering target",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("Server '%s' is not registered with target group '%s'.", serverId, targetGroup.ID),
+		})
+		return
+	}
+
+	h.events.LogServerEventAs(originOf(c), serverId, "TARGET_DEREGISTERED",
+		fmt.Sprintf("Deregistered from target group '%s'.", targetGroup.Name), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Target deregistered successfully"})
+}
+
+// SetTargetHealthCheck turns a simulated health-check failure on or off.
+func (h *ServerHandler) SetTargetHealthCheck(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLoadBalancersWrite, "") {
+		return
+	}
+
+	targetGroup, ok := h.findTargetGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Failing bool `json:"failing"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	serverId := c.Param("serverId")
+	var target *models.Target
+	for i := range targetGroup.Targets {
+		if targetGroup.Targets[i].ServerID == serverId {
+			target = &targetGroup.Targets[i]
+		}
+	}
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("Server '%s' is not registered with target group '%s'.", serverId, targetGroup.ID),
+		})
+		return
+	}
+
+	target.SimulatedFailure = req.Failing
+	if err := h.db.Model(target).Update("simulated_failure", req.Failing).Error; err != nil {
+		log.Printf("Error updating health check for target '%s': %v\n", target.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error updating health check",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.refreshTargetHealth(serverId)
+
+	if err := h.db.First(target, "id = ?", target.ID).Error; err != nil {
+		log.Printf("Error reloading target '%s': %v\n", target.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Target health check updated successfully",
+		"target":  target,
+	})
+}
+
+// refreshTargetHealth re-evaluates every target backed by the server and logs
+// a lifecycle event for each one whose health changed.
+func (h *ServerHandler) refreshTargetHealth(serverId string) {
+	server, err := h.servers.Get(serverId, "")
+	if err != nil {
+		log.Printf("WARNING: Failed to load server %s for health check: %v\n", serverId, err)
+		return
+	}
+
+	var targets []models.Target
+	if err := h.db.Where("server_id = ?", serverId).Find(&targets).Error; err != nil {
+		log.Printf("WARNING: Failed to load targets for server %s: %v\n", serverId, err)
+		return
+	}
+
+	for _, target := range targets {
+		health, reason := service.TargetHealth(server.Status, target.SimulatedFailure)
+		if health == target.Health && reason == target.HealthReason {
+			continue
+		}
+
+		oldHealth := target.Health
+		if err := h.db.Model(&target).Updates(map[string]interface{}{
+			"health":        health,
+			"health_reason": reason,
+		}).Error; err != nil {
+			log.Printf("WARNING: Failed to update health of target %s: %v\n", target.ID, err)
+			continue
+		}
+
+		if oldHealth != health {
+			h.events.LogServerEvent(serverId, "TARGET_HEALTH_CHANGED",
+				fmt.Sprintf("Target in group '%s' changed from %s to %s: %s", target.TargetGroupID, oldHealth, health, reason), nil, nil)
+		}
+	}
+}
+
+func (h *ServerHandler) findLoadBalancer(c *gin.Context, loadBalancerId string) (*models.LoadBalancer, bool) {
+	var loadBalancer models.LoadBalancer
+	result := scopeToProject(h.db.Preload("Listeners"), projectOf(c)).First(&loadBalancer, "id = ?", loadBalancerId)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Load balancer with ID '%s' not found.", loadBalancerId),
+			})
+			return nil, false
+		}
+		log.Printf("Error fetching load balancer '%s': %v\n", loadBalancerId, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching load balancer",
+			"error":   result.Error.Error(),
+		})
+		return nil, false
+	}
+	return &loadBalancer, true
+}
+
+func (h *ServerHandler) findTargetGroup(c *gin.Context, targetGroupId string) (*models.TargetGroup, bool) {
+	var targetGroup models.TargetGroup
+	result := scopeToProject(h.db.Preload("Targets"), projectOf(c)).First(&targetGroup, "id = ?", targetGroupId)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Target group with ID '%s' not found.", targetGroupId),
+			})
+			return nil, false
+		}
+		log.Printf("Error fetching target group '%s': %v\n", targetGroupId, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching target group",
+			"error":   result.Error.Error(),
+		})
+		return nil, false
+	}
+	return &targetGroup, true
+}
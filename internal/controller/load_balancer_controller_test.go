@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestTargetHealthFollowsServerStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	server := models.Server{ID: uuid.New().String(), Status: "running", Region: "India", Type: Basic}
+	if err := testDB.Create(&server).Error; err != nil {
+		t.Fatalf("Failed to create test server in DB: %v", err)
+	}
+
+	router := gin.Default()
+	router.POST("/api/target-groups", h.CreateTargetGroup)
+	router.POST("/api/target-groups/:id/targets", h.RegisterTarget)
+	router.PUT("/api/target-groups/:id/targets/:serverId/health-check", h.SetTargetHealthCheck)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+
+	targetHealth := func() string {
+		var target models.Target
+		if err := testDB.First(&target, "server_id = ?", server.ID).Error; err != nil {
+			t.Fatalf("Failed to load target: %v", err)
+		}
+		return target.Health
+	}
+
+	rec := sendJSON(t, router, http.MethodPost, "/api/target-groups", `{"name": "blue", "protocol": "http", "port": 80}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d creating target group, got %d", http.StatusCreated, rec.Code)
+	}
+	var created struct {
+		TargetGroup models.TargetGroup `json:"targetGroup"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	groupPath := "/api/target-groups/" + created.TargetGroup.ID
+
+	if rec := sendJSON(t, router, http.MethodPost, groupPath+"/targets", `{"serverId": "`+server.ID+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d registering target, got %d", http.StatusCreated, rec.Code)
+	}
+	if health := targetHealth(); health != "healthy" {
+		t.Errorf("Expected running server to be healthy, got %s", health)
+	}
+
+	if rec := sendJSON(t, router, http.MethodPost, "/api/servers/"+server.ID+"/action", `{"action": "stop"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d stopping server, got %d", http.StatusOK, rec.Code)
+	}
+	if health := targetHealth(); health != "unhealthy" {
+		t.Errorf("Expected stopped server to be unhealthy, got %s", health)
+	}
+
+	var events int64
+	testDB.Model(&models.ServerLog{}).Where("server_id = ? AND event_type = ?", server.ID, "TARGET_HEALTH_CHANGED").Count(&events)
+	if events != 1 {
+		t.Errorf("Expected 1 TARGET_HEALTH_CHANGED event, got %d", events)
+	}
+
+	sendJSON(t, router, http.MethodPost, "/api/servers/"+server.ID+"/action", `{"action": "start"}`)
+	if health := targetHealth(); health != "healthy" {
+		t.Errorf("Expected restarted server to be healthy, got %s", health)
+	}
+
+	if rec := sendJSON(t, router, http.MethodPut, groupPath+"/targets/"+server.ID+"/health-check", `{"failing": true}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d simulating failure, got %d", http.StatusOK, rec.Code)
+	}
+	if health := targetHealth(); health != "unhealthy" {
+		t.Errorf("Expected simulated failure to mark target unhealthy, got %s", health)
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+const (
+	defaultLogArchiveDir = "./log-archive"
+	logArchiveBatchSize  = 5000
+)
+
+// RunLogRetention applies the log retention policy every interval.
+func (h *ServerHandler) RunLogRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.ApplyLogRetention(time.Now())
+		<-ticker.C
+	}
+}
+
+// ApplyLogRetention moves the server log entries that LOG_RETENTION_FILE no
+// longer keeps to archive files in LOG_ARCHIVE_DIR. Without a policy file
+// nothing is removed. It returns the number of entries archived.
+func (h *ServerHandler) ApplyLogRetention(now time.Time) int {
+	path := os.Getenv("LOG_RETENTION_FILE")
+	if path == "" {
+		return 0
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("WARNING: Cannot read log retention policy: %v\n", err)
+		return 0
+	}
+	policy, err := service.ParseRetentionPolicy(data)
+	if err != nil {
+		log.Printf("WARNING: %v\n", err)
+		return 0
+	}
+	dir := os.Getenv("LOG_ARCHIVE_DIR")
+	if dir == "" {
+		dir = defaultLogArchiveDir
+	}
+
+	var serverIds []string
+	if err := h.db.Model(&models.ServerLog{}).Distinct("server_id").Pluck("server_id", &serverIds).Error; err != nil {
+		log.Printf("WARNING: Failed to load servers for log retention: %v\n", err)
+		return 0
+	}
+
+	archived := 0
+	var pending []models.ServerLog
+	flush := func() {
+		name, err := logger.ArchiveServerLogs(h.db, dir, pending, now)
+		if err != nil {
+			log.Printf("WARNING: Failed to archive %d server log entries: %v\n", len(pending), err)
+		} else {
+			archived += len(pending)
+			log.Printf("Archived %d server log entries to %s.\n", len(pending), name)
+		}
+		pending = nil
+	}
+	for _, serverId := range serverIds {
+		var entries []models.ServerLog
+		if err := h.db.Where("server_id = ?", serverId).Order("created_at DESC").Order("sequence DESC").
+			Find(&entries).Error; err != nil {
+			log.Printf("WARNING: Failed to load logs of server '%s' for retention: %v\n", serverId, err)
+			continue
+		}
+		pending = append(pending, policy.ExpiredLogs(entries, now)...)
+		if len(pending) >= logArchiveBatchSize {
+			flush()
+		}
+	}
+	if len(pending) > 0 {
+		flush()
+	}
+	return archived
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestLogRetention(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testDB, h := setupTestDB(t)
+
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "retention.yaml")
+	os.WriteFile(policyFile, []byte(`
+default:
+  maxPerServer: 2
+eventTypes:
+  EXPIRY_WARNING:
+    maxAge: 24h
+  SERVER_CREATED: {}
+`), 0o600)
+	t.Setenv("LOG_RETENTION_FILE", policyFile)
+	t.Setenv("LOG_ARCHIVE_DIR", filepath.Join(dir, "archive"))
+
+	router := gin.Default()
+	router.GET("/api/audit/verify", h.VerifyAuditLog)
+	verify := func() (bool, int64, int64) {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/audit/verify", nil)
+		router.ServeHTTP(rec, req)
+		var body struct {
+			Valid    bool  `json:"valid"`
+			Entries  int64 `json:"entries"`
+			Archived int64 `json:"archived"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return body.Valid, body.Entries, body.Archived
+	}
+
+	serverId := uuid.New().String()
+	h.events.LogServerEvent(serverId, "SERVER_CREATED", "created", nil, nil)
+	for i := 0; i < 4; i++ {
+		h.events.LogServerEvent(serverId, "STATUS_CHANGE", "changed", nil, nil)
+	}
+	h.events.LogServerEvent(serverId, "EXPIRY_WARNING", "expiring", nil, nil)
+	otherId := uuid.New().String()
+	h.events.LogServerEvent(otherId, "STATUS_CHANGE", "changed", nil, nil)
+
+	if archived := h.ApplyLogRetention(time.Now()); archived != 2 {
+		t.Fatalf("Expected the 2 oldest status changes to be archived, got %d", archived)
+	}
+	if archived := h.ApplyLogRetention(time.Now().Add(48 * time.Hour)); archived != 1 {
+		t.Fatalf("Expected the expiry warning to be archived, got %d", archived)
+	}
+
+	var live []models.ServerLog
+	testDB.Order("sequence").Find(&live)
+	if len(live) != 4 {
+		t.Fatalf("Expected 4 entries to remain, got %d", len(live))
+	}
+	if live[0].EventType != "SERVER_CREATED" {
+		t.Errorf("Expected SERVER_CREATED to be kept, got %s", live[0].EventType)
+	}
+
+	t.Run("Chain still verifies", func(t *testing.T) {
+		h.events.LogServerEvent(serverId, "STATUS_CHANGE", "after retention", nil, nil)
+		if valid, entries, archived := verify(); !valid || entries != 8 || archived != 3 {
+			t.Errorf("Expected a valid chain of 8 entries with 3 archived, got valid=%v entries=%d archived=%d", valid, entries, archived)
+		}
+	})
+
+	archives, _ := filepath.Glob(filepath.Join(dir, "archive", "*.jsonl.gz"))
+	if len(archives) != 2 {
+		t.Fatalf("Expected 2 archive files, got %v", archives)
+	}
+
+	t.Run("Restore", func(t *testing.T) {
+		for _, archive := range archives {
+			if _, err := logger.RestoreArchive(testDB, archive); err != nil {
+				t.Fatalf("Failed to restore %s: %v", archive, err)
+			}
+		}
+		// Restoring twice does not duplicate entries.
+		logger.RestoreArchive(testDB, archives[0])
+
+		var restored int64
+		testDB.Model(&models.RestoredServerLog{}).Where("server_id = ?", serverId).Count(&restored)
+		if restored != 3 {
+			t.Errorf("Expected 3 restored entries, got %d", restored)
+		}
+		if valid, _, _ := verify(); !valid {
+			t.Error("Restoring changed the chain")
+		}
+		if removed, err := logger.ClearRestored(testDB, ""); err != nil || removed != 3 {
+			t.Errorf("Expected 3 restored entries to be cleared, got %d: %v", removed, err)
+		}
+	})
+
+	t.Run("Modified archive is refused", func(t *testing.T) {
+		entries, err := logger.ReadArchive(archives[0])
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		entries[0].Message = "nothing happened"
+		var buf bytes.Buffer
+		compressed := gzip.NewWriter(&buf)
+		for _, entry := range entries {
+			json.NewEncoder(compressed).Encode(entry)
+		}
+		compressed.Close()
+		forged := filepath.Join(dir, "forged.jsonl.gz")
+		os.WriteFile(forged, buf.Bytes(), 0o600)
+
+		if _, err := logger.RestoreArchive(testDB, forged); err == nil {
+			t.Error("Expected a modified archive to be refused")
+		}
+	})
+}
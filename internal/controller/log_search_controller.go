@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLogSearchLimit = 50
+	maxLogSearchLimit     = 200
+	// maxLogSearchCandidates bounds how many entries the token index hands to
+	// ranking, newest first.
+	maxLogSearchCandidates = 5000
+)
+
+type logSearchResult struct {
+	Log       models.ServerLog `json:"log"`
+	Rank      float64          `json:"rank"`
+	Highlight string           `json:"highlight"`
+}
+
+// SearchLogs finds lifecycle log entries matching q, best matches first. On
+// PostgreSQL the message is searched through its tsvector index, elsewhere
+// through the token index kept by the logger.
+func (h *ServerHandler) SearchLogs(c *gin.Context) {
+	if !h.authorize(c, service.PermissionLogsRead, "") {
+		return
+	}
+
+	query, err := service.ParseLogQuery(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid search",
+			"error":   err.Error(),
+		})
+		return
+	}
+	limit := defaultLogSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLogSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Query parameter 'limit' must be between 1 and " + strconv.Itoa(maxLogSearchLimit) + ".",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	base := scopeToProject(h.db.Model(&models.ServerLog{}), projectOf(c))
+	for column, value := range query.Fields {
+		base = base.Where(column+" = ?", value)
+	}
+
+	var results []logSearchResult
+	switch {
+	case !query.HasText():
+		results, err = searchLogsByFields(base, limit)
+	case h.db.Dialector.Name() == "postgres":
+		results, err = searchLogsFullText(base, query, limit)
+	default:
+		results, err = h.searchLogsTokenIndex(base, query, limit)
+	}
+	if err != nil {
+		log.Printf("Error searching logs for '%s': %v\n", c.Query("q"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error searching logs",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Log search completed successfully",
+		"count":   len(results),
+		"results": results,
+	})
+}
+
+// searchLogsByFields lists the newest entries when only fields are given.
+func searchLogsByFields(base *gorm.DB, limit int) ([]logSearchResult, error) {
+	var entries []models.ServerLog
+	if err := base.Order("created_at DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	results := make([]logSearchResult, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, logSearchResult{Log: entry, Highlight: html.EscapeString(entry.Message)})
+	}
+	return results, nil
+}
+
+// searchLogsFullText matches, ranks and highlights in PostgreSQL. The vector
+// expression must match the index created by db.AutoMigrate.
+func searchLogsFullText(base *gorm.DB, query *service.LogQuery, limit int) ([]logSearchResult, error) {
+	const vector = "to_tsvector('english', message)"
+	// The message is escaped as html.EscapeString does before the marks are
+	// added. The parser reads entities as entities, so the matches are the same.
+	const escaped = `replace(replace(replace(replace(replace(message, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+	var parts []string
+	var args []interface{}
+	if len(query.Terms) > 0 {
+		parts = append(parts, "plainto_tsquery('english', ?)")
+		args = append(args, strings.Join(query.Terms, " "))
+	}
+	for _, phrase := range query.Phrases {
+		parts = append(parts, "phraseto_tsquery('english', ?)")
+		args = append(args, strings.Join(phrase, " "))
+	}
+	tsquery := "(" + strings.Join(parts, " && ") + ")"
+
+	var rows []struct {
+		models.ServerLog
+		Rank      float64
+		Highlight string
+	}
+	err := base.
+		Select("server_logs.*, ts_rank("+vector+", "+tsquery+") AS rank, "+
+			"ts_headline('english', "+escaped+", "+tsquery+", 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS highlight",
+			append(append([]interface{}{}, args...), args...)...).
+		Where(vector+" @@ "+tsquery, args...).
+		Order("rank DESC").Order("created_at DESC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	results := make([]logSearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, logSearchResult{Log: row.ServerLog, Rank: row.Rank, Highlight: row.Highlight})
+	}
+	return results, nil
+}
+
+// searchLogsTokenIndex narrows the entries to those holding every word of the
+// query through the token index, then matches phrases, ranks and highlights
+// them in Go.
+func (h *ServerHandler) searchLogsTokenIndex(base *gorm.DB, query *service.LogQuery, limit int) ([]logSearchResult, error) {
+	candidates := base
+	for _, token := range query.Tokens() {
+		candidates = candidates.Where("id IN (?)",
+			h.db.Model(&models.ServerLogToken{}).Select("server_log_id").Where("token = ?", token))
+	}
+	var entries []models.ServerLog
+	if err := candidates.Order("created_at DESC").Limit(maxLogSearchCandidates).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	var results []logSearchResult
+	for _, entry := range entries {
+		if rank, ok := service.MatchLog(query, entry.Message); ok {
+			results = append(results, logSearchResult{Log: entry, Rank: rank, Highlight: service.HighlightLog(query, entry.Message)})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSearchLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	_, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+	router.GET("/api/logs/search", h.SearchLogs)
+
+	type result struct {
+		Log struct {
+			ServerID  string `json:"serverId"`
+			EventType string `json:"eventType"`
+		} `json:"log"`
+		Rank      float64 `json:"rank"`
+		Highlight string  `json:"highlight"`
+	}
+	search := func(q string) []result {
+		rec := sendJSON(t, router, http.MethodGet, "/api/logs/search?q="+url.QueryEscape(q), "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %q, got %d: %s", http.StatusOK, q, rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Results []result `json:"results"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return body.Results
+	}
+
+	first := createTestServer(t, router, `{"region": "India", "type": "basic"}`)
+	second := createTestServer(t, router, `{"region": "India", "type": "basic"}`)
+	sendJSON(t, router, http.MethodPost, "/api/servers/"+first+"/action", `{"action": "start"}`)
+	sendJSON(t, router, http.MethodPost, "/api/servers/"+second+"/action", `{"action": "start"}`)
+	sendJSON(t, router, http.MethodPost, "/api/servers/"+second+"/action", `{"action": "stop"}`)
+
+	t.Run("Qualified phrase", func(t *testing.T) {
+		results := search(`eventType:ACTION_DENIED server:` + first + ` "already running"`)
+		if len(results) != 1 {
+			t.Fatalf("Expected 1 result, got %+v", results)
+		}
+		if results[0].Log.ServerID != first || results[0].Log.EventType != "ACTION_DENIED" {
+			t.Errorf("Unexpected result %+v", results[0])
+		}
+		if !strings.Contains(results[0].Highlight, "<mark>already</mark> <mark>running</mark>") {
+			t.Errorf("Expected the phrase to be highlighted, got %q", results[0].Highlight)
+		}
+	})
+
+	t.Run("Ranked across servers", func(t *testing.T) {
+		results := search(`"already running"`)
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %+v", results)
+		}
+		if results[0].Rank < results[1].Rank || results[1].Rank <= 0 {
+			t.Errorf("Expected results ranked best first, got %v and %v", results[0].Rank, results[1].Rank)
+		}
+		if results := search(`"running already"`); len(results) != 0 {
+			t.Errorf("Expected words out of order not to match a phrase, got %+v", results)
+		}
+	})
+
+	t.Run("Fields only", func(t *testing.T) {
+		results := search("server:" + second)
+		if len(results) != 3 {
+			t.Errorf("Expected every entry of the server, got %d", len(results))
+		}
+	})
+
+	t.Run("Invalid search", func(t *testing.T) {
+		for _, q := range []string{"", "colour:red", `"already`} {
+			if rec := sendJSON(t, router, http.MethodGet, "/api/logs/search?q="+url.QueryEscape(q), ""); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, q, rec.Code)
+			}
+		}
+	})
+}
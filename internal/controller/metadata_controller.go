@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/repository"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+const (
+	ServerTokenHeader     = "X-Server-Token"
+	MetadataTokenHeader   = "X-Metadata-Token"
+	MetadataTTLHeader     = "X-Metadata-Token-TTL-Seconds"
+	metadataServerContext = "metadataServer"
+)
+
+var metadataSessions = service.NewMetadataSessionStore()
+
+var metadataItems = []string{
+	"instance-id",
+	"server-number",
+	"region",
+	"instance-type",
+	"local-ipv4",
+	"public-ipv4",
+	"public-keys",
+	"tags",
+	"user-data",
+	"document",
+}
+
+// IssueMetadataToken exchanges a server's own token for a short-lived session
+// token, following the IMDSv2 PUT-then-GET flow.
+func (h *ServerHandler) IssueMetadataToken(c *gin.Context) {
+	// Session tokens must not be obtainable through a forwarding proxy.
+	if c.GetHeader("X-Forwarded-For") != "" {
+		c.String(http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	ttlSeconds, err := strconv.Atoi(c.GetHeader(MetadataTTLHeader))
+	ttl := time.Duration(ttlSeconds) * time.Second
+	if err != nil || ttl < service.MinMetadataSessionTTL || ttl > service.MaxMetadataSessionTTL {
+		c.String(http.StatusBadRequest, fmt.Sprintf("%s must be between %d and %d",
+			MetadataTTLHeader, int(service.MinMetadataSessionTTL.Seconds()), int(service.MaxMetadataSessionTTL.Seconds())))
+		return
+	}
+
+	serverToken := c.GetHeader(ServerTokenHeader)
+	if serverToken == "" {
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	server, err := h.servers.FindByMetadataToken(service.HashToken(serverToken))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.String(http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		log.Printf("Error looking up metadata token: %v\n", err)
+		c.String(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	if server.Status == service.StatusTerminated {
+		c.String(http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	token, err := metadataSessions.Issue(server.ID, ttl)
+	if err != nil {
+		log.Printf("Error issuing metadata session for server '%s': %v\n", server.ID, err)
+		c.String(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	c.Header(MetadataTTLHeader, strconv.Itoa(ttlSeconds))
+	c.String(http.StatusOK, token)
+}
+
+// RequireMetadataSession resolves the session token to a server and makes it
+// available to the metadata handlers.
+func (h *ServerHandler) RequireMetadataSession(c *gin.Context) {
+	serverId, ok := metadataSessions.Lookup(c.GetHeader(MetadataTokenHeader))
+	if !ok {
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		c.Abort()
+		return
+	}
+
+	server, err := h.servers.Get(serverId, "")
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.String(http.StatusUnauthorized, "Unauthorized")
+		} else {
+			log.Printf("Error fetching server '%s' for metadata request: %v\n", serverId, err)
+			c.String(http.StatusInternalServerError, "Internal Server Error")
+		}
+		c.Abort()
+		return
+	}
+
+	c.Set(metadataServerContext, server)
+	c.Next()
+}
+
+func (h *ServerHandler) MetadataIndex(c *gin.Context) {
+	c.String(http.StatusOK, strings.Join(metadataItems, "\n"))
+}
+
+func (h *ServerHandler) GetMetadataItem(c *gin.Context) {
+	server := c.MustGet(metadataServerContext).(*models.Server)
+
+	switch c.Param("item") {
+	case "instance-id":
+		c.String(http.StatusOK, server.ID)
+	case "server-number":
+		c.String(http.StatusOK, strconv.FormatInt(server.ServerNumber, 10))
+	case "region":
+		c.String(http.StatusOK, server.Region)
+	case "instance-type":
+		c.String(http.StatusOK, server.Type)
+	case "local-ipv4":
+		c.String(http.StatusOK, server.PrivateIP)
+	case "public-ipv4":
+		c.String(http.StatusOK, server.PublicIP)
+	case "public-keys":
+		keys, ok := h.metadataKeys(c, server.ID)
+		if !ok {
+			return
+		}
+		lines := make([]string, 0, len(keys))
+		for i, key := range keys {
+			lines = append(lines, fmt.Sprintf("%d=%s", i, key.KeyName))
+		}
+		c.String(http.StatusOK, strings.Join(lines, "\n"))
+	case "tags":
+		pairs := make([]string, 0, len(server.Tags))
+		for key, value := range server.Tags {
+			pairs = append(pairs, key+"="+value)
+		}
+		sort.Strings(pairs)
+		c.String(http.StatusOK, strings.Join(pairs, "\n"))
+	case "user-data":
+		if server.UserData == "" {
+			c.String(http.StatusNotFound, "Not Found")
+			return
+		}
+		decoded, err := base64.StdEncoding.DecodeString(server.UserData)
+		if err != nil {
+			log.Printf("Stored user data for server '%s' is not valid base64: %v\n", server.ID, err)
+			c.String(http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		c.Data(http.StatusOK, "application/octet-stream", decoded)
+	case "document":
+		keys, ok := h.metadataKeys(c, server.ID)
+		if !ok {
+			return
+		}
+		publicKeys := make([]gin.H, 0, len(keys))
+		for _, key := range keys {
+			publicKeys = append(publicKeys, gin.H{
+				"name":        key.KeyName,
+				"fingerprint": key.Fingerprint,
+				"opensshKey":  key.PublicKey,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"instanceId":   server.ID,
+			"serverNumber": server.ServerNumber,
+			"region":       server.Region,
+			"instanceType": server.Type,
+			"network": gin.H{
+				"localIpv4":  server.PrivateIP,
+				"publicIpv4": server.PublicIP,
+			},
+			"publicKeys": publicKeys,
+			"tags":       server.Tags,
+			"userData":   server.UserData,
+		})
+	default:
+		c.String(http.StatusNotFound, "Not Found")
+	}
+}
+
+func (h *ServerHandler) GetMetadataPublicKey(c *gin.Context) {
+	server := c.MustGet(metadataServerContext).(*models.Server)
+
+	keys, ok := h.metadataKeys(c, server.ID)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 || index >= len(keys) {
+		c.String(http.StatusNotFound, "Not Found")
+		return
+	}
+
+	c.String(http.StatusOK, keys[index].PublicKey)
+}
+
+// RotateMetadataToken issues a new per-server metadata token and invalidates
+// the old one together with all sessions derived from it.
+func (h *ServerHandler) RotateMetadataToken(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersUpdate, server.ID) {
+		return
+	}
+
+	token, hash, err := service.NewSecretToken()
+	if err != nil {
+		log.Printf("Error generating metadata token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error generating metadata token",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	server.MetadataTokenHash = hash
+	if err := h.servers.Update(server, "metadata_token_hash"); err != nil {
+		log.Printf("Error saving metadata token for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error saving metadata token",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	metadataSessions.RevokeServer(server.ID)
+	h.events.LogServerEventAs(originOf(c), server.ID, "METADATA_TOKEN_ROTATED", "Metadata token rotated.", nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Metadata token rotated successfully",
+		"metadataToken": token,
+	})
+}
+
+func (h *ServerHandler) metadataKeys(c *gin.Context, serverId string) ([]models.ServerKey, bool) {
+	keys, err := h.servers.Keys(serverId)
+	if err != nil {
+		log.Printf("Error fetching keys for server '%s': %v\n", serverId, err)
+		c.String(http.StatusInternalServerError, "Internal Server Error")
+		return nil, false
+	}
+	return keys, true
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetadataService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	_, h := setupTestDB(t)
+
+	api := gin.Default()
+	api.POST("/api/server", h.CreateServer)
+
+	metadata := gin.Default()
+	metadata.PUT("/metadata/v1/token", h.IssueMetadataToken)
+	metadata.GET("/metadata/v1/:item", h.RequireMetadataSession, h.GetMetadataItem)
+
+	body := []byte(`{"region": "India", "type": "basic"}`)
+	req, _ := http.NewRequest(http.MethodPost, "/api/server", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+
+	var created struct {
+		ID            string `json:"id"`
+		PrivateIP     string `json:"privateIp"`
+		MetadataToken string `json:"metadataToken"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal the response body: %v", err)
+	}
+	if created.MetadataToken == "" || created.PrivateIP == "" {
+		t.Fatalf("Expected metadata token and private IP in create response, got %s", rec.Body.String())
+	}
+
+	issue := func(serverToken string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, "/metadata/v1/token", nil)
+		req.Header.Set(ServerTokenHeader, serverToken)
+		req.Header.Set(MetadataTTLHeader, "60")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		metadata.ServeHTTP(rec, req)
+		return rec
+	}
+
+	get := func(path, sessionToken string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if sessionToken != "" {
+			req.Header.Set(MetadataTokenHeader, sessionToken)
+		}
+		rec := httptest.NewRecorder()
+		metadata.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Unknown server token", func(t *testing.T) {
+		if rec := issue("bogus", nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("Forwarded token request", func(t *testing.T) {
+		if rec := issue(created.MetadataToken, map[string]string{"X-Forwarded-For": "1.2.3.4"}); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("Invalid TTL", func(t *testing.T) {
+		if rec := issue(created.MetadataToken, map[string]string{MetadataTTLHeader: "0"}); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Missing session token", func(t *testing.T) {
+		if rec := get("/metadata/v1/instance-id", ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("Session flow", func(t *testing.T) {
+		rec := issue(created.MetadataToken, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		session := rec.Body.String()
+
+		if rec := get("/metadata/v1/instance-id", session); rec.Body.String() != created.ID {
+			t.Errorf("Expected instance-id %s, got %q", created.ID, rec.Body.String())
+		}
+		if rec := get("/metadata/v1/local-ipv4", session); rec.Body.String() != created.PrivateIP {
+			t.Errorf("Expected local-ipv4 %s, got %q", created.PrivateIP, rec.Body.String())
+		}
+		if rec := get("/metadata/v1/user-data", session); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for missing user data, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (h *ServerHandler) CreateOrganization(c *gin.Context) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Organization name is required."})
+		return
+	}
+
+	var existing int64
+	h.db.Model(&models.Organization{}).Where("name = ?", req.Name).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Organization with name '%s' already exists.", req.Name),
+		})
+		return
+	}
+
+	organization := models.Organization{ID: uuid.New().String(), Name: req.Name}
+	if err := h.db.Create(&organization).Error; err != nil {
+		log.Printf("Error saving organization '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error saving organization",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Organization created successfully",
+		"organization": organization,
+	})
+}
+
+func (h *ServerHandler) ListOrganizations(c *gin.Context) {
+	var organizations []models.Organization
+	if err := h.db.Order("name").Find(&organizations).Error; err != nil {
+		log.Printf("Error fetching organizations: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching organizations",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Organizations fetched successfully",
+		"organizations": organizations,
+	})
+}
+
+// CreateProject adds a project to an organization. Only global callers can
+// create projects; a caller bound to a project cannot.
+func (h *ServerHandler) CreateProject(c *gin.Context) {
+	if !h.authorize(c, service.PermissionProjectsWrite, "") {
+		return
+	}
+	if projectOf(c) != "" {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Credentials bound to a project cannot create projects."})
+		return
+	}
+
+	var req struct {
+		OrganizationID string `json:"organizationId"`
+		Name           string `json:"name"`
+		Description    string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Project name is required."})
+		return
+	}
+	var organization models.Organization
+	if err := h.db.First(&organization, "id = ?", req.OrganizationID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Organization '%s' does not exist.", req.OrganizationID),
+		})
+		return
+	}
+	if conflict := h.checkProjectNameConflict(req.OrganizationID, req.Name, ""); conflict != "" {
+		c.JSON(http.StatusConflict, gin.H{"message": conflict})
+		return
+	}
+
+	project := models.Project{
+		ID:             uuid.New().String(),
+		OrganizationID: organization.ID,
+		Name:           req.Name,
+		Description:    req.Description,
+	}
+	if err := h.db.Create(&project).Error; err != nil {
+		log.Printf("Error saving project '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error saving project",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("Project '%s' created in organization '%s' by %s.\n", project.Name, organization.Name, actorOf(c))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project created successfully",
+		"project": project,
+	})
+}
+
+// ListProjects returns the caller's own project, or every project (optionally
+// of one organization) for a global caller.
+func (h *ServerHandler) ListProjects(c *gin.Context) {
+	if !h.authorize(c, service.PermissionProjectsRead, "") {
+		return
+	}
+
+	query := h.db.Order("created_at")
+	if projectId := projectOf(c); projectId != "" {
+		query = query.Where("id = ?", projectId)
+	}
+	if organizationId := c.Query("organizationId"); organizationId != "" {
+		query = query.Where("organization_id = ?", organizationId)
+	}
+
+	var projects []models.Project
+	if err := query.Find(&projects).Error; err != nil {
+		log.Printf("Error fetching projects: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching projects",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Projects fetched successfully",
+		"projects": projects,
+	})
+}
+
+func (h *ServerHandler) GetProject(c *gin.Context) {
+	if !h.authorize(c, service.PermissionProjectsRead, "") {
+		return
+	}
+	project, ok := h.findProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project fetched successfully",
+		"project": project,
+	})
+}
+
+func (h *ServerHandler) UpdateProject(c *gin.Context) {
+	if !h.authorize(c, service.PermissionProjectsWrite, "") {
+		return
+	}
+	project, ok := h.findProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Project name is required."})
+			return
+		}
+		if conflict := h.checkProjectNameConflict(project.OrganizationID, *req.Name, project.ID); conflict != "" {
+			c.JSON(http.StatusConflict, gin.H{"message": conflict})
+			return
+		}
+		project.Name = *req.Name
+	}
+	if req.Description != nil {
+		project.Description = *req.Description
+	}
+
+	if err := h.db.Model(project).Updates(map[string]interface{}{
+		"name":        project.Name,
+		"description": project.Description,
+	}).Error; err != nil {
+		log.Printf("Error updating project '%s': %v\n", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update project",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project updated successfully",
+		"project": project,
+	})
+}
+
+// DeleteProject removes an empty project. Servers that are not terminated keep
+// it alive, so nothing is orphaned.
+func (h *ServerHandler) DeleteProject(c *gin.Context) {
+	if !h.authorize(c, service.PermissionProjectsWrite, "") {
+		return
+	}
+	if projectOf(c) != "" {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Credentials bound to a project cannot delete projects."})
+		return
+	}
+	project, ok := h.findProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	live, err := h.servers.FindBySelector(service.Selector{}, "", project.ID)
+	if err != nil {
+		log.Printf("Error counting servers of project '%s': %v\n", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting project",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if len(live) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Project '%s' still has %d servers. Terminate them first.", project.Name, len(live)),
+		})
+		return
+	}
+
+	if err := h.db.Delete(project).Error; err != nil {
+		log.Printf("Error deleting project '%s': %v\n", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting project",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("Project '%s' deleted by %s.\n", project.Name, actorOf(c))
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// findProject resolves a project the caller can see, writing the error
+// response itself when it cannot. Other projects look like missing ones.
+func (h *ServerHandler) findProject(c *gin.Context, projectId string) (*models.Project, bool) {
+	if own := projectOf(c); own != "" && own != projectId {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("Project with ID '%s' not found.", projectId),
+		})
+		return nil, false
+	}
+
+	project, err := h.lookupProject(projectId)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Project with ID '%s' not found.", projectId),
+			})
+			return nil, false
+		}
+		log.Printf("Error fetching project '%s': %v\n", projectId, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching project",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return project, true
+}
+
+func (h *ServerHandler) lookupProject(projectId string) (*models.Project, error) {
+	var project models.Project
+	if err := h.db.First(&project, "id = ?", projectId).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// checkProjectNameConflict keeps project names unique within an organization.
+func (h *ServerHandler) checkProjectNameConflict(organizationId, name, projectId string) string {
+	var count int64
+	h.db.Model(&models.Project{}).
+		Where("organization_id = ? AND name = ? AND id <> ?", organizationId, name, projectId).
+		Count(&count)
+	if count > 0 {
+		return fmt.Sprintf("A project named '%s' already exists in this organization.", name)
+	}
+	return ""
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestProjects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testDB, h := setupTestDB(t)
+
+	t.Setenv("AUTH_DISABLED", "")
+	t.Setenv("ADMIN_API_KEY", "vs_bootstrap-secret")
+	t.Setenv("POLICY_FILE", "")
+
+	router := gin.Default()
+	api := router.Group("/api", middleware.Authenticate(testDB))
+	api.POST("/server", h.CreateServer)
+	api.GET("/servers", h.ListServers)
+	api.GET("/servers/:id", h.GetServer)
+	api.POST("/servers/:id/action", h.CompleteAction)
+	api.GET("/servers/:id/logs", h.GetLogs)
+	api.POST("/projects", h.CreateProject)
+	api.GET("/projects", h.ListProjects)
+	api.GET("/projects/:id", h.GetProject)
+	api.PATCH("/projects/:id", h.UpdateProject)
+	api.DELETE("/projects/:id", h.DeleteProject)
+	api.POST("/admin/organizations", h.CreateOrganization)
+	api.POST("/security-groups", h.CreateSecurityGroup)
+	api.GET("/security-groups", h.ListSecurityGroups)
+	api.GET("/security-groups/:id", h.GetSecurityGroup)
+	api.POST("/key-pairs", h.CreateKeyPair)
+	api.GET("/key-pairs/:name", h.GetKeyPair)
+	api.POST("/load-balancers", h.CreateLoadBalancer)
+	api.GET("/load-balancers/:id", h.GetLoadBalancer)
+	api.POST("/target-groups", h.CreateTargetGroup)
+	api.GET("/target-groups/:id", h.GetTargetGroup)
+	api.POST("/dns/zones", h.CreateDNSZone)
+	api.GET("/dns/zones/:id", h.GetDNSZone)
+	api.POST("/admin/api-keys", h.CreateAPIKey)
+	api.GET("/admin/api-keys", h.ListAPIKeys)
+	api.DELETE("/admin/api-keys/:id", h.RevokeAPIKey)
+
+	const admin = "vs_bootstrap-secret"
+	created := func(rec *httptest.ResponseRecorder, field string) string {
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var body map[string]json.RawMessage
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		var object struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(body[field], &object)
+		return object.ID
+	}
+
+	org := created(sendJSON(t, router, http.MethodPost, "/api/admin/organizations", `{"name": "acme"}`, "X-API-Key", admin), "organization")
+	project := func(name string) string {
+		return created(sendJSON(t, router, http.MethodPost, "/api/projects", fmt.Sprintf(`{"organizationId": "%s", "name": "%s"}`, org, name), "X-API-Key", admin), "project")
+	}
+	red, blue := project("red"), project("blue")
+	keyFor := func(name, projectId string) string {
+		rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", fmt.Sprintf(`{"name": "%s", "scopes": ["write"], "projectId": "%s"}`, name, projectId), "X-API-Key", admin)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Key string `json:"key"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return body.Key
+	}
+	redKey, blueKey := keyFor("red-team", red), keyFor("blue-team", blue)
+
+	t.Run("Project CRUD", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodPost, "/api/projects", fmt.Sprintf(`{"organizationId": "%s", "name": "red"}`, org), "X-API-Key", admin); rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for a duplicate name, got %d", http.StatusConflict, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/projects", `{"organizationId": "missing", "name": "x"}`, "X-API-Key", admin); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown organization, got %d", http.StatusBadRequest, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPatch, "/api/projects/"+blue, `{"description": "Blue team"}`, "X-API-Key", admin); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/projects", fmt.Sprintf(`{"organizationId": "%s", "name": "x"}`, org), "X-API-Key", redKey); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for a project-bound key, got %d", http.StatusForbidden, rec.Code)
+		}
+
+		rec := sendJSON(t, router, http.MethodGet, "/api/projects", "", "X-API-Key", redKey)
+		var listed struct {
+			Projects []models.Project `json:"projects"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &listed)
+		if len(listed.Projects) != 1 || listed.Projects[0].ID != red {
+			t.Errorf("Expected a project-bound key to list only its project, got %+v", listed.Projects)
+		}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/projects/"+blue, "", "X-API-Key", redKey); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for another project, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("Servers are scoped by project", func(t *testing.T) {
+		redServer := createTestServer(t, router, `{"region": "India", "type": "basic"}`, "X-API-Key", redKey)
+		blueServer := createTestServer(t, router, `{"region": "India", "type": "basic"}`, "X-API-Key", blueKey)
+
+		var server models.Server
+		testDB.First(&server, "id = ?", redServer)
+		if server.ProjectID != red {
+			t.Fatalf("Expected the server to belong to project %s, got %q", red, server.ProjectID)
+		}
+
+		rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", "X-API-Key", redKey)
+		var listed struct {
+			Servers []models.Server `json:"server"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &listed)
+		if len(listed.Servers) != 1 || listed.Servers[0].ID != redServer {
+			t.Errorf("Expected only the red server, got %d servers", len(listed.Servers))
+		}
+
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers/"+blueServer, "", "X-API-Key", redKey); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for another project's server, got %d", http.StatusNotFound, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/"+blueServer+"/action", `{"action": "stop"}`, "X-API-Key", redKey); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d when stopping another project's server, got %d", http.StatusNotFound, rec.Code)
+		}
+		var other models.Server
+		testDB.First(&other, "id = ?", blueServer)
+		if other.Status != "running" {
+			t.Errorf("Expected the blue server to keep running, got %s", other.Status)
+		}
+
+		rec = sendJSON(t, router, http.MethodGet, "/api/servers/"+blueServer+"/logs", "", "X-API-Key", redKey)
+		var logs struct {
+			Logs []models.ServerLog `json:"logs"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &logs)
+		if len(logs.Logs) != 0 {
+			t.Errorf("Expected no logs from another project, got %d", len(logs.Logs))
+		}
+		var entry models.ServerLog
+		testDB.Where("server_id = ?", blueServer).First(&entry)
+		if entry.ProjectID != blue {
+			t.Errorf("Expected log entries to belong to project %s, got %q", blue, entry.ProjectID)
+		}
+
+		rec = sendJSON(t, router, http.MethodGet, "/api/servers", "", "X-API-Key", admin, "X-Project-ID", blue)
+		json.Unmarshal(rec.Body.Bytes(), &listed)
+		if len(listed.Servers) != 1 || listed.Servers[0].ID != blueServer {
+			t.Errorf("Expected the admin to see only the blue server when narrowed, got %d servers", len(listed.Servers))
+		}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", "X-API-Key", redKey, "X-Project-ID", blue); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d when a bound key switches projects, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("Server names are unique per project", func(t *testing.T) {
+		body := `{"region": "India", "type": "basic", "name": "db", "hostname": "db"}`
+		createTestServer(t, router, body, "X-API-Key", redKey)
+		createTestServer(t, router, body, "X-API-Key", blueKey)
+		if rec := sendJSON(t, router, http.MethodPost, "/api/server", body, "X-API-Key", redKey); rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for a duplicate name in the project, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("Networking is scoped by project", func(t *testing.T) {
+		group := created(sendJSON(t, router, http.MethodPost, "/api/security-groups", `{"name": "web"}`, "X-API-Key", redKey), "securityGroup")
+		targetGroup := created(sendJSON(t, router, http.MethodPost, "/api/target-groups", `{"name": "web", "protocol": "http", "port": 80}`, "X-API-Key", redKey), "targetGroup")
+		loadBalancer := created(sendJSON(t, router, http.MethodPost, "/api/load-balancers", `{"name": "web"}`, "X-API-Key", redKey), "loadBalancer")
+		zone := created(sendJSON(t, router, http.MethodPost, "/api/dns/zones", `{"name": "red.example"}`, "X-API-Key", redKey), "zone")
+		created(sendJSON(t, router, http.MethodPost, "/api/key-pairs", `{"name": "laptop"}`, "X-API-Key", redKey), "keyPair")
+
+		for _, path := range []string{
+			"/api/security-groups/" + group,
+			"/api/target-groups/" + targetGroup,
+			"/api/load-balancers/" + loadBalancer,
+			"/api/dns/zones/" + zone,
+			"/api/key-pairs/laptop",
+		} {
+			if rec := sendJSON(t, router, http.MethodGet, path, "", "X-API-Key", redKey); rec.Code != http.StatusOK {
+				t.Errorf("Expected status %d for %s in its project, got %d", http.StatusOK, path, rec.Code)
+			}
+			if rec := sendJSON(t, router, http.MethodGet, path, "", "X-API-Key", blueKey); rec.Code != http.StatusNotFound {
+				t.Errorf("Expected status %d for %s from another project, got %d", http.StatusNotFound, path, rec.Code)
+			}
+		}
+
+		rec := sendJSON(t, router, http.MethodGet, "/api/security-groups", "", "X-API-Key", blueKey)
+		var listed struct {
+			SecurityGroups []models.SecurityGroup `json:"securityGroups"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &listed)
+		if len(listed.SecurityGroups) != 0 {
+			t.Errorf("Expected no security groups for the blue project, got %d", len(listed.SecurityGroups))
+		}
+
+		if rec := sendJSON(t, router, http.MethodPost, "/api/server", `{"region": "India", "type": "basic", "keyNames": ["laptop"]}`, "X-API-Key", blueKey); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d injecting another project's key pair, got %d", http.StatusBadRequest, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/key-pairs", `{"name": "laptop"}`, "X-API-Key", blueKey); rec.Code != http.StatusCreated {
+			t.Errorf("Expected key pair names to be unique per project, got %d: %s", rec.Code, rec.Body.String())
+		}
+		for _, name := range []string{`team/laptop`, `laptop\nssh-rsa AAAA`} {
+			body, _ := json.Marshal(map[string]string{"name": name})
+			if rec := sendJSON(t, router, http.MethodPost, "/api/key-pairs", string(body), "X-API-Key", blueKey); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for key pair name %q, got %d", http.StatusBadRequest, name, rec.Code)
+			}
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/security-groups", `{"name": "web"}`, "X-API-Key", blueKey); rec.Code != http.StatusCreated {
+			t.Errorf("Expected security group names to be unique per project, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("Project-bound admin keys stay in their project", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", fmt.Sprintf(`{"name": "red-admin", "scopes": ["admin"], "projectId": "%s"}`, red), "X-API-Key", admin)
+		var issued struct {
+			Key string `json:"key"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &issued)
+		redAdmin := issued.Key
+
+		for name, body := range map[string]string{
+			"global":  `{"name": "global", "scopes": ["admin"]}`,
+			"foreign": fmt.Sprintf(`{"name": "foreign", "scopes": ["admin"], "projectId": "%s"}`, blue),
+		} {
+			rec := sendJSON(t, router, http.MethodPost, "/api/admin/api-keys", body, "X-API-Key", redAdmin)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+			}
+			var key models.APIKey
+			testDB.First(&key, "name = ?", name)
+			if key.ProjectID != red {
+				t.Errorf("Expected key %s to be bound to project %s, got %q", name, red, key.ProjectID)
+			}
+		}
+
+		rec = sendJSON(t, router, http.MethodGet, "/api/admin/api-keys", "", "X-API-Key", redAdmin)
+		var listed struct {
+			APIKeys []models.APIKey `json:"apiKeys"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &listed)
+		for _, key := range listed.APIKeys {
+			if key.ProjectID != red {
+				t.Errorf("Expected only keys of project %s, got %s in %q", red, key.Name, key.ProjectID)
+			}
+		}
+
+		var blueTeam models.APIKey
+		testDB.First(&blueTeam, "name = ?", "blue-team")
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/admin/api-keys/"+blueTeam.ID, "", "X-API-Key", redAdmin); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d revoking another project's key, got %d", http.StatusNotFound, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers", "", "X-API-Key", blueKey); rec.Code != http.StatusOK {
+			t.Errorf("Expected the blue key to keep working, got %d", rec.Code)
+		}
+	})
+
+	t.Run("Delete project", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/projects/"+red, "", "X-API-Key", admin); rec.Code != http.StatusConflict {
+			t.Fatalf("Expected status %d for a project with servers, got %d", http.StatusConflict, rec.Code)
+		}
+		empty := project("empty")
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/projects/"+empty, "", "X-API-Key", admin); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if rec := sendJSON(t, router, http.MethodGet, "/api/projects/"+empty, "", "X-API-Key", admin); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d after deletion, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+}
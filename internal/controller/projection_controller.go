@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/repository"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+const consistencyBatchSize = 100
+
+type serverConsistency struct {
+	ServerID       string                `json:"serverId"`
+	Name           string                `json:"name,omitempty"`
+	Drift          []service.ServerDrift `json:"drift"`
+	ArchivedEvents int64                 `json:"archivedEvents,omitempty"`
+}
+
+// getServerAsOf answers GET /servers/:id?asOf= with the server's state at
+// that moment, rebuilt from its events.
+func (h *ServerHandler) getServerAsOf(c *gin.Context, server *models.Server) {
+	asOf, err := time.Parse(time.RFC3339, c.Query("asOf"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Query parameter 'asOf' must be an RFC 3339 timestamp."})
+		return
+	}
+
+	events, archived, err := loadServerEvents(h.logs, server.ID, &asOf)
+	if err != nil {
+		log.Printf("Error fetching events for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching server events",
+			"error":   err.Error(),
+		})
+		return
+	}
+	projection := service.ProjectServer(server.ID, events)
+	if projection.Events == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Server has no events at or before " + asOf.Format(time.RFC3339) + ".",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Server state rebuilt successfully",
+		"asOf":           asOf,
+		"server":         projection,
+		"archivedEvents": archived,
+	})
+}
+
+// CheckConsistency rebuilds servers from their events and reports every field
+// where the rebuilt state and the servers table disagree. ?serverId= checks a
+// single server.
+func (h *ServerHandler) CheckConsistency(c *gin.Context) {
+	if !h.authorize(c, service.PermissionAuditRead, "") {
+		return
+	}
+
+	checked := 0
+	drifted := []serverConsistency{}
+	check := func(batch []models.Server) error {
+		for i := range batch {
+			server := &batch[i]
+			events, archived, err := loadServerEvents(h.logs, server.ID, nil)
+			if err != nil {
+				return err
+			}
+			checked++
+			if drift := service.ProjectServer(server.ID, events).Drift(server); len(drift) > 0 {
+				drifted = append(drifted, serverConsistency{ServerID: server.ID, Name: server.Name, Drift: drift, ArchivedEvents: archived})
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if serverId := c.Query("serverId"); serverId != "" {
+		var server *models.Server
+		if server, err = h.servers.Get(serverId, projectOf(c)); err == nil {
+			err = check([]models.Server{*server})
+		} else if err == repository.ErrNotFound {
+			err = nil
+		}
+	} else {
+		err = h.servers.InBatches(projectOf(c), consistencyBatchSize, check)
+	}
+	if err != nil {
+		log.Printf("Error checking server consistency: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error checking server consistency",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := "Servers match their events"
+	if len(drifted) > 0 {
+		message = "Servers drifted from their events"
+		log.Printf("WARNING: %d of %d servers drifted from their events.\n", len(drifted), checked)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"checked": checked,
+		"drifted": len(drifted),
+		"servers": drifted,
+	})
+}
+
+// loadServerEvents returns the server's events up to until (all of them when
+// nil), oldest first, and how many of its events were archived by log
+// retention and cannot be replayed.
+func loadServerEvents(logs repository.LogRepository, serverId string, until *time.Time) ([]models.ServerLog, int64, error) {
+	events, err := logs.Events(serverId, until)
+	if err != nil {
+		return nil, 0, err
+	}
+	archived, err := logs.ArchivedCount(serverId)
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, archived, nil
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+func TestServerProjection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.GET("/api/servers/:id", h.GetServer)
+	router.PUT("/api/servers/:id/tags", h.UpdateTags)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+	router.GET("/api/audit/consistency", h.CheckConsistency)
+
+	asOf := func(id string, at time.Time) (int, service.ServerProjection) {
+		rec := sendJSON(t, router, http.MethodGet, "/api/servers/"+id+"?asOf="+url.QueryEscape(at.Format(time.RFC3339Nano)), "")
+		var body struct {
+			Server service.ServerProjection `json:"server"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Server
+	}
+	pause := func() time.Time {
+		time.Sleep(5 * time.Millisecond)
+		at := time.Now()
+		time.Sleep(5 * time.Millisecond)
+		return at
+	}
+
+	before := pause()
+	id := createTestServer(t, router, `{"region": "India", "type": "basic", "name": "alerting"}`)
+	afterCreate := pause()
+	sendJSON(t, router, http.MethodPut, "/api/servers/"+id+"/tags", `{"tags": {"env": "prod"}}`)
+	sendJSON(t, router, http.MethodPost, "/api/servers/"+id+"/action", `{"action": "stop"}`)
+
+	t.Run("Point in time", func(t *testing.T) {
+		if code, _ := asOf(id, before); code != http.StatusNotFound {
+			t.Errorf("Expected status %d before the server existed, got %d", http.StatusNotFound, code)
+		}
+
+		code, projection := asOf(id, afterCreate)
+		if code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+		if projection.Status != service.StatusRunning || projection.Name != "alerting" || len(projection.Tags) != 0 {
+			t.Errorf("Unexpected state after creation %+v", projection)
+		}
+
+		_, projection = asOf(id, time.Now())
+		if projection.Status != service.StatusStopped || projection.Tags["env"] != "prod" || projection.Type != "basic" {
+			t.Errorf("Unexpected current state %+v", projection)
+		}
+
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers/"+id+"?asOf=yesterday", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an invalid timestamp, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	type report struct {
+		Checked int `json:"checked"`
+		Drifted int `json:"drifted"`
+		Servers []struct {
+			ServerID string                `json:"serverId"`
+			Drift    []service.ServerDrift `json:"drift"`
+		} `json:"servers"`
+	}
+	check := func() report {
+		rec := sendJSON(t, router, http.MethodGet, "/api/audit/consistency", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var body report
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return body
+	}
+
+	t.Run("Consistent", func(t *testing.T) {
+		if body := check(); body.Checked != 1 || body.Drifted != 0 {
+			t.Errorf("Expected 1 consistent server, got %+v", body)
+		}
+	})
+
+	t.Run("Drift", func(t *testing.T) {
+		testDB.Model(&models.Server{}).Where("id = ?", id).Updates(map[string]interface{}{"type": "prime", "status": service.StatusRunning})
+		body := check()
+		if body.Drifted != 1 || len(body.Servers) != 1 || body.Servers[0].ServerID != id {
+			t.Fatalf("Expected the server to drift, got %+v", body)
+		}
+		fields := map[string]service.ServerDrift{}
+		for _, drift := range body.Servers[0].Drift {
+			fields[drift.Field] = drift
+		}
+		if len(fields) != 2 || fields["type"].Projected != "basic" || fields["status"].Actual != service.StatusRunning {
+			t.Errorf("Unexpected drift %+v", body.Servers[0].Drift)
+		}
+	})
+}
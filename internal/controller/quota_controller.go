@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/repository"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errQuotaExceeded = errors.New("quota exceeded")
+
+// GetProjectQuotas reports the project's limits next to its live usage.
+func (h *ServerHandler) GetProjectQuotas(c *gin.Context) {
+	if !h.authorize(c, service.PermissionProjectsRead, "") {
+		return
+	}
+	project, ok := h.findProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	quota, err := loadProjectQuota(h.db, project.ID)
+	if err != nil {
+		log.Printf("Error fetching quotas of project '%s': %v\n", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching project quotas",
+			"error":   err.Error(),
+		})
+		return
+	}
+	usage, err := projectQuotaUsage(h.db, h.servers, project.ID, time.Now())
+	if err != nil {
+		log.Printf("Error computing usage of project '%s': %v\n", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching project quotas",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Project quotas fetched successfully",
+		"projectId":   project.ID,
+		"definitions": quota,
+		"quotas":      service.QuotaStatuses(quota, usage),
+		"usage":       usage,
+	})
+}
+
+// SetProjectQuotas replaces the project's quota definitions. Leaving a limit
+// out removes it. Credentials bound to the project cannot change its quotas.
+func (h *ServerHandler) SetProjectQuotas(c *gin.Context) {
+	if !h.authorize(c, service.PermissionProjectsWrite, "") {
+		return
+	}
+	if projectOf(c) != "" {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Credentials bound to a project cannot change quotas."})
+		return
+	}
+	project, ok := h.findProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var quota models.ProjectQuota
+	if err := c.ShouldBindJSON(&quota); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if errorMessage := service.ValidateQuota(&quota); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	quota.ProjectID = project.ID
+	if err := h.db.Save(&quota).Error; err != nil {
+		log.Printf("Error saving quotas of project '%s': %v\n", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error saving project quotas",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("Quotas of project '%s' set by %s.\n", project.Name, actorOf(c))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project quotas updated successfully",
+		"quota":   quota,
+	})
+}
+
+// enforceQuota locks the project and checks that change, applied to its
+// current usage, stays within its quotas. It must run inside the transaction
+// that makes the change, so that concurrent requests are checked one after
+// the other. Servers outside a project have no quotas.
+func enforceQuota(tx *gorm.DB, servers repository.ServerRepository, projectId string, now time.Time, change func(*service.QuotaUsage)) (*service.QuotaViolation, error) {
+	if projectId == "" {
+		return nil, nil
+	}
+	var project models.Project
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, "id = ?", projectId).Error; err != nil {
+		return nil, err
+	}
+
+	quota, err := loadProjectQuota(tx, projectId)
+	if err != nil || quota == nil {
+		return nil, err
+	}
+	before, err := projectQuotaUsage(tx, servers, projectId, now)
+	if err != nil {
+		return nil, err
+	}
+	after := before.Clone()
+	change(&after)
+	return service.CheckQuota(quota, before, after), nil
+}
+
+func loadProjectQuota(tx *gorm.DB, projectId string) (*models.ProjectQuota, error) {
+	var quotas []models.ProjectQuota
+	if err := tx.Where("project_id = ?", projectId).Limit(1).Find(&quotas).Error; err != nil {
+		return nil, err
+	}
+	if len(quotas) == 0 {
+		return nil, nil
+	}
+	return &quotas[0], nil
+}
+
+// projectQuotaUsage counts the project's servers and projects its spend for
+// the month that contains now. A server being resized counts as its new type.
+func projectQuotaUsage(tx *gorm.DB, servers repository.ServerRepository, projectId string, now time.Time) (service.QuotaUsage, error) {
+	usage := service.NewQuotaUsage()
+
+	live, err := servers.FindBySelector(service.Selector{}, "", projectId)
+	if err != nil {
+		return usage, err
+	}
+	for _, server := range live {
+		instanceType := server.Type
+		if server.ResizingTo != "" {
+			instanceType = server.ResizingTo
+		}
+		usage.AddServer(instanceType, server.Region, 1)
+	}
+
+	monthStart, monthEnd := monthOf(now)
+	var periods []models.BillingPeriod
+	if err := tx.Where("project_id = ? AND started_at < ? AND (ended_at IS NULL OR ended_at > ?)", projectId, monthEnd, monthStart).
+		Find(&periods).Error; err != nil {
+		return usage, err
+	}
+	for _, period := range periods {
+		// Open periods run to the end of the month, which makes this a projection.
+		_, cost := service.PeriodCost(period.Rate, period.StartedAt, period.EndedAt, monthStart, monthEnd)
+		usage.MonthlySpend += cost
+	}
+	return usage, nil
+}
+
+// remainingMonthHours is how many hours of the month containing now are left.
+func remainingMonthHours(now time.Time) float64 {
+	_, monthEnd := monthOf(now)
+	return monthEnd.Sub(now).Hours()
+}
+
+func monthOf(now time.Time) (time.Time, time.Time) {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+func quotaExceededResponse(violation *service.QuotaViolation) gin.H {
+	return gin.H{
+		"message": violation.Message(),
+		"code":    service.CodeQuotaExceeded,
+		"quota":   violation,
+	}
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+)
+
+func TestProjectQuotas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testDB, h := setupTestDB(t)
+
+	t.Setenv("AUTH_DISABLED", "true")
+
+	router := gin.Default()
+	api := router.Group("/api", middleware.Authenticate(testDB))
+	api.POST("/server", h.CreateServer)
+	api.POST("/servers/:id/action", h.CompleteAction)
+	api.GET("/projects/:id/quotas", h.GetProjectQuotas)
+	api.PUT("/projects/:id/quotas", h.SetProjectQuotas)
+
+	project := models.Project{ID: uuid.New().String(), OrganizationID: uuid.New().String(), Name: "quota"}
+	testDB.Create(&project)
+
+	// Quotas are set by a global caller; everything else acts in the project.
+	inProject := []string{"X-Project-ID", project.ID}
+	quotaError := func(rec *httptest.ResponseRecorder) (string, *service.QuotaViolation) {
+		var body struct {
+			Code  string                  `json:"code"`
+			Quota *service.QuotaViolation `json:"quota"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return body.Code, body.Quota
+	}
+
+	if rec := sendJSON(t, router, http.MethodPut, "/api/projects/"+project.ID+"/quotas", `{"maxServersPerType": {"huge": 1}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown type, got %d", http.StatusBadRequest, rec.Code)
+	}
+	rec := sendJSON(t, router, http.MethodPut, "/api/projects/"+project.ID+"/quotas", `{"maxServers": 2, "maxServersPerType": {"prime": 0}, "maxVcpusPerRegion": 2}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		ids = append(ids, createTestServer(t, router, fmt.Sprintf(`{"region": "India", "type": "basic", "name": "q%d"}`, i), inProject...))
+	}
+
+	t.Run("Create over quota", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodPost, "/api/server", `{"region": "US", "type": "basic"}`, inProject...)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body.String())
+		}
+		code, violation := quotaError(rec)
+		if code != service.CodeQuotaExceeded || violation == nil || violation.Quota != "servers" || violation.Usage != 2 || violation.Limit != 2 {
+			t.Errorf("Unexpected quota error %s %+v", code, violation)
+		}
+		var count int64
+		testDB.Model(&models.Server{}).Where("project_id = ?", project.ID).Count(&count)
+		if count != 2 {
+			t.Errorf("Expected 2 servers in the project, got %d", count)
+		}
+	})
+
+	t.Run("Resize over quota", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodPost, "/api/servers/"+ids[0]+"/action", `{"action": "resize", "type": "prime", "online": true}`, inProject...)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body.String())
+		}
+		if _, violation := quotaError(rec); violation == nil || violation.Quota != "servers.prime" {
+			t.Errorf("Expected the prime quota to be exceeded, got %+v", violation)
+		}
+
+		rec = sendJSON(t, router, http.MethodPost, "/api/servers/"+ids[0]+"/action", `{"action": "resize", "type": "plus", "online": true}`, inProject...)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body.String())
+		}
+		if _, violation := quotaError(rec); violation == nil || violation.Quota != "vcpus.India" || violation.Usage != 2 || violation.Requested != 1 {
+			t.Errorf("Expected the vCPU quota to be exceeded, got %+v", violation)
+		}
+
+		var server models.Server
+		testDB.First(&server, "id = ?", ids[0])
+		if server.Type != "basic" || server.Status != service.StatusRunning || server.ResizingTo != "" {
+			t.Errorf("Denied resize changed the server: %+v", server)
+		}
+		var denied int64
+		testDB.Model(&models.ServerLog{}).Where("server_id = ? AND event_type = ? AND message LIKE ?", ids[0], "ACTION_DENIED", "%QUOTA_EXCEEDED%").Count(&denied)
+		if denied != 2 {
+			t.Errorf("Expected 2 quota denials in the log, got %d", denied)
+		}
+	})
+
+	t.Run("Live usage", func(t *testing.T) {
+		rec := sendJSON(t, router, http.MethodGet, "/api/projects/"+project.ID+"/quotas", "", inProject...)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Quotas []service.QuotaStatus `json:"quotas"`
+			Usage  service.QuotaUsage    `json:"usage"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if body.Usage.Servers != 2 || body.Usage.VCPUsPerRegion["India"] != 2 || body.Usage.MonthlySpend <= 0 {
+			t.Errorf("Unexpected usage %+v", body.Usage)
+		}
+		expected := map[string][2]float64{"servers": {2, 2}, "servers.prime": {0, 0}, "vcpus.India": {2, 2}}
+		if len(body.Quotas) != len(expected) {
+			t.Fatalf("Expected %d quotas, got %+v", len(expected), body.Quotas)
+		}
+		for _, quota := range body.Quotas {
+			if want := expected[quota.Quota]; quota.Limit != want[0] || quota.Usage != want[1] {
+				t.Errorf("Quota %s: expected limit %v and usage %v, got %v and %v", quota.Quota, want[0], want[1], quota.Limit, quota.Usage)
+			}
+		}
+	})
+
+	t.Run("Terminating frees quota", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/"+ids[1]+"/action", `{"action": "terminate"}`, inProject...); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/server", `{"region": "US", "type": "basic"}`, inProject...); rec.Code != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+	})
+}
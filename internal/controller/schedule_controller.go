@@ -0,0 +1,335 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ScheduleOutcomeSkipped = "skipped"
+
+var schedulerOrigin = logger.System("scheduler")
+
+func (h *ServerHandler) CreateSchedule(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersSchedule, server.ID) {
+		return
+	}
+
+	var req struct {
+		Action          string     `json:"action"`
+		RunAt           *time.Time `json:"runAt"`
+		Cron            string     `json:"cron"`
+		TimeZone        string     `json:"timeZone"`
+		MissedRunPolicy string     `json:"missedRunPolicy"`
+		// ConfirmationToken confirms a scheduled terminate of a production
+		// server; the scheduler cannot answer the confirmation itself.
+		ConfirmationToken string `json:"confirmationToken"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if server.Status == service.StatusTerminated {
+		c.JSON(http.StatusConflict, gin.H{"message": "Cannot schedule actions on a terminated server."})
+		return
+	}
+	if !service.IsAction(req.Action) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Action '%s' is not supported.", req.Action),
+		})
+		return
+	}
+	if !h.authorize(c, service.ActionPermission(req.Action), server.ID) {
+		return
+	}
+	if (req.RunAt == nil) == (req.Cron == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Specify exactly one of runAt or cron."})
+		return
+	}
+	if errorMessage := service.ValidateMissedRunPolicy(&req.MissedRunPolicy); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	if req.TimeZone == "" {
+		req.TimeZone = defaultScheduleTimeZone()
+	}
+	loc, err := time.LoadLocation(req.TimeZone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Unknown time zone '%s'.", req.TimeZone),
+		})
+		return
+	}
+
+	now := time.Now()
+	var nextRunAt time.Time
+	if req.RunAt != nil {
+		if !req.RunAt.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "runAt must be in the future."})
+			return
+		}
+		nextRunAt = req.RunAt.UTC()
+	} else {
+		cron, err := service.ParseCron(req.Cron)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid cron expression",
+				"error":   err.Error(),
+			})
+			return
+		}
+		nextRunAt = cron.Next(now.In(loc)).UTC()
+		if nextRunAt.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Cron expression never matches."})
+			return
+		}
+	}
+
+	// Checked last, so a request refused for any other reason does not use
+	// up the token.
+	confirmed := false
+	if req.Action == service.ActionTerminate && productionSelector().Matches(server.Tags) {
+		switch {
+		case req.ConfirmationToken == "":
+			requireConfirmation(c, server.ID, "Scheduling the termination of a production server must be confirmed with a confirmation token.")
+			return
+		case !terminationConfirmations.Consume(server.ID, req.ConfirmationToken):
+			requireConfirmation(c, server.ID, "The confirmation token is invalid or has expired.")
+			return
+		}
+		confirmed = true
+	}
+
+	schedule := models.Schedule{
+		ID:              uuid.New().String(),
+		ServerID:        server.ID,
+		Action:          req.Action,
+		RunAt:           req.RunAt,
+		Cron:            req.Cron,
+		TimeZone:        req.TimeZone,
+		MissedRunPolicy: req.MissedRunPolicy,
+		Confirmed:       confirmed,
+		Enabled:         true,
+		NextRunAt:       &nextRunAt,
+	}
+	if err := h.db.Create(&schedule).Error; err != nil {
+		log.Printf("Error creating schedule for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error creating schedule",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.events.LogServerEventAs(originOf(c), server.ID, "SCHEDULE_CREATED",
+		fmt.Sprintf("Scheduled '%s' %s; next run at %s.", schedule.Action, describeSchedule(&schedule), nextRunAt.In(loc).Format(time.RFC3339)), nil, nil)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Schedule created successfully",
+		"schedule": schedule,
+	})
+}
+
+func (h *ServerHandler) ListSchedules(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersRead, server.ID) {
+		return
+	}
+
+	var schedules []models.Schedule
+	if err := h.db.Where("server_id = ?", server.ID).Order("created_at").Find(&schedules).Error; err != nil {
+		log.Printf("Error fetching schedules for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching schedules",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Schedules fetched successfully",
+		"schedules": schedules,
+	})
+}
+
+func (h *ServerHandler) DeleteSchedule(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersSchedule, server.ID) {
+		return
+	}
+
+	scheduleId := c.Param("scheduleId")
+	result := h.db.Where("id = ? AND server_id = ?", scheduleId, server.ID).Delete(&models.Schedule{})
+	if result.Error != nil {
+		log.Printf("Error deleting schedule '%s': %v\n", scheduleId, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting schedule",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("Schedule with ID '%s' not found.", scheduleId),
+		})
+		return
+	}
+
+	h.events.LogServerEventAs(originOf(c), server.ID, "SCHEDULE_DELETED", fmt.Sprintf("Schedule '%s' deleted.", scheduleId), nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+// RunScheduler executes due schedules every interval until the process exits.
+// The first pass happens immediately so runs missed while the app was down are
+// dealt with on startup.
+func (h *ServerHandler) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.RunDueSchedules(time.Now())
+		<-ticker.C
+	}
+}
+
+// RunDueSchedules runs every enabled schedule whose next run is at or before
+// now. A run that is later than service.MissedRunGrace was missed; it is either
+// run once or skipped according to the schedule's policy. Several missed
+// occurrences of a cron schedule only ever produce a single run.
+func (h *ServerHandler) RunDueSchedules(now time.Time) {
+	// Run times are stored in UTC so they compare correctly in every database.
+	now = now.UTC()
+	var due []models.Schedule
+	err := h.db.Where("enabled = ? AND next_run_at <= ?", true, now).Order("next_run_at").Find(&due).Error
+	if err != nil {
+		log.Printf("WARNING: Failed to load due schedules: %v\n", err)
+		return
+	}
+
+	for i := range due {
+		h.runSchedule(&due[i], now)
+	}
+}
+
+func (h *ServerHandler) runSchedule(schedule *models.Schedule, now time.Time) {
+	dueAt := *schedule.NextRunAt
+
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	var nextRunAt *time.Time
+	if schedule.Cron != "" {
+		if cron, err := service.ParseCron(schedule.Cron); err == nil {
+			if next := cron.Next(now.In(loc)).UTC(); !next.IsZero() {
+				nextRunAt = &next
+			}
+		}
+	}
+
+	// Claim the run by moving next_run_at past now, so that a second scheduler
+	// working from the same database cannot run it as well.
+	claim := h.db.Model(&models.Schedule{}).
+		Where("id = ? AND enabled = ? AND next_run_at <= ?", schedule.ID, true, now).
+		Updates(map[string]interface{}{
+			"next_run_at": nextRunAt,
+			"enabled":     nextRunAt != nil,
+		})
+	if claim.Error != nil {
+		log.Printf("WARNING: Failed to claim schedule %s: %v\n", schedule.ID, claim.Error)
+		return
+	}
+	if claim.RowsAffected == 0 {
+		return
+	}
+
+	outcome, message := h.executeSchedule(schedule, dueAt, now)
+
+	updates := map[string]interface{}{
+		"last_run_at":  now,
+		"last_outcome": outcome,
+		"last_message": message,
+	}
+	if outcome == OutcomeMissing || message == terminatedScheduleMessage {
+		updates["enabled"] = false
+		updates["next_run_at"] = nil
+	}
+	if err := h.db.Model(&models.Schedule{}).Where("id = ?", schedule.ID).Updates(updates).Error; err != nil {
+		log.Printf("WARNING: Failed to record run of schedule %s: %v\n", schedule.ID, err)
+	}
+}
+
+const terminatedScheduleMessage = "Server is terminated; schedule disabled."
+
+func (h *ServerHandler) executeSchedule(schedule *models.Schedule, dueAt, now time.Time) (string, string) {
+	if now.Sub(dueAt) > service.MissedRunGrace && schedule.MissedRunPolicy == service.MissedRunSkip {
+		message := fmt.Sprintf("Missed run due at %s was skipped.", dueAt.Format(time.RFC3339))
+		h.events.LogServerEventAs(schedulerOrigin, schedule.ServerID, "SCHEDULE_MISSED", message, nil, nil)
+		return ScheduleOutcomeSkipped, message
+	}
+
+	server, err := h.lookupServer(schedule.ServerID, "", "")
+	if err != nil {
+		log.Printf("WARNING: Schedule %s could not load server %s: %v\n", schedule.ID, schedule.ServerID, err)
+		if err == gorm.ErrRecordNotFound {
+			return OutcomeMissing, err.Error()
+		}
+		return OutcomeFailed, err.Error()
+	}
+	if server.Status == service.StatusTerminated {
+		return OutcomeDenied, terminatedScheduleMessage
+	}
+
+	result := h.applyServerAction(server, schedule.Action, actionOptions{
+		AllowNoOp: true,
+		Confirmed: schedule.Confirmed,
+		Reason:    service.TerminationReasonScheduled,
+		Origin:    schedulerOrigin,
+	})
+	message := fmt.Sprintf("Scheduled '%s' (due %s): %s.", schedule.Action, dueAt.Format(time.RFC3339), result.Outcome)
+	if result.Reason != "" {
+		message = fmt.Sprintf("%s %s", message, result.Reason)
+	}
+	h.events.LogServerEventAs(schedulerOrigin, server.ID, "SCHEDULED_ACTION", message, logger.StringPtr(result.OldStatus), nil)
+	return result.Outcome, message
+}
+
+func describeSchedule(schedule *models.Schedule) string {
+	if schedule.Cron != "" {
+		return fmt.Sprintf("on '%s' (%s)", schedule.Cron, schedule.TimeZone)
+	}
+	return "once"
+}
+
+// defaultScheduleTimeZone uses the same zone as the database connection.
+func defaultScheduleTimeZone() string {
+	if tz := os.Getenv("DB_TIME_ZONE"); tz != "" {
+		return tz
+	}
+	return "UTC"
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+func TestSchedules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/servers/:id/schedules", h.CreateSchedule)
+	router.GET("/api/servers/:id/schedules", h.ListSchedules)
+	router.DELETE("/api/servers/:id/schedules/:scheduleId", h.DeleteSchedule)
+
+	id := createTestServer(t, router, `{"region": "India", "type": "basic", "name": "dev-1"}`)
+
+	createSchedule := func(body string) models.Schedule {
+		rec := sendJSON(t, router, http.MethodPost, "/api/servers/dev-1/schedules", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Schedule models.Schedule `json:"schedule"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return response.Schedule
+	}
+	reload := func(id string) models.Schedule {
+		var schedule models.Schedule
+		testDB.First(&schedule, "id = ?", id)
+		return schedule
+	}
+	status := func() string {
+		var server models.Server
+		testDB.First(&server, "id = ?", id)
+		return server.Status
+	}
+	setStatus := func(status string) {
+		testDB.Model(&models.Server{}).Where("id = ?", id).Update("status", status)
+	}
+
+	t.Run("Invalid schedules", func(t *testing.T) {
+		future := time.Now().Add(time.Hour).Format(time.RFC3339)
+		for _, body := range []string{
+			`{"action": "explode", "cron": "0 19 * * 1-5"}`,
+			`{"action": "stop"}`,
+			`{"action": "stop", "cron": "0 19 * * 1-5", "runAt": "` + future + `"}`,
+			`{"action": "stop", "cron": "0 25 * * *"}`,
+			`{"action": "stop", "cron": "0 19 * * *", "timeZone": "Mars/Olympus"}`,
+			`{"action": "stop", "cron": "0 19 * * *", "missedRunPolicy": "maybe"}`,
+			`{"action": "stop", "runAt": "2001-01-01T00:00:00Z"}`,
+		} {
+			if rec := sendJSON(t, router, http.MethodPost, "/api/servers/dev-1/schedules", body); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, rec.Code)
+			}
+		}
+	})
+
+	t.Run("One-off schedule runs once", func(t *testing.T) {
+		runAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		schedule := createSchedule(`{"action": "stop", "runAt": "` + runAt.Format(time.RFC3339) + `"}`)
+
+		h.RunDueSchedules(runAt.Add(-time.Minute))
+		if status() != service.StatusRunning {
+			t.Fatalf("Schedule ran early")
+		}
+
+		h.RunDueSchedules(runAt.Add(time.Minute))
+		if status() != service.StatusStopped {
+			t.Fatalf("Expected server to be stopped, got %s", status())
+		}
+		if s := reload(schedule.ID); s.Enabled || s.LastOutcome != OutcomeChanged {
+			t.Errorf("Expected a disabled schedule with outcome changed, got %+v", s)
+		}
+	})
+
+	t.Run("Recurring schedule in time zone", func(t *testing.T) {
+		setStatus(service.StatusRunning)
+		schedule := createSchedule(`{"action": "stop", "cron": "0 19 * * *", "timeZone": "Asia/Kolkata"}`)
+		kolkata, _ := time.LoadLocation("Asia/Kolkata")
+		if next := schedule.NextRunAt.In(kolkata); next.Hour() != 19 || next.Minute() != 0 {
+			t.Fatalf("Expected next run at 19:00 Asia/Kolkata, got %s", next)
+		}
+
+		due := *schedule.NextRunAt
+		h.RunDueSchedules(due.Add(time.Minute))
+		s := reload(schedule.ID)
+		if status() != service.StatusStopped || !s.Enabled || s.NextRunAt == nil || !s.NextRunAt.Equal(due.Add(24*time.Hour)) {
+			t.Errorf("Expected server stopped and next run a day later, got %s and %+v", status(), s)
+		}
+
+		// Running again at the same time must not repeat the run.
+		h.RunDueSchedules(due.Add(time.Minute))
+		if reload(schedule.ID).LastRunAt.After(due.Add(time.Minute)) {
+			t.Errorf("Schedule ran twice")
+		}
+		testDB.Delete(&models.Schedule{}, "id = ?", schedule.ID)
+	})
+
+	t.Run("Missed runs", func(t *testing.T) {
+		setStatus(service.StatusRunning)
+		skip := createSchedule(`{"action": "stop", "cron": "0 19 * * *", "missedRunPolicy": "skip"}`)
+		due := *skip.NextRunAt
+
+		// Three days of downtime: the skip policy does nothing and moves on.
+		h.RunDueSchedules(due.Add(72 * time.Hour))
+		s := reload(skip.ID)
+		if status() != service.StatusRunning || s.LastOutcome != ScheduleOutcomeSkipped || !s.NextRunAt.After(due.Add(72*time.Hour)) {
+			t.Errorf("Expected the missed run to be skipped, got %s and %+v", status(), s)
+		}
+		testDB.Delete(&models.Schedule{}, "id = ?", skip.ID)
+
+		once := createSchedule(`{"action": "reboot", "cron": "0 19 * * *"}`)
+		h.RunDueSchedules(once.NextRunAt.Add(72 * time.Hour))
+		if s := reload(once.ID); s.LastOutcome != OutcomeChanged {
+			t.Errorf("Expected the missed run to happen once, got %+v", s)
+		}
+		testDB.Delete(&models.Schedule{}, "id = ?", once.ID)
+	})
+
+	t.Run("Terminated server disables schedule", func(t *testing.T) {
+		setStatus(service.StatusRunning)
+		schedule := createSchedule(`{"action": "start", "cron": "0 8 * * 1-5"}`)
+		setStatus(service.StatusTerminated)
+		h.RunDueSchedules(schedule.NextRunAt.Add(time.Minute))
+		if s := reload(schedule.ID); s.Enabled || s.NextRunAt != nil {
+			t.Errorf("Expected schedule to be disabled, got %+v", s)
+		}
+	})
+
+	t.Run("List and delete", func(t *testing.T) {
+		setStatus(service.StatusRunning)
+		schedule := createSchedule(`{"action": "start", "cron": "0 8 * * 1-5"}`)
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers/dev-1/schedules", ""); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/servers/dev-1/schedules/"+schedule.ID, ""); rec.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/servers/dev-1/schedules/"+schedule.ID, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("Production terminate is confirmed at creation", func(t *testing.T) {
+		createTestServer(t, router, `{"region": "India", "type": "basic", "name": "prod-1", "tags": {"env": "prod"}}`)
+		runAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		body := `{"action": "terminate", "runAt": "` + runAt.Format(time.RFC3339) + `"`
+
+		rec := sendJSON(t, router, http.MethodPost, "/api/servers/prod-1/schedules", body+`}`)
+		var denial struct {
+			Code              string `json:"code"`
+			ConfirmationToken string `json:"confirmationToken"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &denial)
+		if rec.Code != http.StatusConflict || denial.Code != service.CodeConfirmationRequired || denial.ConfirmationToken == "" {
+			t.Fatalf("Expected a confirmation token, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/prod-1/schedules", body+`, "confirmationToken": "invalid"}`); rec.Code != http.StatusConflict {
+			t.Errorf("Expected an invalid token to be refused, got %d", rec.Code)
+		}
+
+		rec = sendJSON(t, router, http.MethodPost, "/api/servers/prod-1/schedules", body+`, "confirmationToken": "`+denial.ConfirmationToken+`"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Schedule models.Schedule `json:"schedule"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		h.RunDueSchedules(runAt.Add(time.Minute))
+		var server models.Server
+		testDB.First(&server, "name = ?", "prod-1")
+		if s := reload(response.Schedule.ID); server.Status != service.StatusTerminated || s.LastOutcome != OutcomeChanged {
+			t.Errorf("Expected the confirmed schedule to terminate the server, got %s and %+v", server.Status, s)
+		}
+	})
+}
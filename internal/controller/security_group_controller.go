@@ -0,0 +1,584 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type securityGroupRuleRequest struct {
+	Direction     string `json:"direction"`
+	Protocol      string `json:"protocol"`
+	FromPort      int    `json:"fromPort"`
+	ToPort        int    `json:"toPort"`
+	CIDR          string `json:"cidr"`
+	SourceGroupID string `json:"sourceGroupId"`
+	Description   string `json:"description"`
+}
+
+func (r securityGroupRuleRequest) toModel(groupID string) models.SecurityGroupRule {
+	return models.SecurityGroupRule{
+		ID:              uuid.New().String(),
+		SecurityGroupID: groupID,
+		Direction:       r.Direction,
+		Protocol:        r.Protocol,
+		FromPort:        r.FromPort,
+		ToPort:          r.ToPort,
+		CIDR:            r.CIDR,
+		SourceGroupID:   r.SourceGroupID,
+		Description:     r.Description,
+	}
+}
+
+// validateRule checks the rule itself and that a referenced source group exists
+// in the project.
+func (h *ServerHandler) validateRule(rule *models.SecurityGroupRule, projectId string) string {
+	if errorMessage := service.ValidateSecurityGroupRule(rule); errorMessage != "" {
+		return errorMessage
+	}
+	if rule.SourceGroupID != "" && rule.SourceGroupID != rule.SecurityGroupID {
+		var count int64
+		scopeToProject(h.db.Model(&models.SecurityGroup{}), projectId).Where("id = ?", rule.SourceGroupID).Count(&count)
+		if count == 0 {
+			return fmt.Sprintf("Source security group '%s' not found.", rule.SourceGroupID)
+		}
+	}
+	return ""
+}
+
+func (h *ServerHandler) CreateSecurityGroup(c *gin.Context) {
+	if !h.authorize(c, service.PermissionSecurityGroupsWrite, "") {
+		return
+	}
+
+	var req struct {
+		Name        string                     `json:"name"`
+		Description string                     `json:"description"`
+		Rules       []securityGroupRuleRequest `json:"rules"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Security group name is required."})
+		return
+	}
+
+	var existing int64
+	scopeToProject(h.db.Model(&models.SecurityGroup{}), projectOf(c)).Where("name = ?", req.Name).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Security group with name '%s' already exists.", req.Name),
+		})
+		return
+	}
+
+	group := models.SecurityGroup{
+		ID:          uuid.New().String(),
+		ProjectID:   projectOf(c),
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	hasEgress := false
+	for _, r := range req.Rules {
+		rule := r.toModel(group.ID)
+		if errorMessage := h.validateRule(&rule, group.ProjectID); errorMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+			return
+		}
+		if rule.Direction == service.DirectionEgress {
+			hasEgress = true
+		}
+		group.Rules = append(group.Rules, rule)
+	}
+
+	// Like most clouds, a new group allows all outbound traffic unless the
+	// caller supplies egress rules of its own.
+	if !hasEgress {
+		for _, cidr := range []string{"0.0.0.0/0", "::/0"} {
+			group.Rules = append(group.Rules, models.SecurityGroupRule{
+				ID:              uuid.New().String(),
+				SecurityGroupID: group.ID,
+				Direction:       service.DirectionEgress,
+				Protocol:        service.ProtocolAll,
+				CIDR:            cidr,
+				Description:     "default egress",
+			})
+		}
+	}
+
+	if err := h.db.Create(&group).Error; err != nil {
+		log.Printf("Error creating security group '%s': %v\n", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error creating security group",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for _, rule := range group.Rules {
+		h.logRuleChange(originOf(c), group.ID, "FIREWALL_RULE_ADDED",
+			fmt.Sprintf("Rule added to security group '%s': %s.", group.Name, service.DescribeSecurityGroupRule(rule)))
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Security group created successfully",
+		"securityGroup": group,
+	})
+}
+
+func (h *ServerHandler) ListSecurityGroups(c *gin.Context) {
+	if !h.authorize(c, service.PermissionSecurityGroupsRead, "") {
+		return
+	}
+
+	var groups []models.SecurityGroup
+	if err := scopeToProject(h.db.Preload("Rules").Order("created_at"), projectOf(c)).Find(&groups).Error; err != nil {
+		log.Printf("Error fetching security groups: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching security groups",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Security groups fetched successfully",
+		"securityGroups": groups,
+	})
+}
+
+func (h *ServerHandler) GetSecurityGroup(c *gin.Context) {
+	if !h.authorize(c, service.PermissionSecurityGroupsRead, "") {
+		return
+	}
+
+	group, ok := h.findSecurityGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Security group fetched successfully",
+		"securityGroup": group,
+	})
+}
+
+func (h *ServerHandler) DeleteSecurityGroup(c *gin.Context) {
+	if !h.authorize(c, service.PermissionSecurityGroupsWrite, "") {
+		return
+	}
+
+	group, ok := h.findSecurityGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var attached int64
+	h.db.Model(&models.ServerSecurityGroup{}).Where("security_group_id = ?", group.ID).Count(&attached)
+	if attached > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Security group '%s' is attached to %d server(s).", group.ID, attached),
+		})
+		return
+	}
+
+	var referenced int64
+	h.db.Model(&models.SecurityGroupRule{}).
+		Where("source_group_id = ? AND security_group_id <> ?", group.ID, group.ID).
+		Count(&referenced)
+	if referenced > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Security group '%s' is referenced by rules of other groups.", group.ID),
+		})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("security_group_id = ?", group.ID).Delete(&models.SecurityGroupRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&group).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting security group '%s': %v\n", group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting security group",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Security group deleted successfully"})
+}
+
+func (h *ServerHandler) AddSecurityGroupRule(c *gin.Context) {
+	if !h.authorize(c, service.PermissionSecurityGroupsWrite, "") {
+		return
+	}
+
+	group, ok := h.findSecurityGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req securityGroupRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rule := req.toModel(group.ID)
+	if errorMessage := h.validateRule(&rule, group.ProjectID); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	if err := h.db.Create(&rule).Error; err != nil {
+		log.Printf("Error adding rule to security group '%s': %v\n", group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error adding security group rule",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.logRuleChange(originOf(c), group.ID, "FIREWALL_RULE_ADDED",
+		fmt.Sprintf("Rule added to security group '%s': %s.", group.Name, service.DescribeSecurityGroupRule(rule)))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Security group rule added successfully",
+		"rule":    rule,
+	})
+}
+
+func (h *ServerHandler) DeleteSecurityGroupRule(c *gin.Context) {
+	if !h.authorize(c, service.PermissionSecurityGroupsWrite, "") {
+		return
+	}
+
+	group, ok := h.findSecurityGroup(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	ruleId := c.Param("ruleId")
+	var rule models.SecurityGroupRule
+	result := h.db.First(&rule, "id = ? AND security_group_id = ?", ruleId, group.ID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Rule with ID '%s' not found.", ruleId),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching security group rule",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+
+	if err := h.db.Delete(&rule).Error; err != nil {
+		log.Printf("Error deleting rule '%s': %v\n", rule.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error deleting security group rule",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.logRuleChange(originOf(c), group.ID, "FIREWALL_RULE_REMOVED",
+		fmt.Sprintf("Rule removed from security group '%s': %s.", group.Name, service.DescribeSecurityGroupRule(rule)))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Security group rule deleted successfully"})
+}
+
+func (h *ServerHandler) ListServerSecurityGroups(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersRead, server.ID) {
+		return
+	}
+
+	groups, err := h.serverSecurityGroups(server.ID)
+	if err != nil {
+		log.Printf("Error fetching security groups for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching security groups",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Server security groups fetched successfully",
+		"securityGroups": groups,
+	})
+}
+
+func (h *ServerHandler) AttachSecurityGroup(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersUpdate, server.ID) {
+		return
+	}
+
+	var req struct {
+		SecurityGroupID string `json:"securityGroupId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	group, ok := h.findSecurityGroup(c, req.SecurityGroupID)
+	if !ok {
+		return
+	}
+
+	if group.ProjectID != server.ProjectID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Security group and server belong to different projects."})
+		return
+	}
+	if server.Status == service.StatusTerminated {
+		c.JSON(http.StatusConflict, gin.H{"message": "Cannot attach a security group to a terminated server."})
+		return
+	}
+
+	var existing int64
+	h.db.Model(&models.ServerSecurityGroup{}).
+		Where("server_id = ? AND security_group_id = ?", server.ID, group.ID).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("Security group '%s' is already attached.", group.ID),
+		})
+		return
+	}
+
+	attachment := models.ServerSecurityGroup{ServerID: server.ID, SecurityGroupID: group.ID}
+	if err := h.db.Create(&attachment).Error; err != nil {
+		log.Printf("Error attaching security group '%s' to server '%s': %v\n", group.ID, server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error attaching security group",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.events.LogServerEventAs(originOf(c), server.ID, "SECURITY_GROUP_ATTACHED",
+		fmt.Sprintf("Security group '%s' (%s) attached.", group.Name, group.ID), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Security group attached successfully"})
+}
+
+func (h *ServerHandler) DetachSecurityGroup(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersUpdate, server.ID) {
+		return
+	}
+
+	groupId := c.Param("groupId")
+	result := h.db.Where("server_id = ? AND security_group_id = ?", server.ID, groupId).
+		Delete(&models.ServerSecurityGroup{})
+	if result.Error != nil {
+		log.Printf("Error detaching security group '%s' from server '%s': %v\n", groupId, server.ID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error detaching security group",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("Security group '%s' is not attached to server '%s'.", groupId, server.ID),
+		})
+		return
+	}
+
+	h.events.LogServerEventAs(originOf(c), server.ID, "SECURITY_GROUP_DETACHED",
+		fmt.Sprintf("Security group '%s' detached.", groupId), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Security group detached successfully"})
+}
+
+func (h *ServerHandler) EvaluateFirewall(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersRead, server.ID) {
+		return
+	}
+
+	var req struct {
+		Direction       string `json:"direction"`
+		Protocol        string `json:"protocol"`
+		SourceIP        string `json:"sourceIp"`
+		SourcePort      int    `json:"sourcePort"`
+		DestinationIP   string `json:"destinationIp"`
+		DestinationPort int    `json:"destinationPort"`
+		PeerServerID    string `json:"peerServerId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Direction == "" {
+		req.Direction = service.DirectionIngress
+	}
+
+	// Reuse rule validation so the packet is held to the same vocabulary.
+	probe := models.SecurityGroupRule{
+		Direction: req.Direction,
+		Protocol:  req.Protocol,
+		FromPort:  req.DestinationPort,
+		ToPort:    req.DestinationPort,
+		CIDR:      "0.0.0.0/0",
+	}
+	if errorMessage := service.ValidateSecurityGroupRule(&probe); errorMessage != "" || probe.Protocol == service.ProtocolAll {
+		if errorMessage == "" {
+			errorMessage = "Packet protocol must be 'tcp', 'udp' or 'icmp'."
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	remote := req.SourceIP
+	if probe.Direction == service.DirectionEgress {
+		remote = req.DestinationIP
+	}
+	if net.ParseIP(remote) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Invalid remote IP address '%s'.", remote),
+		})
+		return
+	}
+
+	groups, err := h.serverSecurityGroups(server.ID)
+	if err != nil {
+		log.Printf("Error fetching security groups for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching security groups",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var rules []models.SecurityGroupRule
+	for _, group := range groups {
+		rules = append(rules, group.Rules...)
+	}
+
+	packet := service.Packet{
+		Direction:       probe.Direction,
+		Protocol:        probe.Protocol,
+		SourceIP:        req.SourceIP,
+		SourcePort:      req.SourcePort,
+		DestinationIP:   req.DestinationIP,
+		DestinationPort: req.DestinationPort,
+	}
+
+	if req.PeerServerID != "" {
+		if err := h.db.Model(&models.ServerSecurityGroup{}).
+			Where("server_id = ?", req.PeerServerID).
+			Pluck("security_group_id", &packet.PeerGroupIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Error fetching peer security groups",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	decision := service.EvaluateFirewall(rules, packet)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Firewall evaluated successfully",
+		"decision": decision,
+	})
+}
+
+func (h *ServerHandler) findSecurityGroup(c *gin.Context, groupId string) (*models.SecurityGroup, bool) {
+	var group models.SecurityGroup
+	result := scopeToProject(h.db.Preload("Rules"), projectOf(c)).First(&group, "id = ?", groupId)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Security group with ID '%s' not found.", groupId),
+			})
+			return nil, false
+		}
+		log.Printf("Error fetching security group '%s': %v\n", groupId, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching security group",
+			"error":   result.Error.Error(),
+		})
+		return nil, false
+	}
+	return &group, true
+}
+
+func (h *ServerHandler) serverSecurityGroups(serverId string) ([]models.SecurityGroup, error) {
+	var groups []models.SecurityGroup
+	err := h.db.Preload("Rules").
+		Joins("JOIN server_security_groups ON server_security_groups.security_group_id = security_groups.id").
+		Where("server_security_groups.server_id = ?", serverId).
+		Order("server_security_groups.created_at").
+		Find(&groups).Error
+	return groups, err
+}
+
+// logRuleChange records a rule change against every server the group is
+// attached to, or once without a server when it is attached to none, so that
+// every change is in the log.
+func (h *ServerHandler) logRuleChange(origin logger.Origin, groupId, eventType, message string) {
+	var serverIds []string
+	if err := h.db.Model(&models.ServerSecurityGroup{}).
+		Where("security_group_id = ?", groupId).
+		Pluck("server_id", &serverIds).Error; err != nil {
+		log.Printf("WARNING: Failed to list servers for security group %s: %v\n", groupId, err)
+	}
+	if len(serverIds) == 0 {
+		serverIds = []string{""}
+	}
+	for _, serverId := range serverIds {
+		h.events.LogServerEventAs(origin, serverId, eventType, message, nil, nil)
+	}
+}
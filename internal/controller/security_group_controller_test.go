@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestSecurityGroupRuleLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/security-groups", h.CreateSecurityGroup)
+	router.POST("/api/security-groups/:id/rules", h.AddSecurityGroupRule)
+	router.POST("/api/servers/:id/security-groups", h.AttachSecurityGroup)
+
+	ruleEvents := func(serverId string) int64 {
+		var count int64
+		testDB.Model(&models.ServerLog{}).Where("event_type = ? AND server_id = ?", "FIREWALL_RULE_ADDED", serverId).Count(&count)
+		return count
+	}
+
+	rec := sendJSON(t, router, http.MethodPost, "/api/security-groups", `{"name": "web", "rules": [{"direction": "ingress", "protocol": "tcp", "fromPort": 443, "toPort": 443, "cidr": "0.0.0.0/0"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var created struct {
+		SecurityGroup models.SecurityGroup `json:"securityGroup"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	group := created.SecurityGroup.ID
+
+	// The inline rule and the two default egress rules.
+	if n := ruleEvents(""); n != 3 {
+		t.Errorf("Expected 3 group-level events for the rules created with the group, got %d", n)
+	}
+	sendJSON(t, router, http.MethodPost, "/api/security-groups/"+group+"/rules", `{"direction": "ingress", "protocol": "tcp", "fromPort": 22, "toPort": 22, "cidr": "10.0.0.0/8"}`)
+	if n := ruleEvents(""); n != 4 {
+		t.Errorf("Expected a rule added to an unattached group to be logged, got %d events", n)
+	}
+
+	server := createTestServer(t, router, `{"region": "India", "type": "basic"}`)
+	if rec := sendJSON(t, router, http.MethodPost, "/api/servers/"+server+"/security-groups", `{"securityGroupId": "`+group+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d attaching the group, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	sendJSON(t, router, http.MethodPost, "/api/security-groups/"+group+"/rules", `{"direction": "ingress", "protocol": "tcp", "fromPort": 80, "toPort": 80, "cidr": "0.0.0.0/0"}`)
+	if ruleEvents(server) != 1 || ruleEvents("") != 4 {
+		t.Errorf("Expected the change to an attached group to be logged on its server only")
+	}
+}
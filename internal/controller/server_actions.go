@@ -0,0 +1,552 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+)
+
+const (
+	OutcomeChanged = "changed"
+	OutcomeNoOp    = "no-op"
+	OutcomeDenied  = "denied"
+	OutcomeFailed  = "failed"
+	OutcomeMissing = "not_found"
+)
+
+const (
+	defaultBulkActionConcurrency = 8
+	maxBulkActionTargets         = 1000
+)
+
+type actionOptions struct {
+	// DryRun reports what would happen without saving or logging anything.
+	DryRun bool
+	// AllowNoOp reports a server already in the action's target state as a
+	// no-op instead of denying the action.
+	AllowNoOp bool
+	// Reason is recorded as the server's termination reason when the action
+	// terminates it.
+	Reason string
+	// ConfirmationToken confirms the termination of a production server.
+	ConfirmationToken string
+	// Confirmed skips the confirmation for callers acting on an earlier,
+	// explicit request, such as the expiry reaper.
+	Confirmed bool
+	// Origin is recorded on the log entries the action writes.
+	Origin logger.Origin
+}
+
+type actionResult struct {
+	ServerID  string `json:"serverId"`
+	Name      string `json:"name,omitempty"`
+	Outcome   string `json:"outcome"`
+	OldStatus string `json:"oldStatus"`
+	NewStatus string `json:"newStatus,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Code      string `json:"code,omitempty"`
+	// Quota is the limit a denied action would have exceeded.
+	Quota *service.QuotaViolation `json:"quota,omitempty"`
+}
+
+var terminationConfirmations = service.NewConfirmationStore()
+
+// applyServerAction runs an action through the FSM and persists the result,
+// including every side effect of a status change. It is shared by the single
+// and bulk action endpoints so both behave identically.
+func (h *ServerHandler) applyServerAction(server *models.Server, action string, opts actionOptions) actionResult {
+	result := actionResult{
+		ServerID:  server.ID,
+		Name:      server.Name,
+		OldStatus: server.Status,
+	}
+	originalStatus := server.Status
+	originalResizingTo := server.ResizingTo
+
+	if opts.AllowNoOp && service.AlreadyInState(action, originalStatus) {
+		result.Outcome = OutcomeNoOp
+		result.NewStatus = originalStatus
+		result.Reason = fmt.Sprintf("Server is already %s.", originalStatus)
+		if !opts.DryRun {
+			h.events.LogServerEventAs(opts.Origin, server.ID, fmt.Sprintf("ACTION_%s_NO_CHANGE", action), fmt.Sprintf("Action '%s' processed, status remains '%s'.", action, originalStatus), logger.StringPtr(originalStatus), nil)
+		}
+		return result
+	}
+
+	if code, errorMessage := checkTerminationGuards(server, action, opts); code != "" {
+		result.Outcome = OutcomeDenied
+		result.Code = code
+		result.Reason = errorMessage
+		if !opts.DryRun {
+			h.events.LogServerEventAs(opts.Origin, server.ID, "ACTION_DENIED", fmt.Sprintf("%s (%s)", errorMessage, code), logger.StringPtr(originalStatus), nil)
+			log.Printf("Termination of server '%s' denied: %s\n", server.ID, code)
+		}
+		return result
+	}
+
+	newStatus, errorMessage := service.HandleAction(action, originalStatus)
+
+	if errorMessage != "" {
+		result.Outcome = OutcomeDenied
+		result.Reason = errorMessage
+		if !opts.DryRun {
+			h.events.LogServerEventAs(opts.Origin, server.ID, "ACTION_DENIED", errorMessage, logger.StringPtr(originalStatus), nil)
+			log.Printf("Invalid state transition for server '%s': %s (Current: %s, Action: %s)\n",
+				server.ID, errorMessage, originalStatus, action)
+		}
+		return result
+	}
+
+	if newStatus == "" {
+		result.Outcome = OutcomeNoOp
+		result.NewStatus = originalStatus
+		if !opts.DryRun {
+			h.events.LogServerEventAs(opts.Origin, server.ID, fmt.Sprintf("ACTION_%s_NO_CHANGE", action), fmt.Sprintf("Action '%s' processed, status remains '%s'.", action, originalStatus), logger.StringPtr(originalStatus), nil)
+			log.Printf("Action '%s' on server '%s' completed without state change (current status: %s).\n",
+				action, server.ID, originalStatus)
+		}
+		return result
+	}
+
+	result.Outcome = OutcomeChanged
+	result.NewStatus = newStatus
+	if opts.DryRun {
+		return result
+	}
+
+	server.Status = newStatus
+	server.ResizingTo = ""
+	message := fmt.Sprintf("Status changed to '%s'.", newStatus)
+	if newStatus == service.StatusTerminated && opts.Reason != "" {
+		server.TerminationReason = opts.Reason
+		message = fmt.Sprintf("Status changed to '%s' (%s).", newStatus, opts.Reason)
+	}
+	// Only the status columns are written, and only if nothing else changed
+	// the status since it was read.
+	updated, err := h.servers.UpdateStatus(server, originalStatus, "status", "resizing_to", "termination_reason")
+	if err != nil || !updated {
+		server.Status = originalStatus
+		server.ResizingTo = originalResizingTo
+		server.TerminationReason = ""
+		result.NewStatus = ""
+	}
+	if err != nil {
+		log.Printf("Error saving new status for server '%s': %v\n", server.ID, err)
+		result.Outcome = OutcomeFailed
+		result.Reason = err.Error()
+		return result
+	}
+	if !updated {
+		result.Outcome = OutcomeDenied
+		result.Code = service.CodeStatusConflict
+		result.Reason = fmt.Sprintf("Server status changed from '%s' while the action was applied. Try again.", originalStatus)
+		h.events.LogServerEventAs(opts.Origin, server.ID, "ACTION_DENIED", fmt.Sprintf("%s (%s)", result.Reason, result.Code), logger.StringPtr(originalStatus), nil)
+		log.Printf("Action '%s' on server '%s' lost a race: %s\n", action, server.ID, result.Code)
+		return result
+	}
+
+	h.events.LogServerEventAs(opts.Origin, server.ID, "STATUS_CHANGE", message, logger.StringPtr(originalStatus), logger.StringPtr(newStatus))
+	h.refreshTargetHealth(server.ID)
+	h.recordBillingTransition(server)
+	if newStatus == service.StatusTerminated {
+		h.deregisterServerDNS(server.ID)
+	}
+
+	log.Printf("Server '%s' status changed from '%s' to '%s' via action '%s'.\n",
+		server.ID, originalStatus, newStatus, action)
+	return result
+}
+
+// BulkServerAction applies one action to many servers, chosen by ID/name or by
+// selector, and reports the outcome for each of them. Individual failures never
+// fail the request as a whole.
+func (h *ServerHandler) BulkServerAction(c *gin.Context) {
+	var req struct {
+		Action   string   `json:"action"`
+		IDs      []string `json:"ids"`
+		Selector string   `json:"selector"`
+		Region   string   `json:"region"`
+		DryRun   bool     `json:"dryRun"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !service.IsAction(req.Action) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Action '%s' is not supported.", req.Action),
+		})
+		return
+	}
+	if !h.authorize(c, service.ActionPermission(req.Action), "") {
+		return
+	}
+	if len(req.IDs) == 0 && req.Selector == "" && req.Region == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Specify ids, a selector or a region.",
+		})
+		return
+	}
+	if len(req.IDs) > 0 && (req.Selector != "" || req.Region != "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "ids cannot be combined with selector or region.",
+		})
+		return
+	}
+
+	var results []actionResult
+	var servers []*models.Server
+	if len(req.IDs) > 0 {
+		results, servers = h.resolveBulkIDs(req.IDs, projectOf(c))
+	} else {
+		selector, err := service.ParseSelector(req.Selector)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid selector",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		// Terminated servers are gone as far as a selector is concerned.
+		matched, err := h.servers.FindBySelector(selector, req.Region, projectOf(c))
+		if err != nil {
+			log.Printf("Error fetching servers for bulk action: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Error fetching server details",
+				"error":   err.Error(),
+			})
+			return
+		}
+		for i := range matched {
+			servers = append(servers, &matched[i])
+		}
+		results = make([]actionResult, len(servers))
+	}
+
+	if len(servers) > maxBulkActionTargets {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("A bulk action can target at most %d servers; %d matched.", maxBulkActionTargets, len(servers)),
+		})
+		return
+	}
+
+	opts := actionOptions{DryRun: req.DryRun, AllowNoOp: true, Origin: originOf(c)}
+	sem := make(chan struct{}, bulkActionConcurrency())
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		if server == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, server *models.Server) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = h.applyServerAction(server, req.Action, opts)
+		}(i, server)
+	}
+	wg.Wait()
+
+	summary := map[string]int{}
+	for _, result := range results {
+		summary[result.Outcome]++
+	}
+
+	log.Printf("Bulk action '%s' on %d servers (dry run: %v): %v\n", req.Action, len(results), req.DryRun, summary)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bulk action processed",
+		"action":  req.Action,
+		"dryRun":  req.DryRun,
+		"summary": summary,
+		"results": results,
+	})
+}
+
+// resolveBulkIDs looks up each requested server, leaving a nil placeholder and
+// a not_found or failed result for the ones that cannot be resolved. Repeated
+// references to the same server are only acted on once.
+func (h *ServerHandler) resolveBulkIDs(ids []string, projectId string) ([]actionResult, []*models.Server) {
+	results := make([]actionResult, 0, len(ids))
+	servers := make([]*models.Server, 0, len(ids))
+	seen := make(map[string]bool)
+
+	for _, id := range ids {
+		server, err := h.lookupServer(id, "", projectId)
+		if err != nil {
+			result := actionResult{ServerID: id, Outcome: OutcomeFailed, Reason: err.Error()}
+			if err == gorm.ErrRecordNotFound {
+				result.Outcome = OutcomeMissing
+				result.Reason = fmt.Sprintf("Server with ID '%s' not found.", id)
+			} else if err == errAmbiguousServerName {
+				result.Reason = fmt.Sprintf("More than one server is named '%s'. Use the ID.", id)
+			}
+			results = append(results, result)
+			servers = append(servers, nil)
+			continue
+		}
+		if seen[server.ID] {
+			continue
+		}
+		seen[server.ID] = true
+		results = append(results, actionResult{})
+		servers = append(servers, server)
+	}
+	return results, servers
+}
+
+// bulkActionConcurrency caps how many servers a bulk action works on at once.
+func bulkActionConcurrency() int {
+	if n, err := strconv.Atoi(os.Getenv("BULK_ACTION_CONCURRENCY")); err == nil && n > 0 {
+		return n
+	}
+	return defaultBulkActionConcurrency
+}
+
+// checkTerminationGuards stops a terminate action on a server with termination
+// protection, or on a production server without a valid confirmation token.
+// It returns the error code and message of the guard that applied.
+func checkTerminationGuards(server *models.Server, action string, opts actionOptions) (string, string) {
+	if action != service.ActionTerminate || server.Status == service.StatusTerminated {
+		return "", ""
+	}
+	if server.TerminationProtection {
+		return service.CodeTerminationProtected, "Termination protection is enabled for this server. Disable it before terminating."
+	}
+	if opts.Confirmed || !productionSelector().Matches(server.Tags) {
+		return "", ""
+	}
+	if opts.ConfirmationToken == "" {
+		return service.CodeConfirmationRequired, "Terminating a production server must be confirmed with a confirmation token."
+	}
+	// A dry run checks the token but must not use it up.
+	confirm := terminationConfirmations.Consume
+	if opts.DryRun {
+		confirm = terminationConfirmations.Valid
+	}
+	if confirm(server.ID, opts.ConfirmationToken) {
+		return "", ""
+	}
+	return service.CodeConfirmationRequired, "The confirmation token is invalid or has expired."
+}
+
+// requireConfirmation answers 409 with a new confirmation token for the
+// termination of the server, to be sent with the repeated request.
+func requireConfirmation(c *gin.Context, serverID, reason string) {
+	token, expiresAt, err := terminationConfirmations.Issue(serverID)
+	if err != nil {
+		log.Printf("Error issuing confirmation token for server '%s': %v\n", serverID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error issuing confirmation token",
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"message":           reason + " Repeat the request with the confirmationToken below.",
+		"code":              service.CodeConfirmationRequired,
+		"confirmationToken": token,
+		"expiresAt":         expiresAt,
+	})
+}
+
+// productionSelector reads PRODUCTION_SELECTOR, falling back to the default
+// when it is unset or invalid.
+func productionSelector() service.Selector {
+	if expr := os.Getenv("PRODUCTION_SELECTOR"); expr != "" {
+		if selector, err := service.ParseSelector(expr); err == nil {
+			return selector
+		}
+		log.Printf("WARNING: Invalid PRODUCTION_SELECTOR %q; using %q\n", expr, service.DefaultProductionSelector)
+	}
+	selector, _ := service.ParseSelector(service.DefaultProductionSelector)
+	return selector
+}
+
+// applyResize changes a server's type. A stopped server can be resized to any
+// type; a running one only to a larger type, and only when online is set. The
+// server passes through the resizing status, and each status change splits the
+// billing period so the new rate applies from the moment of the change.
+func (h *ServerHandler) applyResize(server *models.Server, toType string, online bool, origin logger.Origin) actionResult {
+	result := actionResult{
+		ServerID:  server.ID,
+		Name:      server.Name,
+		OldStatus: server.Status,
+	}
+	original := *server
+	originalStatus := server.Status
+	fromType := server.Type
+
+	deny := func(errorMessage string) actionResult {
+		result.Outcome = OutcomeDenied
+		result.Reason = errorMessage
+		if result.Code != "" {
+			errorMessage = fmt.Sprintf("%s (%s)", errorMessage, result.Code)
+		}
+		h.events.LogServerEventAs(origin, server.ID, "ACTION_DENIED", errorMessage, logger.StringPtr(originalStatus), nil)
+		return result
+	}
+
+	if errorMessage := service.ValidateResize(fromType, toType); errorMessage != "" {
+		return deny(errorMessage)
+	}
+
+	resumeStatus := service.StatusStopped
+	if originalStatus == service.StatusRunning && online {
+		if !service.CanResizeOnline(fromType, toType) {
+			return deny(fmt.Sprintf("Cannot resize online from '%s' to '%s'. Only larger types can be applied online; stop the server first.", fromType, toType))
+		}
+		resumeStatus = service.StatusRunning
+	} else if _, errorMessage := service.HandleAction(service.ActionResize, originalStatus); errorMessage != "" {
+		return deny(errorMessage)
+	}
+
+	// The quota check and the switch to resizing share a transaction, and
+	// ResizingTo holds the new type against the quota until the resize is done.
+	var violation *service.QuotaViolation
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var err error
+		violation, err = enforceQuota(tx, h.servers.WithTx(tx), server.ProjectID, now, func(usage *service.QuotaUsage) {
+			usage.AddServer(fromType, server.Region, -1)
+			usage.AddServer(toType, server.Region, 1)
+			usage.MonthlySpend += (service.BilledRate(resumeStatus, billingRate[toType]) -
+				service.BilledRate(originalStatus, server.BillingRate)) * remainingMonthHours(now)
+		})
+		if err != nil {
+			return err
+		}
+		if violation != nil {
+			return errQuotaExceeded
+		}
+		server.Status = service.StatusResizing
+		server.ResizingTo = toType
+		return h.servers.WithTx(tx).Save(server)
+	})
+	if err == errQuotaExceeded {
+		result.Code = service.CodeQuotaExceeded
+		result.Quota = violation
+		return deny(violation.Message())
+	}
+	if err != nil {
+		log.Printf("Error saving resizing status for server '%s': %v\n", server.ID, err)
+		server.Status = originalStatus
+		server.ResizingTo = ""
+		result.Outcome = OutcomeFailed
+		result.Reason = err.Error()
+		return result
+	}
+	h.events.LogServerEventAs(origin, server.ID, "STATUS_CHANGE", fmt.Sprintf("Status changed to '%s'.", service.StatusResizing),
+		logger.StringPtr(originalStatus), logger.StringPtr(service.StatusResizing))
+	h.recordBillingTransition(server)
+
+	server.Type = toType
+	server.ResizingTo = ""
+	server.BillingRate = billingRate[toType]
+	server.Status = resumeStatus
+	if err := h.servers.Save(server); err != nil {
+		log.Printf("Error completing resize of server '%s': %v\n", server.ID, err)
+		result.Outcome = OutcomeFailed
+		result.Reason = err.Error()
+		return h.abandonResize(server, &original, origin, result)
+	}
+
+	h.events.LogServerChange(origin, server.ID, "SERVER_RESIZED", fmt.Sprintf("Type changed from '%s' to '%s'.", fromType, toType), nil, nil,
+		map[string]string{service.FieldType: toType})
+	h.events.LogServerEventAs(origin, server.ID, "STATUS_CHANGE", fmt.Sprintf("Status changed to '%s'.", resumeStatus),
+		logger.StringPtr(service.StatusResizing), logger.StringPtr(resumeStatus))
+	h.recordBillingTransition(server)
+	h.refreshTargetHealth(server.ID)
+
+	log.Printf("Server '%s' resized from '%s' to '%s'.\n", server.ID, fromType, toType)
+	result.Outcome = OutcomeChanged
+	result.NewStatus = resumeStatus
+	return result
+}
+
+// abandonResize puts a server whose resize could not be completed back the
+// way it was. If that fails too the server stays in resizing, from which
+// stopping it recovers.
+func (h *ServerHandler) abandonResize(server, original *models.Server, origin logger.Origin, result actionResult) actionResult {
+	originalStatus := original.Status
+	resizingTo := server.Type
+	server.Type = original.Type
+	server.ResizingTo = ""
+	server.BillingRate = original.BillingRate
+	server.Status = originalStatus
+	if err := h.servers.Update(server, "type", "resizing_to", "billing_rate", "status"); err != nil {
+		log.Printf("Error rolling back resize of server '%s': %v\n", server.ID, err)
+		server.Status = service.StatusResizing
+		server.ResizingTo = resizingTo
+		result.NewStatus = service.StatusResizing
+		return result
+	}
+
+	h.events.LogServerEventAs(origin, server.ID, "STATUS_CHANGE", fmt.Sprintf("Status changed to '%s'; the resize was abandoned.", originalStatus),
+		logger.StringPtr(service.StatusResizing), logger.StringPtr(originalStatus))
+	h.recordBillingTransition(server)
+	result.NewStatus = originalStatus
+	return result
+}
+
+// applyRebuild reinstalls a server from an image, keeping its ID, addresses,
+// keys and tags. An empty image reinstalls the current one.
+func (h *ServerHandler) applyRebuild(server *models.Server, image string, origin logger.Origin) actionResult {
+	result := actionResult{
+		ServerID:  server.ID,
+		Name:      server.Name,
+		OldStatus: server.Status,
+	}
+	originalStatus := server.Status
+	oldImage := server.Image
+
+	if image == "" {
+		image = oldImage
+	}
+	errorMessage := service.ValidateImage(&image)
+	if errorMessage == "" {
+		_, errorMessage = service.HandleAction(service.ActionRebuild, originalStatus)
+	}
+	if errorMessage != "" {
+		result.Outcome = OutcomeDenied
+		result.Reason = errorMessage
+		h.events.LogServerEventAs(origin, server.ID, "ACTION_DENIED", errorMessage, logger.StringPtr(originalStatus), nil)
+		return result
+	}
+
+	server.Image = image
+	if err := h.servers.Update(server, "image"); err != nil {
+		log.Printf("Error saving image for server '%s': %v\n", server.ID, err)
+		server.Image = oldImage
+		result.Outcome = OutcomeFailed
+		result.Reason = err.Error()
+		return result
+	}
+
+	message := fmt.Sprintf("Rebuilt from image '%s'.", image)
+	if oldImage != "" && oldImage != image {
+		message = fmt.Sprintf("Rebuilt from image '%s' (was '%s').", image, oldImage)
+	}
+	h.events.LogServerChange(origin, server.ID, "SERVER_REBUILT", message, logger.StringPtr(originalStatus), logger.StringPtr(originalStatus),
+		map[string]string{service.FieldImage: image})
+
+	log.Printf("Server '%s' rebuilt from image '%s'.\n", server.ID, image)
+	result.Outcome = OutcomeChanged
+	result.NewStatus = originalStatus
+	return result
+}
@@ -0,0 +1,458 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/repository"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+func TestBulkServerAction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/servers/actions", h.BulkServerAction)
+
+	ids := make(map[string]string)
+	for _, body := range []string{
+		`{"region": "India", "type": "basic", "name": "web-1", "tags": {"env": "prod"}}`,
+		`{"region": "India", "type": "basic", "name": "web-2", "tags": {"env": "prod"}}`,
+		`{"region": "India", "type": "basic", "name": "batch", "tags": {"env": "dev"}}`,
+		`{"region": "USA", "type": "basic", "name": "web-3", "tags": {"env": "prod"}}`,
+	} {
+		id := createTestServer(t, router, body)
+		var server models.Server
+		testDB.First(&server, "id = ?", id)
+		ids[server.Name] = id
+	}
+	// web-2 starts out stopped so that stopping it is a no-op.
+	testDB.Model(&models.Server{}).Where("id = ?", ids["web-2"]).Update("status", "stopped")
+
+	type response struct {
+		Summary map[string]int `json:"summary"`
+		Results []actionResult `json:"results"`
+	}
+	bulk := func(body string) response {
+		rec := sendJSON(t, router, http.MethodPost, "/api/servers/actions", body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var r response
+		json.Unmarshal(rec.Body.Bytes(), &r)
+		return r
+	}
+	status := func(name string) string {
+		var server models.Server
+		testDB.First(&server, "id = ?", ids[name])
+		return server.Status
+	}
+
+	t.Run("Dry run changes nothing", func(t *testing.T) {
+		r := bulk(`{"action": "stop", "selector": "env=prod", "region": "India", "dryRun": true}`)
+		if r.Summary[OutcomeChanged] != 1 || r.Summary[OutcomeNoOp] != 1 || len(r.Results) != 2 {
+			t.Errorf("Unexpected dry run summary %v", r.Summary)
+		}
+		if status("web-1") != "running" {
+			t.Errorf("Dry run stopped web-1")
+		}
+	})
+
+	t.Run("Stop by selector and region", func(t *testing.T) {
+		r := bulk(`{"action": "stop", "selector": "env=prod", "region": "India"}`)
+		if r.Summary[OutcomeChanged] != 1 || r.Summary[OutcomeNoOp] != 1 {
+			t.Errorf("Unexpected summary %v", r.Summary)
+		}
+		if status("web-1") != "stopped" || status("web-3") != "running" || status("batch") != "running" {
+			t.Errorf("Unexpected statuses web-1=%s web-3=%s batch=%s", status("web-1"), status("web-3"), status("batch"))
+		}
+	})
+
+	t.Run("Per-item results by ID", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"action": "reboot",
+			"ids":    []string{ids["web-1"], "batch", "missing", ids["web-1"]},
+		})
+		r := bulk(string(body))
+		if len(r.Results) != 3 {
+			t.Fatalf("Expected 3 results, got %d", len(r.Results))
+		}
+		expected := []string{OutcomeDenied, OutcomeChanged, OutcomeMissing}
+		for i, outcome := range expected {
+			if r.Results[i].Outcome != outcome {
+				t.Errorf("Result %d: expected %s, got %s (%s)", i, outcome, r.Results[i].Outcome, r.Results[i].Reason)
+			}
+		}
+		if r.Results[0].Reason == "" {
+			t.Errorf("Expected a reason for the denied reboot")
+		}
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		for _, body := range []string{
+			`{"action": "explode", "selector": "env=prod"}`,
+			`{"action": "stop"}`,
+			`{"action": "stop", "ids": ["web-1"], "selector": "env=prod"}`,
+			`{"action": "stop", "selector": "env in (prod"}`,
+		} {
+			if rec := sendJSON(t, router, http.MethodPost, "/api/servers/actions", body); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, rec.Code)
+			}
+		}
+	})
+}
+
+func TestTerminationGuards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.PATCH("/api/servers/:id", h.UpdateServer)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+
+	type denial struct {
+		Code              string `json:"code"`
+		ConfirmationToken string `json:"confirmationToken"`
+	}
+	terminate := func(name, body string, expected int) denial {
+		rec := sendJSON(t, router, http.MethodPost, "/api/servers/"+name+"/action", body)
+		if rec.Code != expected {
+			t.Fatalf("Expected status %d terminating %s, got %d: %s", expected, name, rec.Code, rec.Body.String())
+		}
+		var d denial
+		json.Unmarshal(rec.Body.Bytes(), &d)
+		return d
+	}
+	denials := func() int64 {
+		var count int64
+		testDB.Model(&models.ServerLog{}).Where("event_type = ?", "ACTION_DENIED").Count(&count)
+		return count
+	}
+
+	for _, body := range []string{
+		`{"region": "India", "type": "basic", "name": "locked", "terminationProtection": true}`,
+		`{"region": "India", "type": "basic", "name": "db", "tags": {"env": "prod"}}`,
+		`{"region": "India", "type": "basic", "name": "other", "tags": {"env": "prod"}}`,
+	} {
+		createTestServer(t, router, body)
+	}
+
+	t.Run("Termination protection", func(t *testing.T) {
+		if d := terminate("locked", `{"action": "terminate"}`, http.StatusConflict); d.Code != "TERMINATION_PROTECTED" {
+			t.Errorf("Expected TERMINATION_PROTECTED, got %q", d.Code)
+		}
+		if denials() != 1 {
+			t.Errorf("Expected the attempt to be logged as ACTION_DENIED")
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/locked/action", `{"action": "stop"}`); rec.Code != http.StatusOK {
+			t.Errorf("Expected other actions to be allowed, got %d", rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPatch, "/api/servers/locked", `{"terminationProtection": false}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		terminate("locked", `{"action": "terminate"}`, http.StatusOK)
+	})
+
+	t.Run("Production confirmation", func(t *testing.T) {
+		d := terminate("db", `{"action": "terminate"}`, http.StatusConflict)
+		if d.Code != "CONFIRMATION_REQUIRED" || d.ConfirmationToken == "" {
+			t.Fatalf("Expected a confirmation token, got %+v", d)
+		}
+		other := terminate("other", `{"action": "terminate"}`, http.StatusConflict)
+		if rd := terminate("db", `{"action": "terminate", "confirmationToken": "`+other.ConfirmationToken+`"}`, http.StatusConflict); rd.Code != "CONFIRMATION_REQUIRED" {
+			t.Errorf("Expected another server's token to be rejected, got %+v", rd)
+		}
+		var server models.Server
+		testDB.First(&server, "name = ?", "db")
+		dryRun := actionOptions{DryRun: true, ConfirmationToken: "invalid"}
+		if code, _ := checkTerminationGuards(&server, service.ActionTerminate, dryRun); code != service.CodeConfirmationRequired {
+			t.Errorf("Expected a dry run to check the token, got %q", code)
+		}
+		dryRun.ConfirmationToken = d.ConfirmationToken
+		if code, _ := checkTerminationGuards(&server, service.ActionTerminate, dryRun); code != "" {
+			t.Errorf("Expected a dry run to accept the token, got %q", code)
+		}
+		terminate("db", `{"action": "terminate", "confirmationToken": "`+d.ConfirmationToken+`"}`, http.StatusOK)
+	})
+}
+
+func TestResize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+
+	load := func() models.Server {
+		var server models.Server
+		testDB.First(&server, "name = ?", "app")
+		return server
+	}
+
+	createTestServer(t, router, `{"region": "India", "type": "plus", "name": "app"}`)
+
+	t.Run("Denied", func(t *testing.T) {
+		for _, body := range []string{
+			`{"action": "resize", "type": "prime"}`,
+			`{"action": "resize", "type": "basic", "online": true}`,
+			`{"action": "resize", "type": "huge", "online": true}`,
+			`{"action": "resize", "type": "plus", "online": true}`,
+			`{"action": "resize", "online": true}`,
+		} {
+			if rec := sendJSON(t, router, http.MethodPost, "/api/servers/app/action", body); rec.Code != http.StatusConflict {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusConflict, body, rec.Code)
+			}
+		}
+		if server := load(); server.Type != "plus" || server.Status != "running" {
+			t.Errorf("Denied resize changed the server: %s/%s", server.Type, server.Status)
+		}
+	})
+
+	t.Run("Online to a larger type", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/app/action", `{"action": "resize", "type": "prime", "online": true}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if server := load(); server.Type != "prime" || server.Status != "running" || server.BillingRate != billingRate["prime"] {
+			t.Errorf("Unexpected server after resize: %+v", server)
+		}
+	})
+
+	t.Run("Stopped to a smaller type", func(t *testing.T) {
+		sendJSON(t, router, http.MethodPost, "/api/servers/app/action", `{"action": "stop"}`)
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/app/action", `{"action": "resize", "type": "basic"}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		server := load()
+		if server.Type != "basic" || server.Status != "stopped" || server.BillingRate != billingRate["basic"] {
+			t.Errorf("Unexpected server after resize: %+v", server)
+		}
+
+		var resized int64
+		testDB.Model(&models.ServerLog{}).Where("server_id = ? AND event_type = ?", server.ID, "SERVER_RESIZED").Count(&resized)
+		if resized != 2 {
+			t.Errorf("Expected 2 SERVER_RESIZED events, got %d", resized)
+		}
+
+		var periods []models.BillingPeriod
+		testDB.Where("server_id = ?", server.ID).Order("started_at").Find(&periods)
+		types := []string{}
+		for _, period := range periods {
+			types = append(types, period.Type+"/"+period.Status)
+		}
+		expected := []string{"plus/running", "plus/resizing", "prime/running", "prime/stopped", "prime/resizing", "basic/stopped"}
+		if len(types) != len(expected) {
+			t.Fatalf("Expected billing periods %v, got %v", expected, types)
+		}
+		for i := range expected {
+			if types[i] != expected[i] {
+				t.Errorf("Expected billing periods %v, got %v", expected, types)
+				break
+			}
+		}
+	})
+}
+
+// TestActionStatusConflict checks that an action applied to a server whose
+// status changed since it was read writes nothing.
+func TestActionStatusConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	id := createTestServer(t, router, `{"region": "India", "type": "basic", "name": "app"}`)
+
+	stale, err := h.servers.Get(id, "")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	// Another request stops the server after this one read it.
+	testDB.Model(&models.Server{}).Where("id = ?", id).Update("status", "stopped")
+
+	result := h.applyServerAction(stale, service.ActionTerminate, actionOptions{Confirmed: true, Reason: service.TerminationReasonScheduled})
+	if result.Outcome != OutcomeDenied || result.Code != service.CodeStatusConflict {
+		t.Errorf("Expected a %s denial, got %+v", service.CodeStatusConflict, result)
+	}
+	if stale.Status != "running" || stale.TerminationReason != "" {
+		t.Errorf("Denied action left the server as %s/%s", stale.Status, stale.TerminationReason)
+	}
+	var server models.Server
+	testDB.First(&server, "id = ?", id)
+	if server.Status != "stopped" || server.TerminationReason != "" {
+		t.Errorf("Denied action wrote %s/%s", server.Status, server.TerminationReason)
+	}
+}
+
+// TestResizeFailure checks that a resize which cannot be completed leaves the
+// server as it was, or in resizing with stop as the way out.
+func TestResizeFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+	servers := repository.NewFaultyServerRepository(h.servers)
+	h = NewServerHandler(testDB, servers, h.events)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+
+	load := func() models.Server {
+		var server models.Server
+		testDB.First(&server, "name = ?", "app")
+		return server
+	}
+	resize := func() {
+		t.Helper()
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/app/action", `{"action": "resize", "type": "basic"}`); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusInternalServerError, rec.Code, rec.Body.String())
+		}
+	}
+
+	createTestServer(t, router, `{"region": "India", "type": "plus", "name": "app"}`)
+	sendJSON(t, router, http.MethodPost, "/api/servers/app/action", `{"action": "stop"}`)
+	failure := errors.New("connection reset")
+
+	t.Run("Rolled back", func(t *testing.T) {
+		// The switch to resizing is saved; completing the resize is not.
+		servers.FailAfter("Save", 1, failure)
+		defer servers.Fail("Save", nil)
+		resize()
+		if server := load(); server.Status != "stopped" || server.Type != "plus" || server.ResizingTo != "" || server.BillingRate != billingRate["plus"] {
+			t.Errorf("Expected the server back as it was, got %+v", server)
+		}
+	})
+
+	t.Run("Stuck and recovered", func(t *testing.T) {
+		servers.FailAfter("Save", 1, failure)
+		servers.Fail("Update", failure)
+		resize()
+		if server := load(); server.Status != "resizing" || server.ResizingTo != "basic" {
+			t.Fatalf("Expected the server left in resizing, got %+v", server)
+		}
+		servers.Fail("Save", nil)
+		servers.Fail("Update", nil)
+
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/app/action", `{"action": "start"}`); rec.Code != http.StatusConflict {
+			t.Errorf("Expected start to be denied while resizing, got %d", rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/app/action", `{"action": "stop"}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected stop to recover the server, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if server := load(); server.Status != "stopped" || server.Type != "plus" || server.ResizingTo != "" {
+			t.Errorf("Unexpected server after recovery: %+v", server)
+		}
+	})
+}
+
+func TestHibernation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+
+	createTestServer(t, router, `{"region": "India", "type": "prime", "name": "vm"}`)
+
+	steps := []struct {
+		action string
+		code   int
+	}{
+		{"hibernate", http.StatusOK},
+		{"start", http.StatusConflict},
+		{"resume", http.StatusOK},
+		{"resume", http.StatusConflict},
+		{"hibernate", http.StatusOK},
+	}
+	for _, step := range steps {
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/vm/action", `{"action": "`+step.action+`"}`); rec.Code != step.code {
+			t.Fatalf("Expected status %d for %s, got %d: %s", step.code, step.action, rec.Code, rec.Body.String())
+		}
+	}
+
+	var period models.BillingPeriod
+	testDB.Where("ended_at IS NULL").First(&period)
+	if period.Status != "hibernated" || period.Rate != billingRate["prime"]*0.1 {
+		t.Errorf("Expected the open period to bill the storage rate, got %+v", period)
+	}
+}
+
+func TestRebuildAndRescue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.POST("/api/servers/:id/action", h.CompleteAction)
+
+	load := func() models.Server {
+		var server models.Server
+		testDB.First(&server, "name = ?", "box")
+		return server
+	}
+
+	createTestServer(t, router, `{"region": "India", "type": "basic", "name": "box"}`)
+	before := load()
+	if before.Image != "ubuntu-24.04" {
+		t.Errorf("Expected the default image, got %q", before.Image)
+	}
+
+	t.Run("Rebuild", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/box/action", `{"action": "rebuild", "image": "Not An Image"}`); rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for an invalid image, got %d", http.StatusConflict, rec.Code)
+		}
+		if rec := sendJSON(t, router, http.MethodPost, "/api/servers/box/action", `{"action": "rebuild", "image": "debian-12"}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		after := load()
+		if after.Image != "debian-12" || after.Status != "running" || after.PrivateIP != before.PrivateIP || after.PublicIP != before.PublicIP {
+			t.Errorf("Rebuild changed more than the image: %+v", after)
+		}
+		var entry models.ServerLog
+		testDB.Where("server_id = ? AND event_type = ?", after.ID, "SERVER_REBUILT").First(&entry)
+		if entry.Message != "Rebuilt from image 'debian-12' (was 'ubuntu-24.04')." {
+			t.Errorf("Unexpected rebuild log %q", entry.Message)
+		}
+	})
+
+	t.Run("Rescue", func(t *testing.T) {
+		steps := []struct {
+			body string
+			code int
+		}{
+			{`{"action": "rescue"}`, http.StatusOK},
+			{`{"action": "stop"}`, http.StatusConflict},
+			{`{"action": "rebuild"}`, http.StatusConflict},
+			{`{"action": "unrescue"}`, http.StatusOK},
+			{`{"action": "unrescue"}`, http.StatusConflict},
+		}
+		for _, step := range steps {
+			if rec := sendJSON(t, router, http.MethodPost, "/api/servers/box/action", step.body); rec.Code != step.code {
+				t.Fatalf("Expected status %d for %s, got %d: %s", step.code, step.body, rec.Code, rec.Body.String())
+			}
+		}
+		if status := load().Status; status != "running" {
+			t.Errorf("Expected running after unrescue, got %s", status)
+		}
+	})
+}
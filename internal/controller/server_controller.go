@@ -1,29 +1,78 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gitshubham45/virtualServer/internal/db"
 	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
 	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/repository"
 	"github.com/gitshubham45/virtualServer/internal/service"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	errAmbiguousServerName = errors.New("server name is ambiguous")
+	errNameConflict        = errors.New("server name or hostname already in use")
+)
+
+// actorOf identifies the caller of a request in the lifecycle log.
+func actorOf(c *gin.Context) string {
+	return middleware.PrincipalFrom(c).Actor()
+}
+
+// originOf is the origin recorded on the audit log entries a request writes.
+func originOf(c *gin.Context) logger.Origin {
+	return logger.Origin{
+		Actor:      actorOf(c),
+		SourceIP:   c.ClientIP(),
+		RequestID:  c.GetString(middleware.RequestIDContextKey),
+		BodyDigest: c.GetString(middleware.BodyDigestContextKey),
+	}
+}
+
+// projectOf is the project the caller is confined to, or "" for a global
+// caller that sees every project.
+func projectOf(c *gin.Context) string {
+	return middleware.PrincipalFrom(c).Project()
+}
+
+// scopeToProject limits a query to the project's rows, when there is one.
+func scopeToProject(query *gorm.DB, projectId string) *gorm.DB {
+	if projectId == "" {
+		return query
+	}
+	return query.Where("project_id = ?", projectId)
+}
+
 var billingRate = map[string]float64{
 	"basic": 5.0,
 	"plus":  8.0,
 	"prime": 12.0,
 }
 
-func CreateServer(c *gin.Context) {
+func (h *ServerHandler) CreateServer(c *gin.Context) {
 	var req struct {
-		Region string `json:"region"`
-		Type   string `json:"type"`
+		Region                string            `json:"region"`
+		Type                  string            `json:"type"`
+		KeyNames              []string          `json:"keyNames"`
+		UserData              string            `json:"userData"`
+		Hostname              string            `json:"hostname"`
+		Name                  string            `json:"name"`
+		Description           string            `json:"description"`
+		Tags                  map[string]string `json:"tags"`
+		ExpiresAt             *time.Time        `json:"expiresAt"`
+		TTL                   string            `json:"ttl"`
+		TerminationProtection bool              `json:"terminationProtection"`
+		Image                 string            `json:"image"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -32,75 +81,238 @@ func CreateServer(c *gin.Context) {
 		return
 	}
 
+	if !h.authorize(c, service.PermissionServersCreate, "") {
+		return
+	}
+	// An expiry terminates the server later, so it needs the same permission.
+	if (req.ExpiresAt != nil || req.TTL != "") && !h.authorize(c, service.ActionPermission(service.ActionTerminate), "") {
+		return
+	}
+
+	if errorMessage := validateServerNaming(&req.Name, &req.Hostname, req.Description); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	if errorMessage := service.ValidateTags(req.Tags); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	if req.UserData != "" {
+		if _, errorMessage := service.DecodeUserData(req.UserData); errorMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+			return
+		}
+	}
+
+	if errorMessage := service.ValidateImage(&req.Image); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	expiresAt, errorMessage := service.ResolveExpiry(req.ExpiresAt, req.TTL, time.Now())
+	if errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	keyPairs, errorMessage, err := h.resolveKeyNames(req.KeyNames, projectOf(c))
+	if err != nil {
+		log.Printf("Error resolving key pairs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error resolving key pairs"})
+		return
+	}
+	if errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	projectId := projectOf(c)
+	if projectId != "" {
+		if _, err := h.lookupProject(projectId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Project '%s' does not exist.", projectId),
+			})
+			return
+		}
+	}
+
+	metadataToken, metadataTokenHash, err := service.NewSecretToken()
+	if err != nil {
+		log.Printf("Error generating metadata token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error creeating server"})
+		return
+	}
+
 	newUUID := uuid.New().String()
 
 	var newServer = &models.Server{
-		ID:          newUUID,
-		BillingRate: float64(billingRate[req.Type]),
-		Status:      "running",
-		Region:      req.Region,
-		Type:        req.Type,
+		ID:                    newUUID,
+		ProjectID:             projectId,
+		BillingRate:           float64(billingRate[req.Type]),
+		Status:                "running",
+		Region:                req.Region,
+		Type:                  req.Type,
+		Image:                 req.Image,
+		Name:                  req.Name,
+		Hostname:              req.Hostname,
+		Description:           req.Description,
+		Tags:                  req.Tags,
+		UserData:              req.UserData,
+		MetadataTokenHash:     metadataTokenHash,
+		ExpiresAt:             expiresAt,
+		TerminationProtection: req.TerminationProtection,
 	}
 
-	result := db.DB.Create(&newServer)
-	if result == nil {
-		log.Println("Error creating server")
+	var conflict string
+	var violation *service.QuotaViolation
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var err error
+		servers := h.servers.WithTx(tx)
+		violation, err = enforceQuota(tx, servers, newServer.ProjectID, now, func(usage *service.QuotaUsage) {
+			usage.AddServer(newServer.Type, newServer.Region, 1)
+			usage.MonthlySpend += service.BilledRate(newServer.Status, newServer.BillingRate) * remainingMonthHours(now)
+		})
+		if err != nil {
+			return err
+		}
+		if violation != nil {
+			return errQuotaExceeded
+		}
+		if err := servers.AllocateAddresses(newServer); err != nil {
+			return err
+		}
+		if err := servers.Create(newServer); err != nil {
+			if conflict = serverNameConflict(err, newServer); conflict != "" {
+				return errNameConflict
+			}
+			return err
+		}
+		for _, keyPair := range keyPairs {
+			key := models.ServerKey{
+				ID:          uuid.New().String(),
+				ServerID:    newServer.ID,
+				KeyName:     keyPair.Name,
+				Type:        keyPair.Type,
+				PublicKey:   keyPair.PublicKey,
+				Fingerprint: keyPair.Fingerprint,
+			}
+			if err := servers.AddKey(&key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == errNameConflict {
+		c.JSON(http.StatusConflict, gin.H{"message": conflict})
+		return
+	}
+	if err == errQuotaExceeded {
+		log.Printf("Server creation in project '%s' refused: %s\n", newServer.ProjectID, violation.Message())
+		c.JSON(http.StatusForbidden, quotaExceededResponse(violation))
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating server: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error creeating server"})
 		return
 	}
 
-	logger.LogServerEvent(newServer.ID, "SERVER_CREATED", "New server created.", nil, logger.StringPtr(newServer.Status))
+	h.events.LogServerChange(originOf(c), newServer.ID, "SERVER_CREATED", "New server created.", nil, logger.StringPtr(newServer.Status), service.ServerFields(newServer))
+	for _, keyPair := range keyPairs {
+		h.events.LogServerEventAs(originOf(c), newServer.ID, "SSH_KEY_INJECTED", fmt.Sprintf("Key pair '%s' (%s) injected.", keyPair.Name, keyPair.Fingerprint), nil, nil)
+	}
+	if newServer.ExpiresAt != nil {
+		h.events.LogServerEventAs(originOf(c), newServer.ID, "EXPIRY_SET", fmt.Sprintf("Server expires at %s.", newServer.ExpiresAt.Format(time.RFC3339)), nil, nil)
+	}
+	h.registerServerDNS(newServer)
+	h.recordBillingTransition(newServer)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "success",
-		"id":      newServer.ID,
-		"status":  newServer.Status,
+		"message":       "success",
+		"id":            newServer.ID,
+		"status":        newServer.Status,
+		"privateIp":     newServer.PrivateIP,
+		"publicIp":      newServer.PublicIP,
+		"metadataToken": metadataToken,
+		"expiresAt":     newServer.ExpiresAt,
 	})
 }
 
-func GetServersData(c *gin.Context) {
+// ServerHandler serves the API from the database and repositories it is
+// built with, and runs the background jobs against them.
+type ServerHandler struct {
+	db      *gorm.DB
+	servers repository.ServerRepository
+	logs    repository.LogRepository
+	events  *logger.Logger
+}
+
+// NewServerHandler returns a ServerHandler over database, reading servers
+// from servers and writing lifecycle events to events.
+func NewServerHandler(database *gorm.DB, servers repository.ServerRepository, events *logger.Logger) *ServerHandler {
+	return &ServerHandler{db: database, servers: servers, logs: events.Logs(), events: events}
+}
+
+func (h *ServerHandler) GetServer(c *gin.Context) {
 	fmt.Println("inside get server")
 	serverId := c.Param("id")
 	fmt.Println("serverId ", serverId)
+	if !h.authorize(c, service.PermissionServersRead, serverId) {
+		return
+	}
 
-	var server models.Server
-
-	result := db.DB.First(&server, "id = ?", serverId)
-
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			log.Printf("Server with ID %s not found. \n", serverId)
+	server, ok := h.findServer(c, serverId)
+	if !ok {
+		return
+	}
 
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": fmt.Sprintf("Server with ID '%s' not found.", serverId),
-			})
-			return
-		}
+	if c.Query("asOf") != "" {
+		h.getServerAsOf(c, server)
+		return
+	}
 
-		logger.LogServerEvent(serverId, "SERVER_NOT_FOUND", "server not found.", nil, nil)
-		log.Printf("Error fetching server details foe ID '%s' : '%v' \n", serverId, result.Error)
+	keys, err := h.servers.Keys(server.ID)
+	if err != nil {
+		log.Printf("Error fetching keys for server '%s' : '%v' \n", serverId, err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Error fetching server details",
-			"error":   result.Error.Error(),
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	logger.LogServerEvent(serverId, "SERVER_FOUND", "server found.", logger.StringPtr(server.Status), logger.StringPtr(server.Status))
+	keyNames := make([]string, 0, len(keys))
+	publicKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		keyNames = append(keyNames, key.KeyName)
+		publicKeys = append(publicKeys, key.PublicKey)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Server details fetched successfully",
 		"server":  server,
+		"metadata": gin.H{
+			"keyNames":   keyNames,
+			"publicKeys": publicKeys,
+		},
 	})
 }
 
-func CompleteAction(c *gin.Context) {
+func (h *ServerHandler) CompleteAction(c *gin.Context) {
 	fmt.Println("Inside CompleteAction handler")
 
 	serverId := c.Param("id")
 	log.Printf("Attempting action on server with ID: %s\n", serverId)
 
 	var req struct {
-		Action string `json:"action"`
+		Action            string `json:"action"`
+		ConfirmationToken string `json:"confirmationToken"`
+		Type              string `json:"type"`
+		Online            bool   `json:"online"`
+		Image             string `json:"image"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("Error binding request body: %v", err)
@@ -113,55 +325,44 @@ func CompleteAction(c *gin.Context) {
 
 	action := req.Action
 
-	var server models.Server
-	result := db.DB.First(&server, "id = ?", serverId)
-
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			log.Printf("Server with ID '%s' not found.\n", serverId)
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": fmt.Sprintf("Server with ID '%s' not found.", serverId),
-			})
-			return
-		}
-		log.Printf("Error fetching server details for ID '%s': %v\n", serverId, result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Error fetching server details",
-			"error":   result.Error.Error(),
-		})
+	server, ok := h.findServer(c, serverId)
+	if !ok {
 		return
 	}
-
-	originalStatus := server.Status
-	newStatus, errorMessage := service.HandleAction(action, originalStatus)
-
-	if errorMessage != "" {
-		logger.LogServerEvent(server.ID, "ACTION_DENIED", errorMessage, logger.StringPtr(originalStatus), nil)
-		log.Printf("Invalid state transition for server '%s': %s (Current: %s, Action: %s)\n",
-			server.ID, errorMessage, originalStatus, action)
-		c.JSON(http.StatusConflict, gin.H{
-			"message": errorMessage,
-		})
+	if !h.authorize(c, service.ActionPermission(action), server.ID) {
 		return
 	}
 
-	if newStatus != "" {
-		server.Status = newStatus
-		// server.UpTime = serv
-		updateResult := db.DB.Save(&server)
-		if updateResult.Error != nil {
-			log.Printf("Error saving new status for server '%s': %v\n", server.ID, updateResult.Error)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "Failed to update server status",
-				"error":   updateResult.Error.Error(),
-			})
-			return
-		}
-
-		logger.LogServerEvent(server.ID, "STATUS_CHANGE", fmt.Sprintf("Status changed to '%s'.", newStatus), logger.StringPtr(originalStatus), logger.StringPtr(newStatus))
+	var result actionResult
+	switch action {
+	case service.ActionResize:
+		result = h.applyResize(server, req.Type, req.Online, originOf(c))
+	case service.ActionRebuild:
+		result = h.applyRebuild(server, req.Image, originOf(c))
+	default:
+		result = h.applyServerAction(server, action, actionOptions{ConfirmationToken: req.ConfirmationToken, Origin: originOf(c)})
+	}
 
-		log.Printf("Server '%s' status changed from '%s' to '%s' via action '%s'.\n",
-			server.ID, originalStatus, newStatus, action)
+	switch {
+	case result.Code == service.CodeConfirmationRequired:
+		requireConfirmation(c, server.ID, result.Reason)
+	case result.Code == service.CodeQuotaExceeded:
+		c.JSON(http.StatusForbidden, quotaExceededResponse(result.Quota))
+	case result.Code != "":
+		c.JSON(http.StatusConflict, gin.H{
+			"message": result.Reason,
+			"code":    result.Code,
+		})
+	case result.Outcome == OutcomeDenied:
+		c.JSON(http.StatusConflict, gin.H{
+			"message": result.Reason,
+		})
+	case result.Outcome == OutcomeFailed:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update server status",
+			"error":   result.Reason,
+		})
+	case result.Outcome == OutcomeChanged:
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Server action completed successfully",
 			"server": gin.H{
@@ -170,28 +371,127 @@ func CompleteAction(c *gin.Context) {
 				"stoppedAt": server.UpdatedAt,
 			},
 		})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Action '%s' processed for server. Status remains '%s'.", action, result.OldStatus),
+			"server": gin.H{
+				"id":        server.ID,
+				"status":    server.Status,
+				"stoppedAt": server.UpdatedAt,
+			},
+		})
+	}
+}
+
+// UpdateServer changes the human-facing fields of a server. Changing the
+// hostname moves its DNS registration along with it.
+func (h *ServerHandler) UpdateServer(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersUpdate, server.ID) {
+		return
+	}
+
+	var req struct {
+		Name                  *string `json:"name"`
+		Hostname              *string `json:"hostname"`
+		Description           *string `json:"description"`
+		TerminationProtection *bool   `json:"terminationProtection"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
 		return
 	}
 
-	logger.LogServerEvent(server.ID, fmt.Sprintf("ACTION_%s_NO_CHANGE", action), fmt.Sprintf("Action '%s' processed, status remains '%s'.", action, originalStatus), logger.StringPtr(originalStatus), nil)
-	log.Printf("Action '%s' on server '%s' completed without state change (current status: %s).\n",
-		action, server.ID, originalStatus)
+	if server.Status == service.StatusTerminated {
+		c.JSON(http.StatusConflict, gin.H{"message": "Cannot update a terminated server."})
+		return
+	}
+
+	updated := *server
+	if req.Name != nil {
+		updated.Name = *req.Name
+	}
+	if req.Hostname != nil {
+		updated.Hostname = *req.Hostname
+	}
+	if req.Description != nil {
+		updated.Description = *req.Description
+	}
+	if req.TerminationProtection != nil {
+		updated.TerminationProtection = *req.TerminationProtection
+	}
+	// Lifting termination protection is a step towards terminating the server.
+	if server.TerminationProtection && !updated.TerminationProtection &&
+		!h.authorize(c, service.ActionPermission(service.ActionTerminate), server.ID) {
+		return
+	}
+
+	if errorMessage := validateServerNaming(&updated.Name, &updated.Hostname, updated.Description); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	err := h.servers.Update(&updated, "name", "hostname", "description", "termination_protection")
+	if conflict := serverNameConflict(err, &updated); conflict != "" {
+		c.JSON(http.StatusConflict, gin.H{"message": conflict})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update server",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	hostnameChanged := server.Hostname != updated.Hostname
+	h.events.LogServerChange(originOf(c), server.ID, "SERVER_UPDATED",
+		fmt.Sprintf("Server details updated (name '%s', hostname '%s').", updated.Name, updated.Hostname), nil, nil,
+		service.ServerFields(&updated, service.FieldName, service.FieldHostname, service.FieldTerminationProtection))
+	if updated.TerminationProtection != server.TerminationProtection {
+		if updated.TerminationProtection {
+			h.events.LogServerEventAs(originOf(c), server.ID, "TERMINATION_PROTECTION_ENABLED", "Termination protection enabled.", nil, nil)
+		} else {
+			h.events.LogServerEventAs(originOf(c), server.ID, "TERMINATION_PROTECTION_DISABLED", "Termination protection disabled.", nil, nil)
+		}
+	}
+	if hostnameChanged {
+		h.deregisterServerDNS(server.ID)
+		h.registerServerDNS(&updated)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Action '%s' processed for server. Status remains '%s'.", action, originalStatus),
-		"server": gin.H{
-			"id":        server.ID,
-			"status":    server.Status,
-			"stoppedAt": server.UpdatedAt,
-		},
+		"message": "Server updated successfully",
+		"server":  updated,
 	})
 }
 
-func ListServers(c *gin.Context) {
-	var servers []models.Server
-	result := db.DB.Find(&servers)
+func (h *ServerHandler) ListServers(c *gin.Context) {
+	if !h.authorize(c, service.PermissionServersRead, "") {
+		return
+	}
 
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
+	selector, err := service.ParseSelector(c.Query("selector"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid selector",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	servers, err := h.servers.List(projectOf(c))
+
+	if err != nil {
+		if err == repository.ErrNotFound {
 			log.Printf("Servers not found. \n")
 			c.JSON(http.StatusNotFound, gin.H{
 				"message": "Servers not found.",
@@ -199,44 +499,143 @@ func ListServers(c *gin.Context) {
 			return
 		}
 
-		log.Printf("Error fetching server details : '%v' \n", result.Error)
+		log.Printf("Error fetching server details : '%v' \n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Error fetching server details",
-			"error":   result.Error.Error(),
+			"error":   err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Server list fetched successfully",
-		"server":  servers,
+		"server":  filterBySelector(servers, selector),
 	})
 }
 
-func GetLogs(c *gin.Context) {
+func (h *ServerHandler) GetLogs(c *gin.Context) {
 	serverId := c.Param("id")
+	if !h.authorize(c, service.PermissionLogsRead, serverId) {
+		return
+	}
+	c.Set(middleware.AccessServerContextKey, serverId)
 
-	var logs []models.ServerLog
+	// Entries restored from archives are kept apart from the live log.
+	if c.Query("restored") == "true" {
+		restored, err := h.logs.ListRestored(serverId, projectOf(c))
+		if err != nil {
+			log.Printf("Error fetching restored logs for ID '%s' : '%v' \n", serverId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Error fetching server logs",
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Restored server logs fetched successfully",
+			"logs":    restored,
+		})
+		return
+	}
 
-	result := db.DB.Where("server_id = ?", serverId).
-		Order("created_at DESC").
-		Limit(100).
-		Find(&logs)
+	logs, err := h.logs.ListByServer(serverId, projectOf(c), 100)
 
-	if result.Error != nil {
-		logger.LogServerEvent(serverId, "LOGS_NOT_FOUND", fmt.Sprintf("Log not accessed for server '%s'", serverId), nil, nil)
-		log.Printf("Error fetching server logs for ID '%s' : '%v' \n", serverId, result.Error)
+	if err != nil {
+		log.Printf("Error fetching server logs for ID '%s' : '%v' \n", serverId, err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Error fetching server logs",
-			"error":   result.Error.Error(),
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	logger.LogServerEvent(serverId, "LOGS_ACCESSED", fmt.Sprintf("Log accessed for server '%s'", serverId), nil, nil)
 	log.Printf("Found %d logs for server ID '%s'.\n", len(logs), serverId)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Server logs fetched successfully",
 		"logs":    logs,
 	})
 }
+
+// findServer resolves a server by ID or name, writing the error response
+// itself when it cannot.
+func (h *ServerHandler) findServer(c *gin.Context, serverId string) (*models.Server, bool) {
+	server, err := h.lookupServer(serverId, c.Query("region"), projectOf(c))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			log.Printf("Server with ID '%s' not found.\n", serverId)
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Server with ID '%s' not found.", serverId),
+			})
+			return nil, false
+		}
+		if err == errAmbiguousServerName {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": fmt.Sprintf("More than one server is named '%s'. Specify ?region= or use the ID.", serverId),
+			})
+			return nil, false
+		}
+		log.Printf("Error fetching server details for ID '%s': %v\n", serverId, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error fetching server details",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	c.Set(middleware.AccessServerContextKey, server.ID)
+	return server, true
+}
+
+// lookupServer treats anything that is not a UUID as a server name. Names are
+// only unique among live servers of a region, so region narrows the search.
+// Servers outside projectId are not found, unless projectId is empty.
+func (h *ServerHandler) lookupServer(idOrName, region, projectId string) (*models.Server, error) {
+	if _, err := uuid.Parse(idOrName); err == nil {
+		return h.servers.Get(idOrName, projectId)
+	}
+
+	matches, err := h.servers.FindByName(idOrName, region, projectId, 2)
+	if err != nil {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+		return nil, repository.ErrNotFound
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, errAmbiguousServerName
+	}
+}
+
+// validateServerNaming normalises the hostname and checks name, hostname and
+// description. Empty values are allowed.
+func validateServerNaming(name, hostname *string, description string) string {
+	if *name != "" {
+		if errorMessage := service.ValidateServerName(*name); errorMessage != "" {
+			return errorMessage
+		}
+	}
+	if *hostname != "" {
+		*hostname = service.NormalizeDNSName(*hostname)
+		if errorMessage := service.ValidateHostname(*hostname); errorMessage != "" {
+			return errorMessage
+		}
+	}
+	return service.ValidateDescription(description)
+}
+
+// serverNameConflict describes the conflict when err violates one of the
+// indexes keeping names and hostnames unique among the live servers of a
+// project and region, and returns "" for any other error.
+func serverNameConflict(err error, server *models.Server) string {
+	if err == nil {
+		return ""
+	}
+	for _, field := range [][3]string{{db.ServerNameIndex, "name", server.Name}, {db.ServerHostnameIndex, "hostname", server.Hostname}} {
+		index, column, value := field[0], field[1], field[2]
+		if strings.Contains(err.Error(), index) {
+			return fmt.Sprintf("A server with %s '%s' already exists in region '%s'.", column, value, server.Region)
+		}
+	}
+	return ""
+}
@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gitshubham45/virtualServer/internal/db"
+	"github.com/gitshubham45/virtualServer/internal/logger"
 	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/repository"
 	"github.com/google/uuid"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -24,44 +27,62 @@ const (
 	Prime = "prime"
 )
 
-func setupTestDB(t *testing.T) (*gorm.DB, func()) {
-	// open an in memory(created only for testing period)
-	//  SQlite database
-	testDB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+// setupTestDB migrates an in-memory SQLite database of the test's own and
+// returns it with a ServerHandler over it. Nothing is shared between tests,
+// so they can run in parallel.
+func setupTestDB(t *testing.T) (*gorm.DB, *ServerHandler) {
+	testDB, err := gorm.Open(sqlite.Open("file:"+uuid.New().String()+"?mode=memory&cache=shared"), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("Failed to connect to test databse : %v", err)
 	}
 	// Migrate models to the test databse
-	err = testDB.AutoMigrate(&models.Server{}, &models.ServerLog{})
+	err = db.AutoMigrate(testDB)
 	if err != nil {
 		t.Fatalf("Failed to auto migrate test schema: %v", err)
 	}
-
-	originalDB := db.DB
-	db.DB = testDB
-
-	// cleanup function
-	cleanup := func() {
+	t.Cleanup(func() {
 		sqlDB, _ := testDB.DB()
 		sqlDB.Close() // close the in-memory DB connection
-		db.DB = originalDB
-	}
+	})
 
-	return testDB, cleanup
+	events := logger.New(repository.NewGormLogRepository(testDB))
+	return testDB, NewServerHandler(testDB, repository.NewGormServerRepository(testDB), events)
 }
 
-type mockErrorDB struct{}
+// sendJSON serves a request with a JSON body through router. headers are
+// name, value pairs. The request comes from 192.0.2.1.
+func sendJSON(t *testing.T, router http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
 
-// A helper method to simulate a GORM Create() returning an error
-func (m *mockErrorDB) Create(value interface{}) *gorm.DB {
-	return &gorm.DB{Error: errors.New("mock database connection failed")}
+// createTestServer creates a server through POST /api/server on router and
+// returns its ID. body is the create request; headers are passed on.
+func createTestServer(t *testing.T, router http.Handler, body string, headers ...string) string {
+	t.Helper()
+	rec := sendJSON(t, router, http.MethodPost, "/api/server", body, headers...)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d creating %s, got %d: %s", http.StatusCreated, body, rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	return created.ID
 }
 
 func TestCreateServer(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	t.Parallel()
 
-	testDB , cleanup := setupTestDB(t)
-	defer cleanup()
+	testDB, h := setupTestDB(t)
 
 	serverConfig := map[string]interface{}{
 		"region": "India",
@@ -75,7 +96,7 @@ func TestCreateServer(t *testing.T) {
 	}
 
 	router := gin.Default()
-	router.POST("/api/server", CreateServer)
+	router.POST("/api/server", h.CreateServer)
 
 	// --- Test case 1 : Server Creaetd ----
 	t.Run("Server Created", func(t *testing.T) {
@@ -142,6 +163,22 @@ func TestCreateServer(t *testing.T) {
 		// }
 	})
 
+	t.Run("Unknown key pair", func(t *testing.T) {
+		body := []byte(`{"region": "India", "type": "basic", "keyNames": ["missing"]}`)
+		req, err := http.NewRequest(http.MethodPost, "/api/server", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for unknown key pair, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
 	t.Run("Database Error", func(t *testing.T) {
 		
 		err := testDB.Migrator().DropTable(&models.Server{})
@@ -167,12 +204,12 @@ func TestCreateServer(t *testing.T) {
 
 }
 func TestGetServersData(t *testing.T) {
+	t.Parallel()
 	// set Gin to test mode to suppress debug output
 	gin.SetMode(gin.TestMode)
 
 	// setup the in memory test databse and get cleanup function
-	testDB, cleanup := setupTestDB(t)
-	defer cleanup()
+	testDB, h := setupTestDB(t)
 
 	testServer := models.Server{
 		ID:           uuid.New().String(),
@@ -192,7 +229,7 @@ func TestGetServersData(t *testing.T) {
 
 	// create a new Gin router
 	router := gin.Default()
-	router.GET("/api/servers/:id", GetServersData)
+	router.GET("/api/servers/:id", h.GetServer)
 
 	// --- Test Case 1 : Server  Found case(Success) ---
 	t.Run("Server Found", func(t *testing.T) {
@@ -277,3 +314,191 @@ func TestGetServersData(t *testing.T) {
 	})
 
 }
+
+func TestServerNames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	_, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.GET("/api/servers/:id", h.GetServer)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/api/server", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := create(`{"region": "India", "type": "basic", "name": "api", "hostname": "API-1", "description": "public API"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	t.Run("Duplicate name in region", func(t *testing.T) {
+		rec := create(`{"region": "India", "type": "basic", "name": "api"}`)
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("Expected status %d, got %d", http.StatusConflict, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "name 'api' already exists in region 'India'") {
+			t.Errorf("Expected the conflicting name in the message, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("Duplicate hostname in region", func(t *testing.T) {
+		if rec := create(`{"region": "India", "type": "basic", "hostname": "api-1"}`); rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("Invalid hostname", func(t *testing.T) {
+		if rec := create(`{"region": "India", "type": "basic", "hostname": "api_1"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Lookup by name", func(t *testing.T) {
+		rec := get("/api/servers/api")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		var response struct {
+			Server models.Server `json:"server"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response body: %v", err)
+		}
+		if response.Server.Hostname != "api-1" || response.Server.Description != "public API" {
+			t.Errorf("Unexpected server %+v", response.Server)
+		}
+	})
+
+	t.Run("Same name in another region", func(t *testing.T) {
+		if rec := create(`{"region": "US East", "type": "basic", "name": "api"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, rec.Code)
+		}
+		if rec := get("/api/servers/api"); rec.Code != http.StatusConflict {
+			t.Errorf("Expected ambiguous lookup to return %d, got %d", http.StatusConflict, rec.Code)
+		}
+		if rec := get("/api/servers/api?region=US%20East"); rec.Code != http.StatusOK {
+			t.Errorf("Expected region-scoped lookup to return %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+}
+
+// newTestServerHandler serves from in-memory repositories, which need no
+// shared database, so tests using it can run in parallel.
+func newTestServerHandler(t *testing.T) (*repository.FaultyServerRepository, *repository.FaultyLogRepository, *gin.Engine) {
+	memory := repository.NewMemoryServerRepository()
+	servers := repository.NewFaultyServerRepository(memory)
+	logs := repository.NewFaultyLogRepository(repository.NewMemoryLogRepository(memory))
+	handler := NewServerHandler(nil, servers, logger.New(logs))
+
+	router := gin.New()
+	router.GET("/api/servers", handler.ListServers)
+	router.GET("/api/servers/:id", handler.GetServer)
+	router.GET("/api/servers/:id/logs", handler.GetLogs)
+	router.GET("/api/audit/consistency", handler.CheckConsistency)
+	return servers, logs, router
+}
+
+func TestServerHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	get := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	t.Run("Get by name", func(t *testing.T) {
+		t.Parallel()
+		servers, _, router := newTestServerHandler(t)
+		server := models.Server{ID: uuid.New().String(), Name: "web", Region: "India", Status: "running"}
+		servers.Create(&server)
+
+		rec := get(router, "/api/servers/web")
+		var response struct {
+			Server models.Server `json:"server"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if rec.Code != http.StatusOK || response.Server.ID != server.ID {
+			t.Errorf("Expected server %s, got %d: %s", server.ID, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("Store failures", func(t *testing.T) {
+		t.Parallel()
+		servers, logs, router := newTestServerHandler(t)
+		server := models.Server{ID: uuid.New().String(), Status: "running"}
+		servers.Create(&server)
+		failure := errors.New("mock database connection failed")
+
+		servers.Fail("Get", failure)
+		if rec := get(router, "/api/servers/"+server.ID); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d when the lookup fails, got %d", http.StatusInternalServerError, rec.Code)
+		}
+		servers.Fail("Get", nil)
+		servers.Fail("Keys", failure)
+		if rec := get(router, "/api/servers/"+server.ID); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d when the keys fail, got %d", http.StatusInternalServerError, rec.Code)
+		}
+		servers.Fail("List", failure)
+		if rec := get(router, "/api/servers"); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d when listing fails, got %d", http.StatusInternalServerError, rec.Code)
+		}
+		logs.Fail("ListByServer", failure)
+		if rec := get(router, "/api/servers/"+server.ID+"/logs"); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d when the logs fail, got %d", http.StatusInternalServerError, rec.Code)
+		}
+		logs.Fail("Events", failure)
+		if rec := get(router, "/api/servers/"+server.ID+"?asOf="+time.Now().Format(time.RFC3339)); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d when the events fail, got %d", http.StatusInternalServerError, rec.Code)
+		}
+		if rec := get(router, "/api/audit/consistency"); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d when the consistency check cannot read events, got %d", http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("Logs and asOf", func(t *testing.T) {
+		t.Parallel()
+		servers, logs, router := newTestServerHandler(t)
+		server := models.Server{ID: uuid.New().String(), Name: "web", Status: "running"}
+		servers.Create(&server)
+		log := logger.New(logs)
+		log.LogServerChange(logger.System(""), server.ID, "SERVER_CREATED", "created", nil, logger.StringPtr("running"), map[string]string{"name": "web"})
+
+		rec := get(router, "/api/servers/"+server.ID+"/logs")
+		var response struct {
+			Logs []models.ServerLog `json:"logs"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if rec.Code != http.StatusOK || len(response.Logs) != 1 || response.Logs[0].Sequence != 1 {
+			t.Errorf("Expected the one logged event, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		asOf := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		if rec := get(router, "/api/servers/"+server.ID+"?asOf="+asOf); rec.Code != http.StatusOK {
+			t.Errorf("Expected the rebuilt server, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := get(router, "/api/audit/consistency?serverId="+server.ID); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"checked":1`) {
+			t.Errorf("Expected the server to be checked against its events, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+// UpdateTags merges the given tags into the server's tags, overwriting
+// existing values for the same keys.
+func (h *ServerHandler) UpdateTags(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersUpdate, server.ID) {
+		return
+	}
+
+	var req struct {
+		Tags map[string]string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tags := make(map[string]string, len(server.Tags)+len(req.Tags))
+	for key, value := range server.Tags {
+		tags[key] = value
+	}
+	for key, value := range req.Tags {
+		tags[key] = value
+	}
+
+	if errorMessage := service.ValidateTags(tags); errorMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+		return
+	}
+
+	if !h.saveTags(c, server, tags) {
+		return
+	}
+
+	h.events.LogServerChange(originOf(c), server.ID, "TAGS_UPDATED", fmt.Sprintf("Tags set: %s.", formatTags(req.Tags)), nil, nil,
+		map[string]string{service.FieldTags: service.EncodeTags(tags)})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tags updated successfully",
+		"tags":    tags,
+	})
+}
+
+// DeleteTags removes the keys listed in ?keys=a,b from the server's tags.
+func (h *ServerHandler) DeleteTags(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersUpdate, server.ID) {
+		return
+	}
+
+	var keys []string
+	for _, key := range strings.Split(c.Query("keys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Query parameter 'keys' is required."})
+		return
+	}
+
+	tags := make(map[string]string, len(server.Tags))
+	for key, value := range server.Tags {
+		tags[key] = value
+	}
+	for _, key := range keys {
+		delete(tags, key)
+	}
+
+	if !h.saveTags(c, server, tags) {
+		return
+	}
+
+	h.events.LogServerChange(originOf(c), server.ID, "TAGS_REMOVED", fmt.Sprintf("Tags removed: %s.", strings.Join(keys, ", ")), nil, nil,
+		map[string]string{service.FieldTags: service.EncodeTags(tags)})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tags removed successfully",
+		"tags":    tags,
+	})
+}
+
+func (h *ServerHandler) saveTags(c *gin.Context, server *models.Server, tags map[string]string) bool {
+	server.Tags = tags
+	if err := h.servers.Update(server, "tags"); err != nil {
+		log.Printf("Error saving tags for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update tags",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// filterBySelector keeps the servers whose tags match the selector.
+func filterBySelector(servers []models.Server, selector service.Selector) []models.Server {
+	if selector.Empty() {
+		return servers
+	}
+	matched := make([]models.Server, 0, len(servers))
+	for _, server := range servers {
+		if selector.Matches(server.Tags) {
+			matched = append(matched, server)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestTagsAndSelectors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	testDB, h := setupTestDB(t)
+
+	router := gin.Default()
+	router.POST("/api/server", h.CreateServer)
+	router.GET("/api/servers", h.ListServers)
+	router.PUT("/api/servers/:id/tags", h.UpdateTags)
+	router.DELETE("/api/servers/:id/tags", h.DeleteTags)
+	router.GET("/api/billing/report", h.GetBillingReport)
+
+	ids := make(map[string]string)
+	for name, body := range map[string]string{
+		"api":    `{"region": "India", "type": "basic", "name": "api", "tags": {"env": "prod", "team": "a"}}`,
+		"worker": `{"region": "India", "type": "plus", "name": "worker", "tags": {"env": "dev", "team": "b"}}`,
+		"old":    `{"region": "India", "type": "prime", "name": "old", "tags": {"env": "prod", "legacy": ""}}`,
+	} {
+		ids[name] = createTestServer(t, router, body)
+	}
+
+	list := func(selector string) []string {
+		req, _ := http.NewRequest(http.MethodGet, "/api/servers", nil)
+		q := req.URL.Query()
+		q.Set("selector", selector)
+		req.URL.RawQuery = q.Encode()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d listing %q, got %d", http.StatusOK, selector, rec.Code)
+		}
+		var response struct {
+			Server []models.Server `json:"server"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		names := []string{}
+		for _, server := range response.Server {
+			names = append(names, server.Name)
+		}
+		return names
+	}
+
+	t.Run("Selector filters list", func(t *testing.T) {
+		if names := list("env=prod,!legacy"); len(names) != 1 || names[0] != "api" {
+			t.Errorf("Expected [api], got %v", names)
+		}
+		if names := list("team in (a,b)"); len(names) != 2 {
+			t.Errorf("Expected 2 servers, got %v", names)
+		}
+	})
+
+	t.Run("Invalid selector", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodGet, "/api/servers?selector=team+in+(a", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Update and delete tags", func(t *testing.T) {
+		if rec := sendJSON(t, router, http.MethodPut, "/api/servers/worker/tags", `{"tags": {"env": "prod"}}`); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if names := list("env=prod,!legacy"); len(names) != 2 {
+			t.Errorf("Expected 2 servers after retagging, got %v", names)
+		}
+		if rec := sendJSON(t, router, http.MethodDelete, "/api/servers/old/tags?keys=legacy", ""); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if names := list("legacy"); len(names) != 0 {
+			t.Errorf("Expected no legacy servers, got %v", names)
+		}
+		if rec := sendJSON(t, router, http.MethodPut, "/api/servers/api/tags", `{"tags": {"env": "not valid"}}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for invalid tag, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Billing report grouped by tag", func(t *testing.T) {
+		// Backdate the open billing periods so the report has something to total.
+		start := time.Now().Add(-2 * time.Hour)
+		testDB.Model(&models.BillingPeriod{}).Where("ended_at IS NULL").Update("started_at", start)
+
+		from := start.Add(-time.Minute).UTC().Format(time.RFC3339)
+		req, _ := http.NewRequest(http.MethodGet, "/api/billing/report", nil)
+		q := req.URL.Query()
+		q.Set("from", from)
+		q.Set("groupBy", "team")
+		req.URL.RawQuery = q.Encode()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		var response struct {
+			Total  float64            `json:"total"`
+			Groups map[string]float64 `json:"groups"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		// Two hours at 5 (basic, team a), 8 (plus, team b) and 12 (prime, untagged).
+		if response.Groups["a"] < 9.9 || response.Groups["b"] < 15.9 || response.Groups[untaggedGroup] < 23.9 {
+			t.Errorf("Unexpected groups %v", response.Groups)
+		}
+		if response.Total < 49.9 || response.Total > 50.1 {
+			t.Errorf("Expected total of about 50, got %v", response.Total)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/base64"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+func (h *ServerHandler) UpdateUserData(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersUpdate, server.ID) {
+		return
+	}
+
+	var req struct {
+		UserData string `json:"userData"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if errorMessage := service.CanModifyUserData(server.Status); errorMessage != "" {
+		h.events.LogServerEventAs(originOf(c), server.ID, "ACTION_DENIED", errorMessage, logger.StringPtr(server.Status), nil)
+		c.JSON(http.StatusConflict, gin.H{"message": errorMessage})
+		return
+	}
+
+	if req.UserData != "" {
+		if _, errorMessage := service.DecodeUserData(req.UserData); errorMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": errorMessage})
+			return
+		}
+	}
+
+	server.UserData = req.UserData
+	if err := h.servers.Update(server, "user_data"); err != nil {
+		log.Printf("Error saving user data for server '%s': %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update user data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := "User data updated."
+	if req.UserData == "" {
+		message = "User data cleared."
+	}
+	h.events.LogServerEventAs(originOf(c), server.ID, "USER_DATA_UPDATED", message, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "User data updated successfully"})
+}
+
+// GetUserData serves the decoded payload as-is, the way an instance would
+// read it from its metadata service.
+func (h *ServerHandler) GetUserData(c *gin.Context) {
+	server, ok := h.findServer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.authorize(c, service.PermissionServersRead, server.ID) {
+		return
+	}
+
+	if server.UserData == "" {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Server has no user data."})
+		return
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(server.UserData)
+	if err != nil {
+		log.Printf("Stored user data for server '%s' is not valid base64: %v\n", server.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error decoding user data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", decoded)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -34,7 +35,7 @@ func InitDB() {
 		log.Fatalf("Error opening database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.Server{}, &models.ServerLog{})
+	err = AutoMigrate(db)
 	if err != nil {
 		log.Fatalf("Failed to auto migrate schemas : %v", err)
 	}
@@ -44,6 +45,125 @@ func InitDB() {
 	log.Println("Connected to PostgreSql")
 }
 
+func AutoMigrate(db *gorm.DB) error {
+	err := db.AutoMigrate(
+		&models.Server{},
+		&models.ServerLog{},
+		&models.SecurityGroup{},
+		&models.SecurityGroupRule{},
+		&models.ServerSecurityGroup{},
+		&models.KeyPair{},
+		&models.ServerKey{},
+		&models.LoadBalancer{},
+		&models.Listener{},
+		&models.TargetGroup{},
+		&models.Target{},
+		&models.DNSZone{},
+		&models.DNSRecord{},
+		&models.BillingPeriod{},
+		&models.Schedule{},
+		&models.APIKey{},
+		&models.Organization{},
+		&models.Project{},
+		&models.ProjectQuota{},
+		&models.AccessLog{},
+		&models.ArchivedServerLog{},
+		&models.RestoredServerLog{},
+		&models.ServerLogToken{},
+	)
+	if err != nil {
+		return err
+	}
+	for _, statement := range serverNameIndexSQL {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	if db.Dialector.Name() != "postgres" {
+		if err := db.Exec(serverIDIndexSQL).Error; err != nil {
+			return err
+		}
+		return indexServerLogs(db)
+	}
+	for _, statement := range append(serverLogAppendOnlySQL, serverLogSearchIndexSQL, dropKeyPairNameIndexSQL) {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServerNameIndex and ServerHostnameIndex keep names and hostnames unique
+// among the live servers of a project and region. Servers without a project
+// share one namespace.
+const (
+	ServerNameIndex     = "idx_servers_live_name"
+	ServerHostnameIndex = "idx_servers_live_hostname"
+)
+
+var serverNameIndexSQL = []string{
+	`CREATE UNIQUE INDEX IF NOT EXISTS ` + ServerNameIndex + ` ON servers (COALESCE(project_id, ''), region, name)
+	WHERE name <> '' AND status <> '` + service.StatusTerminated + `' AND deleted_at IS NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS ` + ServerHostnameIndex + ` ON servers (COALESCE(project_id, ''), region, hostname)
+	WHERE hostname <> '' AND status <> '` + service.StatusTerminated + `' AND deleted_at IS NULL`,
+}
+
+// ServerIDIndex keeps server IDs unique on SQLite, where server_number
+// becomes the primary key because it auto-increments.
+const ServerIDIndex = "idx_servers_id"
+
+const serverIDIndexSQL = `CREATE UNIQUE INDEX IF NOT EXISTS ` + ServerIDIndex + ` ON servers (id)`
+
+// serverLogSearchIndexSQL is the full-text index log search uses on
+// PostgreSQL. Searches must use the same expression to hit it.
+const serverLogSearchIndexSQL = `CREATE INDEX IF NOT EXISTS idx_server_logs_message_search
+	ON server_logs USING GIN (to_tsvector('english', message))`
+
+// dropKeyPairNameIndexSQL removes the index that kept key pair names unique
+// across every project. They are now unique within a project.
+const dropKeyPairNameIndexSQL = `DROP INDEX IF EXISTS idx_key_pairs_name`
+
+// indexServerLogs fills the token index of log search for entries written
+// before it existed.
+func indexServerLogs(db *gorm.DB) error {
+	var indexed int64
+	if err := db.Model(&models.ServerLogToken{}).Limit(1).Count(&indexed).Error; err != nil || indexed > 0 {
+		return err
+	}
+	var batch []models.ServerLog
+	return db.Select("id", "message").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		var tokens []models.ServerLogToken
+		for i := range batch {
+			tokens = append(tokens, service.ServerLogTokens(&batch[i])...)
+		}
+		if len(tokens) == 0 {
+			return nil
+		}
+		return db.CreateInBatches(&tokens, 1000).Error
+	}).Error
+}
+
+// LogRetentionSetting is the transaction setting under which log retention
+// may delete archived audit log entries.
+const LogRetentionSetting = "virtualserver.log_retention"
+
+// serverLogAppendOnlySQL makes PostgreSQL itself refuse to change or remove
+// audit log entries, for writers that bypass the model's hooks. Only log
+// retention, which archives entries first, may delete them.
+var serverLogAppendOnlySQL = []string{
+	`CREATE OR REPLACE FUNCTION server_logs_append_only() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'DELETE' AND current_setting('` + LogRetentionSetting + `', true) = 'on' THEN
+			RETURN OLD;
+		END IF;
+		RAISE EXCEPTION 'server_logs is append-only';
+	END;
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS server_logs_append_only ON server_logs`,
+	`CREATE TRIGGER server_logs_append_only BEFORE UPDATE OR DELETE ON server_logs
+	FOR EACH ROW EXECUTE FUNCTION server_logs_append_only()`,
+}
+
 func CloseDB() {
 	if DB != nil {
 		sqlDb, err := DB.DB()
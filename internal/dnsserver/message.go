@@ -0,0 +1,246 @@
+package dnsserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+const (
+	typeA     uint16 = 1
+	typeCNAME uint16 = 5
+	typeTXT   uint16 = 16
+	typeAAAA  uint16 = 28
+	typeANY   uint16 = 255
+
+	classIN uint16 = 1
+
+	rcodeSuccess  = 0
+	rcodeFormErr  = 1
+	rcodeNXDomain = 3
+	rcodeNotImpl  = 4
+	rcodeRefused  = 5
+
+	headerSize = 12
+	maxCNAMEs  = 8
+)
+
+var recordTypes = map[string]uint16{
+	service.RecordTypeA:     typeA,
+	service.RecordTypeAAAA:  typeAAAA,
+	service.RecordTypeCNAME: typeCNAME,
+	service.RecordTypeTXT:   typeTXT,
+}
+
+var errMalformed = errors.New("malformed DNS message")
+
+// Source answers lookups for the names the responder is authoritative for.
+// zone is the zone the name falls in, or empty when it is outside every zone.
+type Source interface {
+	Resolve(name string) (records []models.DNSRecord, zone string, err error)
+}
+
+type question struct {
+	name  string
+	qtype uint16
+	class uint16
+	end   int
+}
+
+func parseQuestion(msg []byte) (*question, error) {
+	if len(msg) < headerSize {
+		return nil, errMalformed
+	}
+	if binary.BigEndian.Uint16(msg[4:6]) != 1 {
+		return nil, errMalformed
+	}
+
+	var labels []string
+	offset := headerSize
+	for {
+		if offset >= len(msg) {
+			return nil, errMalformed
+		}
+		length := int(msg[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		if length > 63 || offset+length > len(msg) {
+			return nil, errMalformed
+		}
+		labels = append(labels, string(msg[offset:offset+length]))
+		offset += length
+	}
+
+	if offset+4 > len(msg) {
+		return nil, errMalformed
+	}
+
+	return &question{
+		name:  service.NormalizeDNSName(strings.Join(labels, ".")),
+		qtype: binary.BigEndian.Uint16(msg[offset : offset+2]),
+		class: binary.BigEndian.Uint16(msg[offset+2 : offset+4]),
+		end:   offset + 4,
+	}, nil
+}
+
+// HandleQuery builds the response to a single DNS query message.
+func HandleQuery(msg []byte, source Source) ([]byte, error) {
+	if len(msg) < headerSize {
+		return nil, errMalformed
+	}
+
+	q, err := parseQuestion(msg)
+	if err != nil {
+		return header(msg, rcodeFormErr, false, 0, 0), nil
+	}
+	if msg[2]&0x78 != 0 {
+		return header(msg, rcodeNotImpl, false, 0, 0), nil
+	}
+
+	response := header(msg, rcodeSuccess, true, 1, 0)
+	response = append(response, msg[headerSize:q.end]...)
+
+	if q.class != classIN {
+		return setRcode(response, rcodeRefused, false), nil
+	}
+
+	var answers [][]byte
+	name := q.name
+	for hop := 0; hop < maxCNAMEs; hop++ {
+		records, zone, err := source.Resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		if zone == "" {
+			if hop == 0 {
+				return setRcode(response, rcodeRefused, false), nil
+			}
+			break
+		}
+		if len(records) == 0 {
+			if hop == 0 && name != zone {
+				return setRcode(response, rcodeNXDomain, true), nil
+			}
+			break
+		}
+
+		var cname *models.DNSRecord
+		for i := range records {
+			recordType := recordTypes[records[i].Type]
+			if recordType == typeCNAME && q.qtype != typeCNAME {
+				cname = &records[i]
+				break
+			}
+			if q.qtype == typeANY || q.qtype == recordType {
+				if rr := encodeRecord(records[i], recordType); rr != nil {
+					answers = append(answers, rr)
+				}
+			}
+		}
+
+		if cname == nil {
+			break
+		}
+		answers = append(answers, encodeRecord(*cname, typeCNAME))
+		name = cname.Value
+	}
+
+	binary.BigEndian.PutUint16(response[6:8], uint16(len(answers)))
+	for _, rr := range answers {
+		response = append(response, rr...)
+	}
+	return response, nil
+}
+
+// truncate drops the answers and sets TC, since without EDNS a UDP answer must
+// fit in 512 bytes. Clients retry over TCP, which is not served here.
+func truncate(query, response []byte) []byte {
+	q, err := parseQuestion(query)
+	if err != nil {
+		return header(query, rcodeFormErr, false, 0, 0)
+	}
+	out := append([]byte{}, response[:q.end]...)
+	binary.BigEndian.PutUint16(out[6:8], 0)
+	out[2] |= 0x02
+	return out
+}
+
+func header(query []byte, rcode byte, authoritative bool, qdcount, ancount uint16) []byte {
+	h := make([]byte, headerSize)
+	copy(h[0:2], query[0:2])
+	h[2] = 0x80 | (query[2] & 0x79) // QR, opcode and RD copied from the query
+	if authoritative {
+		h[2] |= 0x04
+	}
+	h[3] = rcode
+	binary.BigEndian.PutUint16(h[4:6], qdcount)
+	binary.BigEndian.PutUint16(h[6:8], ancount)
+	return h
+}
+
+func setRcode(response []byte, rcode byte, authoritative bool) []byte {
+	response[3] = rcode
+	if !authoritative {
+		response[2] &^= 0x04
+	}
+	return response
+}
+
+func encodeName(name string) []byte {
+	var out []byte
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			out = append(out, byte(len(label)))
+			out = append(out, label...)
+		}
+	}
+	return append(out, 0)
+}
+
+func encodeRecord(record models.DNSRecord, recordType uint16) []byte {
+	var rdata []byte
+	switch recordType {
+	case typeA:
+		ip := net.ParseIP(record.Value).To4()
+		if ip == nil {
+			return nil
+		}
+		rdata = ip
+	case typeAAAA:
+		ip := net.ParseIP(record.Value).To16()
+		if ip == nil {
+			return nil
+		}
+		rdata = ip
+	case typeCNAME:
+		rdata = encodeName(record.Value)
+	case typeTXT:
+		value := []byte(record.Value)
+		for len(value) > 0 {
+			chunk := value
+			if len(chunk) > 255 {
+				chunk = chunk[:255]
+			}
+			rdata = append(rdata, byte(len(chunk)))
+			rdata = append(rdata, chunk...)
+			value = value[len(chunk):]
+		}
+	default:
+		return nil
+	}
+
+	rr := encodeName(record.Name)
+	fixed := make([]byte, 10)
+	binary.BigEndian.PutUint16(fixed[0:2], recordType)
+	binary.BigEndian.PutUint16(fixed[2:4], classIN)
+	binary.BigEndian.PutUint32(fixed[4:8], uint32(record.TTL))
+	binary.BigEndian.PutUint16(fixed[8:10], uint16(len(rdata)))
+	rr = append(rr, fixed...)
+	return append(rr, rdata...)
+}
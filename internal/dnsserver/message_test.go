@@ -0,0 +1,123 @@
+package dnsserver
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+type fakeSource struct {
+	zone    string
+	records []models.DNSRecord
+}
+
+func (f fakeSource) Resolve(name string) ([]models.DNSRecord, string, error) {
+	if !service.InZone(name, f.zone) {
+		return nil, "", nil
+	}
+	var out []models.DNSRecord
+	for _, r := range f.records {
+		if r.Name == name {
+			out = append(out, r)
+		}
+	}
+	return out, f.zone, nil
+}
+
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, headerSize)
+	binary.BigEndian.PutUint16(msg[0:2], id)
+	msg[2] = 0x01 // RD
+	binary.BigEndian.PutUint16(msg[4:6], 1)
+	msg = append(msg, encodeName(name)...)
+	tail := make([]byte, 4)
+	binary.BigEndian.PutUint16(tail[0:2], qtype)
+	binary.BigEndian.PutUint16(tail[2:4], classIN)
+	return append(msg, tail...)
+}
+
+func TestHandleQuery(t *testing.T) {
+	source := fakeSource{
+		zone: "sim.internal",
+		records: []models.DNSRecord{
+			{Name: "web-1.sim.internal", Type: "A", Value: "10.0.0.7", TTL: 300},
+			{Name: "www.sim.internal", Type: "CNAME", Value: "web-1.sim.internal", TTL: 60},
+			{Name: "web-1.sim.internal", Type: "TXT", Value: "owner=team-a", TTL: 300},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   uint16
+		rcode   byte
+		answers uint16
+		aa      bool
+	}{
+		{"A record", "web-1.sim.internal", typeA, rcodeSuccess, 1, true},
+		{"case insensitive", "WEB-1.Sim.Internal", typeA, rcodeSuccess, 1, true},
+		{"TXT record", "web-1.sim.internal", typeTXT, rcodeSuccess, 1, true},
+		{"ANY", "web-1.sim.internal", typeANY, rcodeSuccess, 2, true},
+		{"CNAME chased to A", "www.sim.internal", typeA, rcodeSuccess, 2, true},
+		{"NODATA", "web-1.sim.internal", typeAAAA, rcodeSuccess, 0, true},
+		{"zone apex", "sim.internal", typeA, rcodeSuccess, 0, true},
+		{"NXDOMAIN", "nope.sim.internal", typeA, rcodeNXDomain, 0, true},
+		{"outside zone", "example.com", typeA, rcodeRefused, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := HandleQuery(buildQuery(0xbeef, tt.qname, tt.qtype), source)
+			if err != nil {
+				t.Fatalf("HandleQuery failed: %v", err)
+			}
+			if binary.BigEndian.Uint16(response[0:2]) != 0xbeef {
+				t.Errorf("Response ID does not match query")
+			}
+			if response[2]&0x80 == 0 || response[2]&0x01 == 0 {
+				t.Errorf("Expected QR and RD bits to be set, flags %08b", response[2])
+			}
+			if aa := response[2]&0x04 != 0; aa != tt.aa {
+				t.Errorf("Expected AA = %v, got %v", tt.aa, aa)
+			}
+			if rcode := response[3] & 0x0f; rcode != tt.rcode {
+				t.Errorf("Expected rcode %d, got %d", tt.rcode, rcode)
+			}
+			if answers := binary.BigEndian.Uint16(response[6:8]); answers != tt.answers {
+				t.Errorf("Expected %d answers, got %d", tt.answers, answers)
+			}
+		})
+	}
+
+	t.Run("A record data", func(t *testing.T) {
+		query := buildQuery(1, "web-1.sim.internal", typeA)
+		response, _ := HandleQuery(query, source)
+		rdata := response[len(response)-4:]
+		if ip := net.IP(rdata).String(); ip != "10.0.0.7" {
+			t.Errorf("Expected 10.0.0.7, got %s", ip)
+		}
+	})
+
+	t.Run("malformed question", func(t *testing.T) {
+		query := buildQuery(2, "web-1.sim.internal", typeA)
+		response, err := HandleQuery(query[:headerSize+3], source)
+		if err != nil {
+			t.Fatalf("HandleQuery failed: %v", err)
+		}
+		if rcode := response[3] & 0x0f; rcode != rcodeFormErr {
+			t.Errorf("Expected FORMERR, got %d", rcode)
+		}
+	})
+
+	t.Run("truncation keeps question", func(t *testing.T) {
+		query := buildQuery(3, "web-1.sim.internal", typeA)
+		response, _ := HandleQuery(query, source)
+		truncated := truncate(query, response)
+		if len(truncated) != len(query) || truncated[2]&0x02 == 0 || binary.BigEndian.Uint16(truncated[6:8]) != 0 {
+			t.Errorf("Unexpected truncated response %v", truncated)
+		}
+	})
+}
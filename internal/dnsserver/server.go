@@ -0,0 +1,69 @@
+package dnsserver
+
+import (
+	"log"
+	"net"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+)
+
+// DBSource resolves names from the zones and records stored in DB.
+type DBSource struct {
+	DB *gorm.DB
+}
+
+func (s DBSource) Resolve(name string) ([]models.DNSRecord, string, error) {
+	var zones []models.DNSZone
+	if err := s.DB.Find(&zones).Error; err != nil {
+		return nil, "", err
+	}
+
+	zone := ""
+	for _, z := range zones {
+		if service.InZone(name, z.Name) && len(z.Name) > len(zone) {
+			zone = z.Name
+		}
+	}
+	if zone == "" {
+		return nil, "", nil
+	}
+
+	var records []models.DNSRecord
+	err := s.DB.Joins("JOIN dns_zones ON dns_zones.id = dns_records.zone_id").
+		Where("dns_zones.name = ? AND dns_records.name = ?", zone, name).
+		Order("dns_records.created_at").
+		Find(&records).Error
+	return records, zone, err
+}
+
+// ListenAndServe answers DNS queries over UDP until the socket fails.
+func ListenAndServe(addr string, source Source) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	log.Printf("DNS responder listening on %s\n", conn.LocalAddr())
+
+	buf := make([]byte, 512)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		response, err := HandleQuery(buf[:n], source)
+		if err != nil {
+			log.Printf("Error answering DNS query from %s: %v\n", peer, err)
+			continue
+		}
+		if len(response) > 512 {
+			response = truncate(buf[:n], response)
+		}
+		if _, err := conn.WriteTo(response, peer); err != nil {
+			log.Printf("Error sending DNS response to %s: %v\n", peer, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"log"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LogAccess records a read request in the access log. Entries about a server
+// belong to the server's project, like its lifecycle events.
+func LogAccess(database *gorm.DB, entry models.AccessLog) {
+	entry.ID = uuid.New().String()
+	if entry.ServerID != "" {
+		var projectIDs []string
+		database.Model(&models.Server{}).Unscoped().Where("id = ?", entry.ServerID).Limit(1).Pluck("project_id", &projectIDs)
+		if len(projectIDs) > 0 {
+			entry.ProjectID = projectIDs[0]
+		}
+	}
+	if err := database.Create(&entry).Error; err != nil {
+		log.Printf("WARNING: Failed to save access log for %s %s: %v\n", entry.Method, entry.Path, err)
+	}
+}
+
+// PruneAccessLog removes access log entries older than before.
+func PruneAccessLog(database *gorm.DB, before time.Time) (int64, error) {
+	result := database.Where("created_at < ?", before).Delete(&models.AccessLog{})
+	return result.RowsAffected, result.Error
+}
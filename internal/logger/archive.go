@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/db"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const archiveDeleteBatch = 500
+
+// ArchiveServerLogs writes entries to a new gzip-compressed JSONL file in dir
+// and then removes them from the log. Chained entries leave an
+// ArchivedServerLog behind so the audit chain still verifies. It returns the
+// archive's file name.
+func ArchiveServerLogs(database *gorm.DB, dir string, entries []models.ServerLog, now time.Time) (string, error) {
+	if len(entries) == 0 {
+		return "", nil
+	}
+	name := fmt.Sprintf("server-logs-%s-%s.jsonl.gz", now.UTC().Format("20060102T150405Z"), uuid.New().String()[:8])
+	path := filepath.Join(dir, name)
+	if err := writeArchive(path, entries); err != nil {
+		return "", err
+	}
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT set_config(?, 'on', true)", db.LogRetentionSetting).Error; err != nil {
+				return err
+			}
+		}
+
+		var stubs []models.ArchivedServerLog
+		ids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+			if entry.Sequence > 0 {
+				stubs = append(stubs, models.ArchivedServerLog{
+					Sequence:    entry.Sequence,
+					ServerLogID: entry.ID,
+					ServerID:    entry.ServerID,
+					EventType:   entry.EventType,
+					PrevHash:    entry.PrevHash,
+					Hash:        entry.Hash,
+					Archive:     name,
+					ArchivedAt:  now,
+				})
+			}
+		}
+		if len(stubs) > 0 {
+			if err := tx.CreateInBatches(&stubs, archiveDeleteBatch).Error; err != nil {
+				return err
+			}
+		}
+		for start := 0; start < len(ids); start += archiveDeleteBatch {
+			end := min(start+archiveDeleteBatch, len(ids))
+			// The hooks refuse every delete; this is the one sanctioned path.
+			if err := tx.Session(&gorm.Session{SkipHooks: true}).
+				Where("id IN ?", ids[start:end]).Delete(&models.ServerLog{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("server_log_id IN ?", ids[start:end]).Delete(&models.ServerLogToken{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing was removed, so the archive would only duplicate the log.
+		os.Remove(path)
+		return "", err
+	}
+	return name, nil
+}
+
+// RestoreArchive imports the entries of an archive file as RestoredServerLog
+// rows. Each chained entry must match the hash its ArchivedServerLog kept, so
+// an archive altered since it was written is refused as a whole. Entries
+// already restored are skipped. It returns the number of entries read.
+func RestoreArchive(database *gorm.DB, path string) (int, error) {
+	entries, err := ReadArchive(path)
+	if err != nil {
+		return 0, err
+	}
+	name := filepath.Base(path)
+
+	var sequences []int64
+	for _, entry := range entries {
+		if entry.Sequence > 0 {
+			sequences = append(sequences, entry.Sequence)
+		}
+	}
+	stubs := make(map[int64]models.ArchivedServerLog, len(sequences))
+	for start := 0; start < len(sequences); start += archiveDeleteBatch {
+		end := min(start+archiveDeleteBatch, len(sequences))
+		var batch []models.ArchivedServerLog
+		if err := database.Where("sequence IN ?", sequences[start:end]).Find(&batch).Error; err != nil {
+			return 0, err
+		}
+		for _, stub := range batch {
+			stubs[stub.Sequence] = stub
+		}
+	}
+
+	now := time.Now()
+	restored := make([]models.RestoredServerLog, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Sequence > 0 {
+			stub, ok := stubs[entry.Sequence]
+			switch {
+			case !ok:
+				return 0, fmt.Errorf("entry %d was not archived from this log", entry.Sequence)
+			case service.AuditHash(&entry) != entry.Hash || stub.Hash != entry.Hash:
+				return 0, fmt.Errorf("entry %d does not match the audit chain; the archive was modified", entry.Sequence)
+			}
+		}
+		restored = append(restored, entry.Restored(name, now))
+	}
+
+	err = database.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&restored, archiveDeleteBatch).Error
+	return len(restored), err
+}
+
+// ClearRestored removes every restored entry, or only those of one archive.
+func ClearRestored(database *gorm.DB, archive string) (int64, error) {
+	query := database.Where("1 = 1")
+	if archive != "" {
+		query = database.Where("archive = ?", filepath.Base(archive))
+	}
+	result := query.Delete(&models.RestoredServerLog{})
+	return result.RowsAffected, result.Error
+}
+
+// writeArchive writes to a temporary file first, so a crash never leaves a
+// truncated archive behind under the final name.
+func writeArchive(path string, entries []models.ServerLog) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	compressed := gzip.NewWriter(file)
+	encoder := json.NewEncoder(compressed)
+	for i := range entries {
+		if err := encoder.Encode(&entries[i]); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := compressed.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadArchive reads the entries of an archive file.
+func ReadArchive(path string) ([]models.ServerLog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decompressed, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a log archive: %w", path, err)
+	}
+	defer decompressed.Close()
+
+	var entries []models.ServerLog
+	decoder := json.NewDecoder(bufio.NewReader(decompressed))
+	for decoder.More() {
+		var entry models.ServerLog
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("%s: entry %d: %w", path, len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -3,18 +3,71 @@ package logger
 import (
 	"log"
 
-	"github.com/gitshubham45/virtualServer/internal/db"
 	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/repository"
+	"github.com/gitshubham45/virtualServer/internal/service"
 	"github.com/google/uuid"
 )
 
-func LogServerEvent(serverID, eventType, message string, oldStatus, newStatus *string) {
+// Origin says who caused an event and, for events caused by an API request,
+// which request.
+type Origin struct {
+	Actor      string
+	SourceIP   string
+	RequestID  string
+	BodyDigest string
+}
+
+// System is the origin of events the app performs on its own; name is e.g.
+// "scheduler", or empty for the app in general.
+func System(name string) Origin {
+	if name == "" {
+		return Origin{Actor: service.SystemActor}
+	}
+	return Origin{Actor: service.SystemActor + ":" + name}
+}
+
+// Logger writes the lifecycle log to a LogRepository.
+type Logger struct {
+	logs repository.LogRepository
+}
+
+// New returns a Logger appending to logs.
+func New(logs repository.LogRepository) *Logger {
+	return &Logger{logs: logs}
+}
+
+// Logs returns the repository the Logger appends to, for reading the log back.
+func (l *Logger) Logs() repository.LogRepository {
+	return l.logs
+}
+
+// LogServerEvent records an event the app performed on its own behalf.
+func (l *Logger) LogServerEvent(serverID, eventType, message string, oldStatus, newStatus *string) {
+	l.LogServerEventAs(System(""), serverID, eventType, message, oldStatus, newStatus)
+}
+
+// LogServerEventAs records an event with its origin, usually the
+// authenticated caller of the request.
+func (l *Logger) LogServerEventAs(origin Origin, serverID, eventType, message string, oldStatus, newStatus *string) {
+	l.LogServerChange(origin, serverID, eventType, message, oldStatus, newStatus, nil)
+}
+
+// LogServerChange records an event that set server fields other than the
+// status, with their new values, so the server's state can be rebuilt from
+// its events.
+func (l *Logger) LogServerChange(origin Origin, serverID, eventType, message string, oldStatus, newStatus *string, changes map[string]string) {
 	newUUID := uuid.New().String()
 	logEntry := models.ServerLog{
-		ID:        newUUID,
-		ServerID:  serverID,
-		EventType: eventType,
-		Message:   message,
+		ID:         newUUID,
+		ServerID:   serverID,
+		EventType:  eventType,
+		Message:    message,
+		Actor:      origin.Actor,
+		SourceIP:   origin.SourceIP,
+		RequestID:  origin.RequestID,
+		BodyDigest: origin.BodyDigest,
+		Changes:    changes,
 	}
 
 	if oldStatus != nil {
@@ -24,10 +77,10 @@ func LogServerEvent(serverID, eventType, message string, oldStatus, newStatus *s
 		logEntry.NewStatus = *newStatus
 	}
 
-	if err := db.DB.Create(&logEntry).Error; err != nil {
+	if err := l.logs.Append(&logEntry); err != nil {
 		log.Printf("WARNING: Failed to save server log for server %s (Event: %s): %v\n", serverID, eventType, err)
 	} else {
-		log.Printf("Server log saved: ServerID=%s, EventType=%s, Message='%s'\n", serverID, eventType, message)
+		log.Printf("Server log saved: Seq=%d, ServerID=%s, EventType=%s, Actor=%s, Message='%s'\n", logEntry.Sequence, serverID, eventType, origin.Actor, message)
 	}
 }
 
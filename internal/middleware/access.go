@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/logger"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"gorm.io/gorm"
+)
+
+// AccessServerContextKey holds the ID of the server a request read, set by
+// handlers once they have resolved it from an ID or name.
+const AccessServerContextKey = "accessServerId"
+
+// AccessLog records every GET request in the access log of database once it
+// has been handled. Requests that change state are recorded in the lifecycle
+// log instead.
+func AccessLog(database *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logAccess(database, c)
+	}
+}
+
+func logAccess(database *gorm.DB, c *gin.Context) {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.Next()
+		return
+	}
+
+	start := time.Now()
+	c.Next()
+
+	entry := models.AccessLog{
+		Method:    c.Request.Method,
+		Route:     c.FullPath(),
+		Path:      c.Request.URL.Path,
+		Status:    c.Writer.Status(),
+		ServerID:  c.GetString(AccessServerContextKey),
+		Actor:     PrincipalFrom(c).Actor(),
+		SourceIP:  c.ClientIP(),
+		RequestID: c.GetString(RequestIDContextKey),
+		LatencyMs: time.Since(start).Milliseconds(),
+		CreatedAt: start,
+	}
+	if entry.ServerID == "" {
+		entry.ProjectID = PrincipalFrom(c).Project()
+	}
+	logger.LogAccess(database, entry)
+}
@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+)
+
+const (
+	PrincipalContextKey = "principal"
+	APIKeyHeader        = "X-API-Key"
+	ProjectHeader       = "X-Project-ID"
+)
+
+// bootstrapSubject names the principal of ADMIN_API_KEY, which exists so the
+// first real keys can be issued.
+const bootstrapSubject = "bootstrap"
+
+// Authenticate identifies the caller from an API key (X-API-Key, or a bearer
+// token starting with the API key prefix) or a JWT bearer token, and checks
+// that it holds the scope the request method needs. Setting AUTH_DISABLED=true
+// lets every request through as an anonymous admin, for local use only. API
+// keys are looked up in database.
+func Authenticate(database *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticate(database, c)
+	}
+}
+
+func authenticate(database *gorm.DB, c *gin.Context) {
+	if os.Getenv("AUTH_DISABLED") == "true" {
+		principal := &service.Principal{
+			Type:   service.PrincipalAnonymous,
+			Scopes: []string{service.ScopeAdmin},
+			Roles:  []string{service.RoleAdmin},
+		}
+		selectProject(c, principal)
+		c.Set(PrincipalContextKey, principal)
+		c.Next()
+		return
+	}
+
+	token := c.GetHeader(APIKeyHeader)
+	if token == "" {
+		if scheme, value, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(value)
+		}
+	}
+	if token == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="virtualServer"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authentication required."})
+		return
+	}
+
+	var principal *service.Principal
+	if strings.HasPrefix(token, service.APIKeyPrefix) {
+		principal = authenticateAPIKey(database, token)
+	} else {
+		principal = authenticateJWT(token)
+	}
+	if principal == nil {
+		c.Header("WWW-Authenticate", `Bearer realm="virtualServer", error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired credentials."})
+		return
+	}
+
+	if !selectProject(c, principal) {
+		return
+	}
+	c.Set(PrincipalContextKey, principal)
+	if !principal.HasScope(service.ScopeForMethod(c.Request.Method)) {
+		abortInsufficientScope(c, service.ScopeForMethod(c.Request.Method))
+		return
+	}
+	c.Next()
+}
+
+// selectProject narrows a global principal to the project named by the
+// X-Project-ID header. A principal already bound to a project cannot switch
+// to another one.
+func selectProject(c *gin.Context, principal *service.Principal) bool {
+	project := c.GetHeader(ProjectHeader)
+	if project == "" || project == principal.ProjectID {
+		return true
+	}
+	if principal.ProjectID != "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": "This credential is limited to project '" + principal.ProjectID + "'.",
+			"code":    "PROJECT_MISMATCH",
+		})
+		return false
+	}
+	principal.ProjectID = project
+	return true
+}
+
+// RequireScope guards a group of routes that need more than the method implies.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !PrincipalFrom(c).HasScope(scope) {
+			abortInsufficientScope(c, scope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the caller set by Authenticate, or nil for requests
+// that did not pass through it.
+func PrincipalFrom(c *gin.Context) *service.Principal {
+	if value, ok := c.Get(PrincipalContextKey); ok {
+		if principal, ok := value.(*service.Principal); ok {
+			return principal
+		}
+	}
+	return nil
+}
+
+func abortInsufficientScope(c *gin.Context, scope string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"message": "This credential does not have the '" + scope + "' scope.",
+		"code":    "INSUFFICIENT_SCOPE",
+	})
+}
+
+func authenticateAPIKey(database *gorm.DB, token string) *service.Principal {
+	if bootstrap := os.Getenv("ADMIN_API_KEY"); bootstrap != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(bootstrap)) == 1 {
+		return &service.Principal{
+			Type:    service.PrincipalAPIKey,
+			Subject: bootstrapSubject,
+			Scopes:  []string{service.ScopeAdmin},
+			Roles:   []string{service.RoleAdmin},
+		}
+	}
+
+	var key models.APIKey
+	if err := database.First(&key, "key_hash = ?", service.HashToken(token)).Error; err != nil {
+		return nil
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil
+	}
+	if err := database.Model(&key).Update("last_used_at", now).Error; err != nil {
+		log.Printf("WARNING: Failed to record use of API key %s: %v\n", key.ID, err)
+	}
+
+	return &service.Principal{
+		Type:      service.PrincipalAPIKey,
+		Subject:   key.Name,
+		Scopes:    key.Scopes,
+		Roles:     rolesOrDefault(key.Roles, key.Scopes),
+		ProjectID: key.ProjectID,
+	}
+}
+
+func authenticateJWT(token string) *service.Principal {
+	jwks, err := loadJWKS()
+	if err != nil {
+		log.Printf("WARNING: Cannot verify bearer token: %v\n", err)
+		return nil
+	}
+	if jwks == nil {
+		return nil
+	}
+
+	claims, err := jwks.VerifyJWT(token, os.Getenv("JWT_ISSUER"), os.Getenv("JWT_AUDIENCE"), time.Now())
+	if err != nil {
+		log.Printf("Rejected bearer token: %v\n", err)
+		return nil
+	}
+	return &service.Principal{
+		Type:      service.PrincipalJWT,
+		Subject:   claims.Subject,
+		Scopes:    claims.Scopes,
+		Roles:     rolesOrDefault(claims.Roles, claims.Scopes),
+		ProjectID: claims.Project,
+	}
+}
+
+// rolesOrDefault falls back to the role matching the credential's scopes, so
+// keys and tokens issued before roles existed keep working.
+func rolesOrDefault(roles, scopes []string) []string {
+	if len(roles) > 0 {
+		return roles
+	}
+	return service.RolesForScopes(scopes)
+}
+
+var jwksCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	jwks    *service.JWKS
+}
+
+// loadJWKS reads JWKS_FILE, re-reading it whenever it changes so keys can be
+// rotated without a restart. It returns nil when no file is configured.
+func loadJWKS() (*service.JWKS, error) {
+	path := os.Getenv("JWKS_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	jwksCache.Lock()
+	defer jwksCache.Unlock()
+	if jwksCache.jwks != nil && jwksCache.path == path && jwksCache.modTime.Equal(info.ModTime()) {
+		return jwksCache.jwks, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	jwks, err := service.ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	jwksCache.path, jwksCache.modTime, jwksCache.jwks = path, info.ModTime(), jwks
+	return jwks, nil
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDHeader      = "X-Request-ID"
+	RequestIDContextKey  = "requestId"
+	BodyDigestContextKey = "bodyDigest"
+
+	// MaxRequestBodySize bounds the bodies read into memory before the caller
+	// is authenticated. The largest legitimate body, base64 user data, is far
+	// smaller.
+	MaxRequestBodySize = 1 << 20
+)
+
+// RequestMetadata gives every request an ID, taken from X-Request-ID when
+// the client sends one and echoed back, and records the SHA-256 digest of its
+// body. Both end up on the audit log entries the request writes. Bodies over
+// MaxRequestBodySize are refused with 413.
+func RequestMetadata(c *gin.Context) {
+	requestID := c.GetHeader(RequestIDHeader)
+	if requestID == "" || len(requestID) > 128 {
+		requestID = uuid.New().String()
+	}
+	c.Set(RequestIDContextKey, requestID)
+	c.Header(RequestIDHeader, requestID)
+
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxRequestBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"message": fmt.Sprintf("Request body exceeds %d bytes.", MaxRequestBodySize),
+			})
+			return
+		}
+		if err != nil {
+			log.Printf("WARNING: Cannot read body of request %s: %v\n", requestID, err)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) > 0 {
+			sum := sha256.Sum256(body)
+			c.Set(BodyDigestContextKey, hex.EncodeToString(sum[:]))
+		}
+	}
+	c.Next()
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// AccessLog records a read request. Reads are kept apart from the lifecycle
+// log in ServerLog, which only holds events that change state, and are
+// pruned after a retention period.
+type AccessLog struct {
+	ID        string    `gorm:"primaryKey;type:uuid" json:"id"`
+	Method    string    `json:"method"`
+	Route     string    `gorm:"index" json:"route"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	ServerID  string    `gorm:"index" json:"serverId,omitempty"`
+	ProjectID string    `gorm:"index" json:"projectId,omitempty"`
+	Actor     string    `gorm:"index" json:"actor"`
+	SourceIP  string    `json:"sourceIp,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	LatencyMs int64     `json:"latencyMs"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
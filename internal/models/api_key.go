@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIKey is a revocable credential for the API. Only a hash of the key is
+// stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         string     `gorm:"primaryKey;type:uuid" json:"id"`
+	Name       string     `gorm:"uniqueIndex" json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `gorm:"uniqueIndex" json:"-"`
+	Scopes     []string   `gorm:"serializer:json" json:"scopes"`
+	Roles      []string   `gorm:"serializer:json" json:"roles"`
+	ProjectID  string     `gorm:"index" json:"projectId,omitempty"`
+	CreatedBy  string     `json:"createdBy"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
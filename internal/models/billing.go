@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BillingPeriod is a span of time during which a server was billed at a
+// constant hourly rate. The open period of a live server has no EndedAt.
+type BillingPeriod struct {
+	ID        string     `gorm:"primaryKey;type:uuid" json:"id"`
+	ServerID  string     `gorm:"index" json:"serverId"`
+	ProjectID string     `gorm:"index" json:"projectId,omitempty"`
+	Type      string     `json:"type"`
+	Status    string     `json:"status"`
+	Rate      float64    `json:"rate"`
+	StartedAt time.Time  `gorm:"index" json:"startedAt"`
+	EndedAt   *time.Time `gorm:"index" json:"endedAt,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type DNSZone struct {
+	ID        string      `gorm:"primaryKey;type:uuid" json:"id"`
+	ProjectID string      `gorm:"index" json:"projectId,omitempty"`
+	Name      string      `gorm:"uniqueIndex" json:"name"`
+	Records   []DNSRecord `gorm:"foreignKey:ZoneID" json:"records,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+type DNSRecord struct {
+	ID     string `gorm:"primaryKey;type:uuid" json:"id"`
+	ZoneID string `gorm:"index" json:"zoneId"`
+	Name   string `gorm:"index" json:"name"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	TTL    int    `json:"ttl"`
+	// Set on records managed automatically for a server's hostname.
+	ServerID  string    `gorm:"index" json:"serverId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type KeyPair struct {
+	ID          string    `gorm:"primaryKey;type:uuid" json:"id"`
+	ProjectID   string    `gorm:"uniqueIndex:idx_key_pairs_project_name" json:"projectId,omitempty"`
+	Name        string    `gorm:"uniqueIndex:idx_key_pairs_project_name" json:"name"`
+	Type        string    `json:"type"`
+	PublicKey   string    `json:"publicKey"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ServerKey is the copy of a key pair injected into a server at creation.
+// It is kept even if the key pair is deleted later, as on a real instance.
+type ServerKey struct {
+	ID          string    `gorm:"primaryKey;type:uuid" json:"id"`
+	ServerID    string    `gorm:"index" json:"serverId"`
+	KeyName     string    `json:"keyName"`
+	Type        string    `json:"type"`
+	PublicKey   string    `json:"publicKey"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoadBalancer struct {
+	ID        string         `gorm:"primaryKey;type:uuid" json:"id"`
+	ProjectID string         `gorm:"index" json:"projectId,omitempty"`
+	Name      string         `gorm:"index" json:"name"`
+	Region    string         `json:"region"`
+	Listeners []Listener     `gorm:"foreignKey:LoadBalancerID" json:"listeners"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+}
+
+type Listener struct {
+	ID             string    `gorm:"primaryKey;type:uuid" json:"id"`
+	LoadBalancerID string    `gorm:"index" json:"loadBalancerId"`
+	Protocol       string    `json:"protocol"`
+	Port           int       `json:"port"`
+	TargetGroupID  string    `gorm:"index" json:"targetGroupId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type TargetGroup struct {
+	ID        string    `gorm:"primaryKey;type:uuid" json:"id"`
+	ProjectID string    `gorm:"index" json:"projectId,omitempty"`
+	Name      string    `gorm:"index" json:"name"`
+	Protocol  string    `json:"protocol"`
+	Port      int       `json:"port"`
+	Targets   []Target  `gorm:"foreignKey:TargetGroupID" json:"targets"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type Target struct {
+	ID               string    `gorm:"primaryKey;type:uuid" json:"id"`
+	TargetGroupID    string    `gorm:"index" json:"targetGroupId"`
+	ServerID         string    `gorm:"index" json:"serverId"`
+	Port             int       `json:"port"`
+	Health           string    `json:"health"`
+	HealthReason     string    `json:"healthReason"`
+	SimulatedFailure bool      `json:"simulatedFailure"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// ArchivedServerLog stands in for an audit log entry that retention moved to
+// an archive file. It keeps the entry's place in the hash chain, so the chain
+// can still be verified without the archive.
+type ArchivedServerLog struct {
+	Sequence    int64     `gorm:"primaryKey;autoIncrement:false" json:"sequence"`
+	ServerLogID string    `gorm:"index" json:"id"`
+	ServerID    string    `gorm:"index" json:"serverId"`
+	EventType   string    `json:"eventType"`
+	PrevHash    string    `json:"prevHash"`
+	Hash        string    `json:"hash"`
+	Archive     string    `gorm:"index" json:"archive"`
+	ArchivedAt  time.Time `json:"archivedAt"`
+}
+
+// RestoredServerLog is an archived entry imported back for investigation. It
+// is kept apart from the live log, outside the chain and retention.
+type RestoredServerLog struct {
+	ID         string            `gorm:"primaryKey;type:uuid" json:"id"`
+	Sequence   int64             `gorm:"index" json:"sequence"`
+	ServerID   string            `gorm:"index" json:"serverId"`
+	ProjectID  string            `gorm:"index" json:"projectId,omitempty"`
+	EventType  string            `json:"eventType"`
+	Message    string            `json:"message"`
+	OldStatus  string            `json:"oldStatus"`
+	NewStatus  string            `json:"newStatus"`
+	Actor      string            `json:"actor"`
+	SourceIP   string            `json:"sourceIp,omitempty"`
+	RequestID  string            `json:"requestId,omitempty"`
+	BodyDigest string            `json:"bodyDigest,omitempty"`
+	Changes    map[string]string `gorm:"serializer:json" json:"changes,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	PrevHash   string            `json:"prevHash"`
+	Hash       string            `json:"hash"`
+	Archive    string            `gorm:"index" json:"archive"`
+	RestoredAt time.Time         `json:"restoredAt"`
+}
+
+// Restored copies an archived entry for import.
+func (l ServerLog) Restored(archive string, at time.Time) RestoredServerLog {
+	return RestoredServerLog{
+		ID: l.ID, Sequence: l.Sequence, ServerID: l.ServerID, ProjectID: l.ProjectID,
+		EventType: l.EventType, Message: l.Message, OldStatus: l.OldStatus, NewStatus: l.NewStatus,
+		Actor: l.Actor, SourceIP: l.SourceIP, RequestID: l.RequestID, BodyDigest: l.BodyDigest,
+		Changes: l.Changes, CreatedAt: l.CreatedAt, PrevHash: l.PrevHash, Hash: l.Hash,
+		Archive: archive, RestoredAt: at,
+	}
+}
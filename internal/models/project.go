@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization groups the projects of one team or customer.
+type Organization struct {
+	ID        string    `gorm:"primaryKey;type:uuid" json:"id"`
+	Name      string    `gorm:"uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Project owns servers, their logs and their billing. Credentials bound to a
+// project only see what it owns.
+type Project struct {
+	ID             string         `gorm:"primaryKey;type:uuid" json:"id"`
+	OrganizationID string         `gorm:"index" json:"organizationId"`
+	Name           string         `gorm:"index" json:"name"`
+	Description    string         `json:"description,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+}
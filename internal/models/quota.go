@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ProjectQuota caps what a project can run. A nil or missing limit means no
+// limit.
+type ProjectQuota struct {
+	ProjectID         string         `gorm:"primaryKey;type:uuid" json:"projectId"`
+	MaxServers        *int           `json:"maxServers,omitempty"`
+	MaxServersPerType map[string]int `gorm:"serializer:json" json:"maxServersPerType,omitempty"`
+	MaxVCPUsPerRegion *int           `json:"maxVcpusPerRegion,omitempty"`
+	MaxMonthlySpend   *float64       `json:"maxMonthlySpend,omitempty"`
+	UpdatedAt         time.Time      `json:"updatedAt"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Schedule runs an action against a server, either once at RunAt or
+// repeatedly according to Cron, evaluated in TimeZone.
+type Schedule struct {
+	ID              string     `gorm:"primaryKey;type:uuid" json:"id"`
+	ServerID        string     `gorm:"index" json:"serverId"`
+	Action          string     `json:"action"`
+	RunAt           *time.Time `json:"runAt,omitempty"`
+	Cron            string     `json:"cron,omitempty"`
+	TimeZone        string     `json:"timeZone"`
+	MissedRunPolicy string     `json:"missedRunPolicy"`
+	// Confirmed records that the termination of a production server was
+	// confirmed when the schedule was created.
+	Confirmed   bool           `json:"confirmed,omitempty"`
+	Enabled     bool           `gorm:"index" json:"enabled"`
+	NextRunAt   *time.Time     `gorm:"index" json:"nextRunAt,omitempty"`
+	LastRunAt   *time.Time     `json:"lastRunAt,omitempty"`
+	LastOutcome string         `json:"lastOutcome,omitempty"`
+	LastMessage string         `json:"lastMessage,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SecurityGroup struct {
+	ID          string              `gorm:"primaryKey;type:uuid" json:"id"`
+	ProjectID   string              `gorm:"index" json:"projectId,omitempty"`
+	Name        string              `gorm:"index" json:"name"`
+	Description string              `json:"description"`
+	Rules       []SecurityGroupRule `gorm:"foreignKey:SecurityGroupID" json:"rules"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt      `gorm:"index" json:"deletedAt,omitempty"`
+}
+
+type SecurityGroupRule struct {
+	ID              string    `gorm:"primaryKey;type:uuid" json:"id"`
+	SecurityGroupID string    `gorm:"index" json:"securityGroupId"`
+	Direction       string    `json:"direction"`
+	Protocol        string    `json:"protocol"`
+	FromPort        int       `json:"fromPort"`
+	ToPort          int       `json:"toPort"`
+	CIDR            string    `json:"cidr,omitempty"`
+	SourceGroupID   string    `json:"sourceGroupId,omitempty"`
+	Description     string    `json:"description,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type ServerSecurityGroup struct {
+	ServerID        string    `gorm:"primaryKey" json:"serverId"`
+	SecurityGroupID string    `gorm:"primaryKey;index" json:"securityGroupId"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
@@ -7,13 +7,29 @@ import (
 )
 
 type Server struct {
-	ID           string         `gorm:"primaryKey;type:uuid" json:"id"`
-	ServerNumber int64          `json:"serverNumber" gorm:"autoIncrement"`
-	BillingRate  float64        `json:"billingRate"`
-	Status       string         `json:"status"`
-	Region       string         `json:"region"`
-	Type         string         `json:"type"`
-	CreatedAt    time.Time      `json:"createdAt"`
-	UpdatedAt    time.Time      `json:"updatedAt"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+	ID                    string            `gorm:"primaryKey;type:uuid" json:"id"`
+	ProjectID             string            `gorm:"index" json:"projectId,omitempty"`
+	ServerNumber          int64             `json:"serverNumber" gorm:"autoIncrement"`
+	BillingRate           float64           `json:"billingRate"`
+	Status                string            `json:"status"`
+	Region                string            `json:"region"`
+	Type                  string            `json:"type"`
+	ResizingTo            string            `json:"resizingTo,omitempty"`
+	Image                 string            `json:"image,omitempty"`
+	Name                  string            `gorm:"index" json:"name,omitempty"`
+	Hostname              string            `gorm:"index" json:"hostname,omitempty"`
+	Description           string            `json:"description,omitempty"`
+	Tags                  map[string]string `gorm:"serializer:json" json:"tags,omitempty"`
+	UserData              string            `gorm:"type:text" json:"-"`
+	PrivateIP             string            `gorm:"index" json:"privateIp"`
+	PublicIP              string            `gorm:"index" json:"publicIp"`
+	MetadataTokenHash     string            `gorm:"index" json:"-"`
+	ExpiresAt             *time.Time        `gorm:"index" json:"expiresAt,omitempty"`
+	ExpiryWarnedAt        *time.Time        `json:"-"`
+	ExpiryDeniedAt        *time.Time        `json:"-"`
+	TerminationReason     string            `json:"terminationReason,omitempty"`
+	TerminationProtection bool              `json:"terminationProtection"`
+	CreatedAt             time.Time         `json:"createdAt"`
+	UpdatedAt             time.Time         `json:"updatedAt"`
+	DeletedAt             gorm.DeletedAt    `gorm:"index" json:"deletedAt,omitempty"`
 }
@@ -1,14 +1,51 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// ErrServerLogImmutable is returned when something tries to change or remove
+// a log entry.
+var ErrServerLogImmutable = errors.New("server log entries are append-only")
+
+// ServerLog is an entry of the append-only audit log. Entries form a hash
+// chain: Hash covers every field of the entry, including the Hash of the
+// entry before it (PrevHash), so changing, removing or reordering entries
+// breaks the chain. Changes holds the server fields an event set, so a
+// server's state can be rebuilt from its events.
 type ServerLog struct {
-	ID        string    `gorm:"primaryKey;type:uuid" json:"id"`
-	ServerID  string    `gorm:"index" json:"serverId"`
-	EventType string    `json:"eventType"`
-	Message   string    `json:"message"`
-	OldStatus string    `json:"oldStatus"`
-	NewStatus string    `json:"newStatus"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID         string            `gorm:"primaryKey;type:uuid" json:"id"`
+	Sequence   int64             `gorm:"uniqueIndex" json:"sequence"`
+	ServerID   string            `gorm:"index" json:"serverId"`
+	ProjectID  string            `gorm:"index" json:"projectId,omitempty"`
+	EventType  string            `json:"eventType"`
+	Message    string            `json:"message"`
+	OldStatus  string            `json:"oldStatus"`
+	NewStatus  string            `json:"newStatus"`
+	Actor      string            `gorm:"index" json:"actor"`
+	SourceIP   string            `json:"sourceIp,omitempty"`
+	RequestID  string            `gorm:"index" json:"requestId,omitempty"`
+	BodyDigest string            `json:"bodyDigest,omitempty"`
+	Changes    map[string]string `gorm:"serializer:json" json:"changes,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	PrevHash   string            `json:"prevHash"`
+	Hash       string            `gorm:"index" json:"hash"`
+}
+
+func (ServerLog) BeforeUpdate(*gorm.DB) error {
+	return ErrServerLogImmutable
+}
+
+func (ServerLog) BeforeDelete(*gorm.DB) error {
+	return ErrServerLogImmutable
+}
+
+// ServerLogToken indexes the words of log messages for search on databases
+// without full-text search. PostgreSQL uses a tsvector index instead.
+type ServerLogToken struct {
+	Token       string `gorm:"primaryKey"`
+	ServerLogID string `gorm:"primaryKey;index"`
 }
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+)
+
+// faults holds the errors to return in place of calling a method, by method
+// name.
+type faults struct {
+	mu   sync.Mutex
+	errs map[string]error
+	// skips is how many more calls of a failing method to let through.
+	skips map[string]int
+}
+
+// Fail makes every later call of the named method, e.g. "Get", return err.
+// A nil err makes the method work again.
+func (f *faults) Fail(method string, err error) {
+	f.FailAfter(method, 0, err)
+}
+
+// FailAfter is Fail, except that the next calls calls of the method still
+// work.
+func (f *faults) FailAfter(method string, calls int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = map[string]error{}
+		f.skips = map[string]int{}
+	}
+	if err == nil {
+		delete(f.errs, method)
+		delete(f.skips, method)
+		return
+	}
+	f.errs[method] = err
+	f.skips[method] = calls
+}
+
+func (f *faults) fault(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.skips[method] > 0 {
+		f.skips[method]--
+		return nil
+	}
+	return f.errs[method]
+}
+
+// FaultyServerRepository wraps a ServerRepository and fails the methods it is
+// told to.
+type FaultyServerRepository struct {
+	*faults
+	next ServerRepository
+}
+
+// NewFaultyServerRepository wraps next; until Fail is called every method
+// is passed through.
+func NewFaultyServerRepository(next ServerRepository) *FaultyServerRepository {
+	return &FaultyServerRepository{faults: &faults{}, next: next}
+}
+
+func (r *FaultyServerRepository) Get(id, projectId string) (*models.Server, error) {
+	if err := r.fault("Get"); err != nil {
+		return nil, err
+	}
+	return r.next.Get(id, projectId)
+}
+
+func (r *FaultyServerRepository) FindByName(name, region, projectId string, limit int) ([]models.Server, error) {
+	if err := r.fault("FindByName"); err != nil {
+		return nil, err
+	}
+	return r.next.FindByName(name, region, projectId, limit)
+}
+
+func (r *FaultyServerRepository) List(projectId string) ([]models.Server, error) {
+	if err := r.fault("List"); err != nil {
+		return nil, err
+	}
+	return r.next.List(projectId)
+}
+
+func (r *FaultyServerRepository) Create(server *models.Server) error {
+	if err := r.fault("Create"); err != nil {
+		return err
+	}
+	return r.next.Create(server)
+}
+
+func (r *FaultyServerRepository) FindBySelector(selector service.Selector, region, projectId string) ([]models.Server, error) {
+	if err := r.fault("FindBySelector"); err != nil {
+		return nil, err
+	}
+	return r.next.FindBySelector(selector, region, projectId)
+}
+
+func (r *FaultyServerRepository) FindByMetadataToken(hash string) (*models.Server, error) {
+	if err := r.fault("FindByMetadataToken"); err != nil {
+		return nil, err
+	}
+	return r.next.FindByMetadataToken(hash)
+}
+
+func (r *FaultyServerRepository) ListByIDs(ids []string) ([]models.Server, error) {
+	if err := r.fault("ListByIDs"); err != nil {
+		return nil, err
+	}
+	return r.next.ListByIDs(ids)
+}
+
+func (r *FaultyServerRepository) FindExpiring(from, to time.Time, limit int) ([]models.Server, error) {
+	if err := r.fault("FindExpiring"); err != nil {
+		return nil, err
+	}
+	return r.next.FindExpiring(from, to, limit)
+}
+
+func (r *FaultyServerRepository) ClaimExpiryWarning(id string, at time.Time) (bool, error) {
+	if err := r.fault("ClaimExpiryWarning"); err != nil {
+		return false, err
+	}
+	return r.next.ClaimExpiryWarning(id, at)
+}
+
+func (r *FaultyServerRepository) FindExpired(now time.Time, limit int) ([]models.Server, error) {
+	if err := r.fault("FindExpired"); err != nil {
+		return nil, err
+	}
+	return r.next.FindExpired(now, limit)
+}
+
+func (r *FaultyServerRepository) FindExpiredProtected(now time.Time, limit int) ([]models.Server, error) {
+	if err := r.fault("FindExpiredProtected"); err != nil {
+		return nil, err
+	}
+	return r.next.FindExpiredProtected(now, limit)
+}
+
+func (r *FaultyServerRepository) ClaimExpiryDenial(id string, at time.Time) (bool, error) {
+	if err := r.fault("ClaimExpiryDenial"); err != nil {
+		return false, err
+	}
+	return r.next.ClaimExpiryDenial(id, at)
+}
+
+func (r *FaultyServerRepository) InBatches(projectId string, size int, fn func([]models.Server) error) error {
+	if err := r.fault("InBatches"); err != nil {
+		return err
+	}
+	return r.next.InBatches(projectId, size, fn)
+}
+
+func (r *FaultyServerRepository) AllocateAddresses(server *models.Server) error {
+	if err := r.fault("AllocateAddresses"); err != nil {
+		return err
+	}
+	return r.next.AllocateAddresses(server)
+}
+
+func (r *FaultyServerRepository) Save(server *models.Server) error {
+	if err := r.fault("Save"); err != nil {
+		return err
+	}
+	return r.next.Save(server)
+}
+
+func (r *FaultyServerRepository) Update(server *models.Server, columns ...string) error {
+	if err := r.fault("Update"); err != nil {
+		return err
+	}
+	return r.next.Update(server, columns...)
+}
+
+func (r *FaultyServerRepository) UpdateStatus(server *models.Server, from string, columns ...string) (bool, error) {
+	if err := r.fault("UpdateStatus"); err != nil {
+		return false, err
+	}
+	return r.next.UpdateStatus(server, from, columns...)
+}
+
+func (r *FaultyServerRepository) AddKey(key *models.ServerKey) error {
+	if err := r.fault("AddKey"); err != nil {
+		return err
+	}
+	return r.next.AddKey(key)
+}
+
+func (r *FaultyServerRepository) Keys(serverId string) ([]models.ServerKey, error) {
+	if err := r.fault("Keys"); err != nil {
+		return nil, err
+	}
+	return r.next.Keys(serverId)
+}
+
+// WithTx keeps the faults for the repository working within tx.
+func (r *FaultyServerRepository) WithTx(tx *gorm.DB) ServerRepository {
+	return &FaultyServerRepository{faults: r.faults, next: r.next.WithTx(tx)}
+}
+
+// FaultyLogRepository wraps a LogRepository and fails the methods it is told
+// to.
+type FaultyLogRepository struct {
+	*faults
+	next LogRepository
+}
+
+// NewFaultyLogRepository wraps next; until Fail is called every method is
+// passed through.
+func NewFaultyLogRepository(next LogRepository) *FaultyLogRepository {
+	return &FaultyLogRepository{faults: &faults{}, next: next}
+}
+
+func (r *FaultyLogRepository) Append(entry *models.ServerLog) error {
+	if err := r.fault("Append"); err != nil {
+		return err
+	}
+	return r.next.Append(entry)
+}
+
+func (r *FaultyLogRepository) ListByServer(serverId, projectId string, limit int) ([]models.ServerLog, error) {
+	if err := r.fault("ListByServer"); err != nil {
+		return nil, err
+	}
+	return r.next.ListByServer(serverId, projectId, limit)
+}
+
+func (r *FaultyLogRepository) ListRestored(serverId, projectId string) ([]models.RestoredServerLog, error) {
+	if err := r.fault("ListRestored"); err != nil {
+		return nil, err
+	}
+	return r.next.ListRestored(serverId, projectId)
+}
+
+func (r *FaultyLogRepository) Events(serverId string, until *time.Time) ([]models.ServerLog, error) {
+	if err := r.fault("Events"); err != nil {
+		return nil, err
+	}
+	return r.next.Events(serverId, until)
+}
+
+func (r *FaultyLogRepository) ArchivedCount(serverId string) (int64, error) {
+	if err := r.fault("ArchivedCount"); err != nil {
+		return 0, err
+	}
+	return r.next.ArchivedCount(serverId)
+}
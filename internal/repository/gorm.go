@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+)
+
+// auditLockKey identifies the advisory lock guarding the end of the chain.
+const auditLockKey = 7203145
+
+type gormServerRepository struct {
+	db *gorm.DB
+}
+
+// NewGormServerRepository stores servers in the database.
+func NewGormServerRepository(db *gorm.DB) ServerRepository {
+	return &gormServerRepository{db: db}
+}
+
+func (r *gormServerRepository) scoped(projectId string) *gorm.DB {
+	if projectId == "" {
+		return r.db
+	}
+	return r.db.Where("project_id = ?", projectId)
+}
+
+func (r *gormServerRepository) Get(id, projectId string) (*models.Server, error) {
+	var server models.Server
+	if err := r.scoped(projectId).First(&server, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &server, nil
+}
+
+func (r *gormServerRepository) FindByName(name, region, projectId string, limit int) ([]models.Server, error) {
+	query := r.scoped(projectId).Where("name = ? AND status <> ?", name, service.StatusTerminated)
+	if region != "" {
+		query = query.Where("region = ?", region)
+	}
+	var servers []models.Server
+	err := query.Limit(limit).Find(&servers).Error
+	return servers, err
+}
+
+func (r *gormServerRepository) List(projectId string) ([]models.Server, error) {
+	var servers []models.Server
+	err := r.scoped(projectId).Find(&servers).Error
+	return servers, err
+}
+
+func (r *gormServerRepository) FindBySelector(selector service.Selector, region, projectId string) ([]models.Server, error) {
+	query := r.scoped(projectId).Where("status <> ?", service.StatusTerminated).Order("created_at")
+	if region != "" {
+		query = query.Where("region = ?", region)
+	}
+	var servers []models.Server
+	if err := query.Find(&servers).Error; err != nil {
+		return nil, err
+	}
+	return matchSelector(servers, selector), nil
+}
+
+func (r *gormServerRepository) FindByMetadataToken(hash string) (*models.Server, error) {
+	var server models.Server
+	if err := r.db.First(&server, "metadata_token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &server, nil
+}
+
+func (r *gormServerRepository) ListByIDs(ids []string) ([]models.Server, error) {
+	var servers []models.Server
+	err := r.db.Unscoped().Where("id IN ?", ids).Find(&servers).Error
+	return servers, err
+}
+
+func (r *gormServerRepository) FindExpiring(from, to time.Time, limit int) ([]models.Server, error) {
+	var servers []models.Server
+	err := r.db.Where("status <> ? AND expires_at > ? AND expires_at <= ? AND expiry_warned_at IS NULL",
+		service.StatusTerminated, from, to).Limit(limit).Find(&servers).Error
+	return servers, err
+}
+
+func (r *gormServerRepository) ClaimExpiryWarning(id string, at time.Time) (bool, error) {
+	claim := r.db.Model(&models.Server{}).
+		Where("id = ? AND expiry_warned_at IS NULL", id).
+		Update("expiry_warned_at", at)
+	return claim.RowsAffected > 0, claim.Error
+}
+
+func (r *gormServerRepository) FindExpired(now time.Time, limit int) ([]models.Server, error) {
+	var servers []models.Server
+	err := r.db.Where("status <> ? AND expires_at <= ? AND termination_protection = ?", service.StatusTerminated, now, false).
+		Order("expires_at").Limit(limit).Find(&servers).Error
+	return servers, err
+}
+
+func (r *gormServerRepository) FindExpiredProtected(now time.Time, limit int) ([]models.Server, error) {
+	var servers []models.Server
+	err := r.db.Where("status <> ? AND expires_at <= ? AND termination_protection = ? AND expiry_denied_at IS NULL", service.StatusTerminated, now, true).
+		Order("expires_at").Limit(limit).Find(&servers).Error
+	return servers, err
+}
+
+func (r *gormServerRepository) ClaimExpiryDenial(id string, at time.Time) (bool, error) {
+	claim := r.db.Model(&models.Server{}).
+		Where("id = ? AND expiry_denied_at IS NULL", id).
+		Update("expiry_denied_at", at)
+	return claim.RowsAffected > 0, claim.Error
+}
+
+func (r *gormServerRepository) InBatches(projectId string, size int, fn func([]models.Server) error) error {
+	var batch []models.Server
+	return r.scoped(projectId).Unscoped().Order("id").FindInBatches(&batch, size, func(*gorm.DB, int) error {
+		return fn(batch)
+	}).Error
+}
+
+func (r *gormServerRepository) AllocateAddresses(server *models.Server) error {
+	return allocateAddresses(server, func(column, ip string) (bool, error) {
+		var count int64
+		err := r.db.Model(&models.Server{}).Where(column+" = ?", ip).Count(&count).Error
+		return count > 0, err
+	})
+}
+
+func (r *gormServerRepository) Create(server *models.Server) error {
+	return r.db.Create(server).Error
+}
+
+func (r *gormServerRepository) Save(server *models.Server) error {
+	return r.db.Save(server).Error
+}
+
+func (r *gormServerRepository) Update(server *models.Server, columns ...string) error {
+	return r.db.Model(server).Select(columns).Updates(server).Error
+}
+
+func (r *gormServerRepository) UpdateStatus(server *models.Server, from string, columns ...string) (bool, error) {
+	result := r.db.Model(server).Where("status = ?", from).Select(columns).Updates(server)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormServerRepository) AddKey(key *models.ServerKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *gormServerRepository) Keys(serverId string) ([]models.ServerKey, error) {
+	var keys []models.ServerKey
+	err := r.db.Where("server_id = ?", serverId).Order("created_at").Find(&keys).Error
+	return keys, err
+}
+
+func (r *gormServerRepository) WithTx(tx *gorm.DB) ServerRepository {
+	return &gormServerRepository{db: tx}
+}
+
+type gormLogRepository struct {
+	db *gorm.DB
+	// appendMu serialises the repository's appends; the advisory lock does the
+	// same across processes sharing a PostgreSQL database. Other databases
+	// have no such lock, so a process should append through one repository.
+	appendMu sync.Mutex
+}
+
+// NewGormLogRepository stores the lifecycle log in the database.
+func NewGormLogRepository(db *gorm.DB) LogRepository {
+	return &gormLogRepository{db: db}
+}
+
+func (r *gormLogRepository) scoped(projectId string) *gorm.DB {
+	if projectId == "" {
+		return r.db
+	}
+	return r.db.Where("project_id = ?", projectId)
+}
+
+func (r *gormLogRepository) Append(entry *models.ServerLog) error {
+	r.appendMu.Lock()
+	defer r.appendMu.Unlock()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLockKey).Error; err != nil {
+				return err
+			}
+		}
+
+		// Entries belong to the server's project so they are scoped with it.
+		if entry.ServerID != "" {
+			var projectIDs []string
+			tx.Model(&models.Server{}).Unscoped().Where("id = ?", entry.ServerID).Limit(1).Pluck("project_id", &projectIDs)
+			if len(projectIDs) > 0 {
+				entry.ProjectID = projectIDs[0]
+			}
+		}
+
+		// The end of the chain may have been archived by log retention.
+		var last []models.ServerLog
+		if err := tx.Where("sequence > 0").Order("sequence DESC").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		var lastArchived []models.ArchivedServerLog
+		if err := tx.Order("sequence DESC").Limit(1).Find(&lastArchived).Error; err != nil {
+			return err
+		}
+		entry.Sequence = 1
+		if len(last) > 0 {
+			entry.Sequence = last[0].Sequence + 1
+			entry.PrevHash = last[0].Hash
+		}
+		if len(lastArchived) > 0 && lastArchived[0].Sequence >= entry.Sequence {
+			entry.Sequence = lastArchived[0].Sequence + 1
+			entry.PrevHash = lastArchived[0].Hash
+		}
+		entry.CreatedAt = service.AuditTimestamp(time.Now())
+		entry.Hash = service.AuditHash(entry)
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return indexEntry(tx, entry)
+	})
+}
+
+// indexEntry adds the words of the entry's message to the search index, on
+// databases where PostgreSQL's full-text index is not available.
+func indexEntry(tx *gorm.DB, entry *models.ServerLog) error {
+	if tx.Dialector.Name() == "postgres" {
+		return nil
+	}
+	tokens := service.ServerLogTokens(entry)
+	if len(tokens) == 0 {
+		return nil
+	}
+	return tx.Create(&tokens).Error
+}
+
+func (r *gormLogRepository) ListByServer(serverId, projectId string, limit int) ([]models.ServerLog, error) {
+	var logs []models.ServerLog
+	err := r.scoped(projectId).Where("server_id = ?", serverId).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
+
+func (r *gormLogRepository) ListRestored(serverId, projectId string) ([]models.RestoredServerLog, error) {
+	var restored []models.RestoredServerLog
+	err := r.scoped(projectId).Where("server_id = ?", serverId).
+		Order("created_at DESC").Find(&restored).Error
+	return restored, err
+}
+
+func (r *gormLogRepository) Events(serverId string, until *time.Time) ([]models.ServerLog, error) {
+	query := r.db.Where("server_id = ?", serverId)
+	if until != nil {
+		query = query.Where("created_at <= ?", until.UTC())
+	}
+	var events []models.ServerLog
+	err := query.Order("created_at").Order("sequence").Find(&events).Error
+	return events, err
+}
+
+func (r *gormLogRepository) ArchivedCount(serverId string) (int64, error) {
+	var archived int64
+	err := r.db.Model(&models.ArchivedServerLog{}).Where("server_id = ?", serverId).Count(&archived).Error
+	return archived, err
+}
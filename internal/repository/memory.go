@@ -0,0 +1,449 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/db"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// serverSchema maps the columns named in Update to the fields of a server.
+var serverSchema, _ = schema.Parse(&models.Server{}, &sync.Map{}, schema.NamingStrategy{})
+
+// MemoryServerRepository keeps servers in memory. It is safe for concurrent
+// use and hands out copies, so callers never share its records. Like the
+// database it numbers new servers, refuses duplicate IDs and keeps names and
+// hostnames unique among the live servers of a project and region. Unlike
+// the database it has no transactions: WithTx returns the store itself, and
+// its writes stay when the transaction they were made in rolls back.
+type MemoryServerRepository struct {
+	mu         sync.RWMutex
+	servers    map[string]models.Server
+	keys       map[string][]models.ServerKey
+	lastNumber int64
+}
+
+// NewMemoryServerRepository returns an empty in-memory server store.
+func NewMemoryServerRepository() *MemoryServerRepository {
+	return &MemoryServerRepository{
+		servers: map[string]models.Server{},
+		keys:    map[string][]models.ServerKey{},
+	}
+}
+
+func (r *MemoryServerRepository) Get(id, projectId string) (*models.Server, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	server, ok := r.servers[id]
+	if !ok || (projectId != "" && server.ProjectID != projectId) {
+		return nil, ErrNotFound
+	}
+	return copyServer(server), nil
+}
+
+func (r *MemoryServerRepository) FindByName(name, region, projectId string, limit int) ([]models.Server, error) {
+	var servers []models.Server
+	for _, server := range r.sorted(projectId) {
+		if server.Name != name || server.Status == service.StatusTerminated || (region != "" && server.Region != region) {
+			continue
+		}
+		if len(servers) == limit {
+			break
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+func (r *MemoryServerRepository) List(projectId string) ([]models.Server, error) {
+	return r.sorted(projectId), nil
+}
+
+func (r *MemoryServerRepository) FindBySelector(selector service.Selector, region, projectId string) ([]models.Server, error) {
+	var servers []models.Server
+	for _, server := range r.sorted(projectId) {
+		if server.Status != service.StatusTerminated && (region == "" || server.Region == region) {
+			servers = append(servers, server)
+		}
+	}
+	return matchSelector(servers, selector), nil
+}
+
+func (r *MemoryServerRepository) FindByMetadataToken(hash string) (*models.Server, error) {
+	for _, server := range r.sorted("") {
+		if server.MetadataTokenHash == hash {
+			return &server, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryServerRepository) ListByIDs(ids []string) ([]models.Server, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var servers []models.Server
+	for _, id := range ids {
+		if server, ok := r.servers[id]; ok {
+			servers = append(servers, *copyServer(server))
+		}
+	}
+	return servers, nil
+}
+
+func (r *MemoryServerRepository) FindExpiring(from, to time.Time, limit int) ([]models.Server, error) {
+	var servers []models.Server
+	for _, server := range r.sorted("") {
+		if len(servers) == limit {
+			break
+		}
+		if server.Status != service.StatusTerminated && server.ExpiresAt != nil && server.ExpiryWarnedAt == nil &&
+			server.ExpiresAt.After(from) && !server.ExpiresAt.After(to) {
+			servers = append(servers, server)
+		}
+	}
+	return servers, nil
+}
+
+func (r *MemoryServerRepository) ClaimExpiryWarning(id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	server, ok := r.servers[id]
+	if !ok || server.ExpiryWarnedAt != nil {
+		return false, nil
+	}
+	server.ExpiryWarnedAt = &at
+	server.UpdatedAt = time.Now()
+	r.servers[id] = server
+	return true, nil
+}
+
+func (r *MemoryServerRepository) FindExpired(now time.Time, limit int) ([]models.Server, error) {
+	var servers []models.Server
+	for _, server := range r.sorted("") {
+		if server.Status != service.StatusTerminated && !server.TerminationProtection &&
+			server.ExpiresAt != nil && !server.ExpiresAt.After(now) {
+			servers = append(servers, server)
+		}
+	}
+	sort.SliceStable(servers, func(i, j int) bool { return servers[i].ExpiresAt.Before(*servers[j].ExpiresAt) })
+	if len(servers) > limit {
+		servers = servers[:limit]
+	}
+	return servers, nil
+}
+
+func (r *MemoryServerRepository) FindExpiredProtected(now time.Time, limit int) ([]models.Server, error) {
+	var servers []models.Server
+	for _, server := range r.sorted("") {
+		if server.Status != service.StatusTerminated && server.TerminationProtection && server.ExpiryDeniedAt == nil &&
+			server.ExpiresAt != nil && !server.ExpiresAt.After(now) {
+			servers = append(servers, server)
+		}
+	}
+	sort.SliceStable(servers, func(i, j int) bool { return servers[i].ExpiresAt.Before(*servers[j].ExpiresAt) })
+	if len(servers) > limit {
+		servers = servers[:limit]
+	}
+	return servers, nil
+}
+
+func (r *MemoryServerRepository) ClaimExpiryDenial(id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	server, ok := r.servers[id]
+	if !ok || server.ExpiryDeniedAt != nil {
+		return false, nil
+	}
+	server.ExpiryDeniedAt = &at
+	server.UpdatedAt = time.Now()
+	r.servers[id] = server
+	return true, nil
+}
+
+func (r *MemoryServerRepository) InBatches(projectId string, size int, fn func([]models.Server) error) error {
+	servers := r.sorted(projectId)
+	sort.Slice(servers, func(i, j int) bool { return servers[i].ID < servers[j].ID })
+	for start := 0; start < len(servers); start += size {
+		if err := fn(servers[start:min(start+size, len(servers))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryServerRepository) AllocateAddresses(server *models.Server) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return allocateAddresses(server, func(column, ip string) (bool, error) {
+		for _, other := range r.servers {
+			if (column == "private_ip" && other.PrivateIP == ip) || (column == "public_ip" && other.PublicIP == ip) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func (r *MemoryServerRepository) Create(server *models.Server) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.servers[server.ID]; ok {
+		return duplicateKey(db.ServerIDIndex)
+	}
+	if err := r.checkUnique(server); err != nil {
+		return err
+	}
+	if server.ServerNumber == 0 {
+		server.ServerNumber = r.lastNumber + 1
+	}
+	r.lastNumber = max(r.lastNumber, server.ServerNumber)
+	if server.CreatedAt.IsZero() {
+		server.CreatedAt = time.Now()
+		server.UpdatedAt = server.CreatedAt
+	}
+	r.servers[server.ID] = *copyServer(*server)
+	return nil
+}
+
+func (r *MemoryServerRepository) Save(server *models.Server) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.servers[server.ID]; !ok {
+		return ErrNotFound
+	}
+	if err := r.checkUnique(server); err != nil {
+		return err
+	}
+	server.UpdatedAt = time.Now()
+	r.servers[server.ID] = *copyServer(*server)
+	return nil
+}
+
+func (r *MemoryServerRepository) Update(server *models.Server, columns ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.servers[server.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	return r.update(stored, server, columns)
+}
+
+func (r *MemoryServerRepository) UpdateStatus(server *models.Server, from string, columns ...string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.servers[server.ID]
+	if !ok || stored.Status != from {
+		return false, nil
+	}
+	if err := r.update(stored, server, columns); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// update copies the named columns of server onto stored and saves it. The
+// caller holds the lock.
+func (r *MemoryServerRepository) update(stored models.Server, server *models.Server, columns []string) error {
+	from, to := reflect.ValueOf(copyServer(*server)).Elem(), reflect.ValueOf(&stored).Elem()
+	for _, column := range columns {
+		field, ok := serverSchema.FieldsByDBName[column]
+		if !ok {
+			return fmt.Errorf("unknown server column %q", column)
+		}
+		to.FieldByIndex(field.StructField.Index).Set(from.FieldByIndex(field.StructField.Index))
+	}
+	if err := r.checkUnique(&stored); err != nil {
+		return err
+	}
+	stored.UpdatedAt = time.Now()
+	r.servers[server.ID] = stored
+	return nil
+}
+
+func (r *MemoryServerRepository) AddKey(key *models.ServerKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	r.keys[key.ServerID] = append(r.keys[key.ServerID], *key)
+	return nil
+}
+
+func (r *MemoryServerRepository) Keys(serverId string) ([]models.ServerKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := append([]models.ServerKey(nil), r.keys[serverId]...)
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (r *MemoryServerRepository) WithTx(tx *gorm.DB) ServerRepository {
+	return r
+}
+
+// checkUnique enforces db.ServerNameIndex and db.ServerHostnameIndex against
+// the other servers. The caller holds the write lock.
+func (r *MemoryServerRepository) checkUnique(server *models.Server) error {
+	if server.Status == service.StatusTerminated {
+		return nil
+	}
+	for id, other := range r.servers {
+		if id == server.ID || other.Status == service.StatusTerminated ||
+			other.ProjectID != server.ProjectID || other.Region != server.Region {
+			continue
+		}
+		if server.Name != "" && other.Name == server.Name {
+			return duplicateKey(db.ServerNameIndex)
+		}
+		if server.Hostname != "" && other.Hostname == server.Hostname {
+			return duplicateKey(db.ServerHostnameIndex)
+		}
+	}
+	return nil
+}
+
+// duplicateKey names the violated index in its message, as database errors
+// do, so callers can tell which constraint failed.
+func duplicateKey(index string) error {
+	return fmt.Errorf("%w: index '%s'", gorm.ErrDuplicatedKey, index)
+}
+
+// sorted returns copies of the project's servers, oldest first.
+func (r *MemoryServerRepository) sorted(projectId string) []models.Server {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	servers := make([]models.Server, 0, len(r.servers))
+	for _, server := range r.servers {
+		if projectId == "" || server.ProjectID == projectId {
+			servers = append(servers, *copyServer(server))
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		if !servers[i].CreatedAt.Equal(servers[j].CreatedAt) {
+			return servers[i].CreatedAt.Before(servers[j].CreatedAt)
+		}
+		return servers[i].ID < servers[j].ID
+	})
+	return servers
+}
+
+// matchSelector keeps the servers whose tags match selector.
+func matchSelector(servers []models.Server, selector service.Selector) []models.Server {
+	if selector.Empty() {
+		return servers
+	}
+	matched := make([]models.Server, 0, len(servers))
+	for _, server := range servers {
+		if selector.Matches(server.Tags) {
+			matched = append(matched, server)
+		}
+	}
+	return matched
+}
+
+func copyServer(server models.Server) *models.Server {
+	if server.Tags != nil {
+		tags := make(map[string]string, len(server.Tags))
+		for key, value := range server.Tags {
+			tags[key] = value
+		}
+		server.Tags = tags
+	}
+	return &server
+}
+
+// MemoryLogRepository keeps the lifecycle log in memory, hash-chained as in
+// the database.
+type MemoryLogRepository struct {
+	mu       sync.RWMutex
+	servers  ServerRepository
+	entries  []models.ServerLog
+	restored []models.RestoredServerLog
+}
+
+// NewMemoryLogRepository returns an empty in-memory log. Entries take the
+// project of their server in servers, which may be nil.
+func NewMemoryLogRepository(servers ServerRepository) *MemoryLogRepository {
+	return &MemoryLogRepository{servers: servers}
+}
+
+func (r *MemoryLogRepository) Append(entry *models.ServerLog) error {
+	if r.servers != nil && entry.ServerID != "" {
+		if server, err := r.servers.Get(entry.ServerID, ""); err == nil {
+			entry.ProjectID = server.ProjectID
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.Sequence = int64(len(r.entries)) + 1
+	entry.PrevHash = ""
+	if len(r.entries) > 0 {
+		entry.PrevHash = r.entries[len(r.entries)-1].Hash
+	}
+	entry.CreatedAt = service.AuditTimestamp(time.Now())
+	entry.Hash = service.AuditHash(entry)
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+// Restore adds entries as if restored from the named archive.
+func (r *MemoryLogRepository) Restore(archive string, entries ...models.ServerLog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range entries {
+		r.restored = append(r.restored, entry.Restored(archive, time.Now()))
+	}
+}
+
+func (r *MemoryLogRepository) ListByServer(serverId, projectId string, limit int) ([]models.ServerLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var logs []models.ServerLog
+	for i := len(r.entries) - 1; i >= 0 && len(logs) < limit; i-- {
+		entry := r.entries[i]
+		if entry.ServerID == serverId && (projectId == "" || entry.ProjectID == projectId) {
+			logs = append(logs, entry)
+		}
+	}
+	return logs, nil
+}
+
+func (r *MemoryLogRepository) ListRestored(serverId, projectId string) ([]models.RestoredServerLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var restored []models.RestoredServerLog
+	for _, entry := range r.restored {
+		if entry.ServerID == serverId && (projectId == "" || entry.ProjectID == projectId) {
+			restored = append(restored, entry)
+		}
+	}
+	sort.SliceStable(restored, func(i, j int) bool { return restored[i].CreatedAt.After(restored[j].CreatedAt) })
+	return restored, nil
+}
+
+func (r *MemoryLogRepository) Events(serverId string, until *time.Time) ([]models.ServerLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var events []models.ServerLog
+	for _, entry := range r.entries {
+		if entry.ServerID == serverId && (until == nil || !entry.CreatedAt.After(*until)) {
+			events = append(events, entry)
+		}
+	}
+	return events, nil
+}
+
+// ArchivedCount is always zero; log retention only runs against the database.
+func (r *MemoryLogRepository) ArchivedCount(serverId string) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,117 @@
+// Package repository defines the stores behind servers and their logs, with
+// a GORM implementation for the database, an in-memory one for tests and a
+// wrapper that injects failures.
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a record does not exist. It is the error GORM
+// returns, so callers handle every store alike.
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// ServerRepository stores servers. A non-empty projectId confines a lookup to
+// that project.
+type ServerRepository interface {
+	Get(id, projectId string) (*models.Server, error)
+	// FindByName returns up to limit servers named name that are not
+	// terminated, in region unless it is empty.
+	FindByName(name, region, projectId string, limit int) ([]models.Server, error)
+	List(projectId string) ([]models.Server, error)
+	// FindBySelector returns the servers that are not terminated and whose
+	// tags match selector, oldest first, in region unless it is empty.
+	FindBySelector(selector service.Selector, region, projectId string) ([]models.Server, error)
+	// FindByMetadataToken returns the server whose metadata token hashes to
+	// hash.
+	FindByMetadataToken(hash string) (*models.Server, error)
+	// ListByIDs returns the servers with the given IDs, deleted ones included.
+	ListByIDs(ids []string) ([]models.Server, error)
+	// FindExpiring returns up to limit servers that are not terminated,
+	// expire after from and no later than to, and have not been warned yet.
+	FindExpiring(from, to time.Time, limit int) ([]models.Server, error)
+	// ClaimExpiryWarning marks the server as warned of its expiry at at,
+	// reporting false when it already was, e.g. by another reaper.
+	ClaimExpiryWarning(id string, at time.Time) (bool, error)
+	// FindExpired returns up to limit servers that are not terminated or
+	// protected and expired at or before now, earliest expiry first.
+	FindExpired(now time.Time, limit int) ([]models.Server, error)
+	// FindExpiredProtected returns up to limit servers that are not
+	// terminated, are protected, expired at or before now and have not been
+	// denied termination for it yet.
+	FindExpiredProtected(now time.Time, limit int) ([]models.Server, error)
+	// ClaimExpiryDenial marks the server as denied termination for its expiry
+	// at at, reporting false when it already was.
+	ClaimExpiryDenial(id string, at time.Time) (bool, error)
+	// InBatches calls fn with every server, deleted ones included, in ID
+	// order and batches of up to size, stopping at the first error.
+	InBatches(projectId string, size int, fn func([]models.Server) error) error
+	// AllocateAddresses assigns the server a private and a public address
+	// not used by any other server.
+	AllocateAddresses(server *models.Server) error
+	Create(server *models.Server) error
+	// Save writes every field of the server.
+	Save(server *models.Server) error
+	// Update writes the named columns of the server, e.g. "status", from
+	// their values in server.
+	Update(server *models.Server, columns ...string) error
+	// UpdateStatus is Update, but only while the stored status is still from.
+	// It reports false, writing nothing, when the status has changed since.
+	UpdateStatus(server *models.Server, from string, columns ...string) (bool, error)
+	// AddKey records an SSH key injected into a server.
+	AddKey(key *models.ServerKey) error
+	// Keys returns the SSH keys injected into the server, oldest first.
+	Keys(serverId string) ([]models.ServerKey, error)
+	// WithTx returns the repository working within tx, so its writes commit
+	// with the others of the transaction. Stores outside the database return
+	// themselves.
+	WithTx(tx *gorm.DB) ServerRepository
+}
+
+// allocateAddresses picks random addresses for the server until used reports
+// one free, giving up after a few attempts per address.
+func allocateAddresses(server *models.Server, used func(column, ip string) (bool, error)) error {
+	pick := func(column string, generate func() (string, error)) (string, error) {
+		for attempt := 0; attempt < 10; attempt++ {
+			ip, err := generate()
+			if err != nil {
+				return "", err
+			}
+			taken, err := used(column, ip)
+			if err != nil {
+				return "", err
+			}
+			if !taken {
+				return ip, nil
+			}
+		}
+		return "", fmt.Errorf("no free address found for %s", column)
+	}
+
+	var err error
+	if server.PrivateIP, err = pick("private_ip", service.RandomPrivateIP); err != nil {
+		return err
+	}
+	server.PublicIP, err = pick("public_ip", service.RandomPublicIP)
+	return err
+}
+
+// LogRepository stores the lifecycle log of servers.
+type LogRepository interface {
+	// Append links the entry to the end of the audit chain and stores it.
+	Append(entry *models.ServerLog) error
+	// ListByServer returns the newest entries of a server, newest first.
+	ListByServer(serverId, projectId string, limit int) ([]models.ServerLog, error)
+	// ListRestored returns the entries of a server restored from archives.
+	ListRestored(serverId, projectId string) ([]models.RestoredServerLog, error)
+	// Events returns a server's entries up to until (all of them when nil),
+	// oldest first.
+	Events(serverId string, until *time.Time) ([]models.ServerLog, error)
+	// ArchivedCount is how many entries of a server log retention archived.
+	ArchivedCount(serverId string) (int64, error)
+}
@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/db"
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"github.com/gitshubham45/virtualServer/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// stores returns each implementation, the GORM ones over a database of their
+// own so tests can run in parallel.
+func stores(t *testing.T) map[string]func() (ServerRepository, LogRepository) {
+	return map[string]func() (ServerRepository, LogRepository){
+		"gorm": func() (ServerRepository, LogRepository) {
+			testDB, err := gorm.Open(sqlite.Open("file:"+uuid.New().String()+"?mode=memory&cache=shared"), &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Failed to connect to test database: %v", err)
+			}
+			if err := db.AutoMigrate(testDB); err != nil {
+				t.Fatalf("Failed to migrate test schema: %v", err)
+			}
+			t.Cleanup(func() {
+				sqlDB, _ := testDB.DB()
+				sqlDB.Close()
+			})
+			return NewGormServerRepository(testDB), NewGormLogRepository(testDB)
+		},
+		"memory": func() (ServerRepository, LogRepository) {
+			servers := NewMemoryServerRepository()
+			return servers, NewMemoryLogRepository(servers)
+		},
+	}
+}
+
+func TestServerRepository(t *testing.T) {
+	t.Parallel()
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			servers, _ := open()
+
+			web := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "web", Region: "India", Status: service.StatusRunning}
+			old := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "web", Region: "India", Status: service.StatusTerminated}
+			other := models.Server{ID: uuid.New().String(), ProjectID: "beta", Name: "web", Region: "US East", Status: service.StatusRunning}
+			for _, server := range []*models.Server{&web, &old, &other} {
+				if err := servers.Create(server); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			}
+
+			if got, err := servers.Get(web.ID, "alpha"); err != nil || got.ID != web.ID {
+				t.Errorf("Get in project = %v, %v", got, err)
+			}
+			if _, err := servers.Get(web.ID, "beta"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get in another project: expected ErrNotFound, got %v", err)
+			}
+
+			if found, _ := servers.FindByName("web", "", "alpha", 2); len(found) != 1 || found[0].ID != web.ID {
+				t.Errorf("FindByName in project found %v, expected only the live server", found)
+			}
+			if found, _ := servers.FindByName("web", "", "", 2); len(found) != 2 {
+				t.Errorf("FindByName across projects found %d servers, expected 2", len(found))
+			}
+			if found, _ := servers.FindByName("web", "US East", "", 2); len(found) != 1 || found[0].ID != other.ID {
+				t.Errorf("FindByName in region found %v", found)
+			}
+
+			if list, _ := servers.List("alpha"); len(list) != 2 {
+				t.Errorf("List in project returned %d servers, expected 2", len(list))
+			}
+
+			// Update writes only the named columns.
+			web.Status = service.StatusStopped
+			web.Name = "ignored"
+			if err := servers.Update(&web, "status"); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if got, _ := servers.Get(web.ID, ""); got.Status != service.StatusStopped || got.Name != "web" {
+				t.Errorf("After Update got status %q and name %q", got.Status, got.Name)
+			}
+
+			// UpdateStatus writes nothing once the status has moved on.
+			web.Status = service.StatusRunning
+			if updated, err := servers.UpdateStatus(&web, service.StatusRunning, "status"); err != nil || updated {
+				t.Errorf("UpdateStatus from a stale status = %v, %v; expected no update", updated, err)
+			}
+			if updated, err := servers.UpdateStatus(&web, service.StatusStopped, "status"); err != nil || !updated {
+				t.Errorf("UpdateStatus = %v, %v; expected an update", updated, err)
+			}
+			if got, _ := servers.Get(web.ID, ""); got.Status != service.StatusRunning || got.Name != "web" {
+				t.Errorf("After UpdateStatus got status %q and name %q", got.Status, got.Name)
+			}
+
+			web.Name = "api"
+			if err := servers.Save(&web); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if got, _ := servers.Get(web.ID, ""); got.Name != "api" {
+				t.Errorf("After Save got name %q", got.Name)
+			}
+
+			key := models.ServerKey{ID: uuid.New().String(), ServerID: web.ID, KeyName: "deploy", PublicKey: "ssh-ed25519 AAAA"}
+			if err := servers.AddKey(&key); err != nil {
+				t.Fatalf("AddKey: %v", err)
+			}
+			if keys, _ := servers.Keys(web.ID); len(keys) != 1 || keys[0].KeyName != "deploy" {
+				t.Errorf("Keys = %v", keys)
+			}
+		})
+	}
+}
+
+// TestServerConstraints holds every store to the constraints of the servers
+// table.
+func TestServerConstraints(t *testing.T) {
+	t.Parallel()
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			servers, _ := open()
+
+			web := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "web", Hostname: "web.example.com", Region: "India", Status: service.StatusRunning}
+			db1 := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "db", Region: "India", Status: service.StatusRunning}
+			for _, server := range []*models.Server{&web, &db1} {
+				if err := servers.Create(server); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			}
+			if web.ServerNumber == 0 || db1.ServerNumber <= web.ServerNumber {
+				t.Errorf("Expected increasing server numbers, got %d and %d", web.ServerNumber, db1.ServerNumber)
+			}
+
+			duplicate := models.Server{ID: web.ID, ProjectID: "alpha", Name: "other", Region: "India", Status: service.StatusRunning}
+			if err := servers.Create(&duplicate); err == nil {
+				t.Errorf("Create with a used ID: expected an error")
+			}
+			if got, _ := servers.Get(web.ID, ""); got.Name != "web" {
+				t.Errorf("Create with a used ID replaced the server with %q", got.Name)
+			}
+
+			for _, tt := range []struct {
+				server models.Server
+				index  string
+			}{
+				{models.Server{ProjectID: "alpha", Name: "web", Region: "India", Status: service.StatusRunning}, db.ServerNameIndex},
+				{models.Server{ProjectID: "alpha", Name: "api", Hostname: "web.example.com", Region: "India", Status: service.StatusRunning}, db.ServerHostnameIndex},
+				{models.Server{ProjectID: "beta", Name: "web", Region: "India", Status: service.StatusRunning}, ""},
+				{models.Server{ProjectID: "alpha", Name: "web", Region: "US East", Status: service.StatusRunning}, ""},
+				{models.Server{ProjectID: "alpha", Name: "web", Region: "India", Status: service.StatusTerminated}, ""},
+			} {
+				server := tt.server
+				server.ID = uuid.New().String()
+				err := servers.Create(&server)
+				if tt.index == "" && err != nil {
+					t.Errorf("Create %s/%s/%s: %v", server.ProjectID, server.Region, server.Name, err)
+				}
+				if tt.index != "" && (err == nil || !strings.Contains(err.Error(), tt.index)) {
+					t.Errorf("Create %s/%s/%s: expected a %s violation, got %v", server.ProjectID, server.Region, server.Name, tt.index, err)
+				}
+			}
+
+			db1.Name = "web"
+			if err := servers.Update(&db1, "name"); err == nil || !strings.Contains(err.Error(), db.ServerNameIndex) {
+				t.Errorf("Update to a used name: expected a %s violation, got %v", db.ServerNameIndex, err)
+			}
+			db1.Hostname = "web.example.com"
+			db1.Name = "db"
+			if err := servers.Save(&db1); err == nil || !strings.Contains(err.Error(), db.ServerHostnameIndex) {
+				t.Errorf("Save with a used hostname: expected a %s violation, got %v", db.ServerHostnameIndex, err)
+			}
+
+			// A terminated server frees its name.
+			web.Status = service.StatusTerminated
+			if err := servers.Update(&web, "status"); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			db1.Hostname = ""
+			db1.Name = "web"
+			if err := servers.Update(&db1, "name", "hostname"); err != nil {
+				t.Errorf("Update to the name of a terminated server: %v", err)
+			}
+		})
+	}
+}
+
+func TestServerQueries(t *testing.T) {
+	t.Parallel()
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			servers, _ := open()
+
+			now := time.Now()
+			at := func(d time.Duration) *time.Time { later := now.Add(d); return &later }
+			soon := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "soon", Region: "India", Status: service.StatusRunning,
+				Tags: map[string]string{"env": "prod"}, ExpiresAt: at(time.Hour), MetadataTokenHash: "soon-hash"}
+			expired := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "expired", Region: "US East", Status: service.StatusRunning,
+				Tags: map[string]string{"env": "prod"}, ExpiresAt: at(-time.Hour)}
+			older := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "older", Region: "India", Status: service.StatusStopped,
+				Tags: map[string]string{"env": "dev"}, ExpiresAt: at(-2 * time.Hour)}
+			protected := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "protected", Region: "India", Status: service.StatusRunning,
+				ExpiresAt: at(-time.Hour), TerminationProtection: true}
+			gone := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "gone", Region: "India", Status: service.StatusTerminated,
+				Tags: map[string]string{"env": "prod"}, ExpiresAt: at(-time.Hour)}
+			for _, server := range []*models.Server{&soon, &expired, &older, &protected, &gone} {
+				if err := servers.Create(server); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			}
+
+			prod, _ := service.ParseSelector("env=prod")
+			if found, _ := servers.FindBySelector(prod, "", "alpha"); len(found) != 2 {
+				t.Errorf("FindBySelector found %d servers, expected the 2 live prod servers", len(found))
+			}
+			if found, _ := servers.FindBySelector(prod, "US East", "alpha"); len(found) != 1 || found[0].ID != expired.ID {
+				t.Errorf("FindBySelector in region found %v", found)
+			}
+			if found, _ := servers.FindBySelector(service.Selector{}, "", "beta"); len(found) != 0 {
+				t.Errorf("FindBySelector in another project found %d servers", len(found))
+			}
+
+			if got, err := servers.FindByMetadataToken("soon-hash"); err != nil || got.ID != soon.ID {
+				t.Errorf("FindByMetadataToken = %v, %v", got, err)
+			}
+			if _, err := servers.FindByMetadataToken("unknown"); err == nil {
+				t.Errorf("FindByMetadataToken with an unknown hash: expected an error")
+			}
+			if found, _ := servers.ListByIDs([]string{soon.ID, gone.ID, "missing"}); len(found) != 2 {
+				t.Errorf("ListByIDs found %d servers, expected 2", len(found))
+			}
+
+			expiring, _ := servers.FindExpiring(now, now.Add(2*time.Hour), 10)
+			if len(expiring) != 1 || expiring[0].ID != soon.ID {
+				t.Fatalf("FindExpiring = %v, expected only %s", expiring, soon.Name)
+			}
+			if claimed, err := servers.ClaimExpiryWarning(soon.ID, now); err != nil || !claimed {
+				t.Errorf("First ClaimExpiryWarning = %v, %v", claimed, err)
+			}
+			if claimed, _ := servers.ClaimExpiryWarning(soon.ID, now); claimed {
+				t.Errorf("Second ClaimExpiryWarning claimed the server again")
+			}
+			if expiring, _ := servers.FindExpiring(now, now.Add(2*time.Hour), 10); len(expiring) != 0 {
+				t.Errorf("FindExpiring returned a warned server")
+			}
+
+			found, _ := servers.FindExpired(now, 10)
+			if len(found) != 2 || found[0].ID != older.ID || found[1].ID != expired.ID {
+				t.Errorf("FindExpired = %v, expected the unprotected live servers, earliest expiry first", found)
+			}
+			if found, _ := servers.FindExpired(now, 1); len(found) != 1 {
+				t.Errorf("FindExpired ignored its limit, returned %d servers", len(found))
+			}
+			if found, _ := servers.FindExpiredProtected(now, 10); len(found) != 1 || found[0].ID != protected.ID {
+				t.Errorf("FindExpiredProtected = %v, expected only %s", found, protected.Name)
+			}
+			if claimed, err := servers.ClaimExpiryDenial(protected.ID, now); err != nil || !claimed {
+				t.Errorf("First ClaimExpiryDenial = %v, %v", claimed, err)
+			}
+			if claimed, _ := servers.ClaimExpiryDenial(protected.ID, now); claimed {
+				t.Errorf("Second ClaimExpiryDenial claimed the server again")
+			}
+			if found, _ := servers.FindExpiredProtected(now, 10); len(found) != 0 {
+				t.Errorf("FindExpiredProtected returned a server already denied")
+			}
+
+			var batches, seen int
+			if err := servers.InBatches("alpha", 2, func(batch []models.Server) error {
+				batches++
+				seen += len(batch)
+				return nil
+			}); err != nil || batches != 3 || seen != 5 {
+				t.Errorf("InBatches made %d batches of %d servers, %v; expected 3 of 5", batches, seen, err)
+			}
+
+			fresh := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Name: "fresh", Region: "India", Status: service.StatusRunning}
+			if err := servers.AllocateAddresses(&fresh); err != nil || fresh.PrivateIP == "" || fresh.PublicIP == "" {
+				t.Errorf("AllocateAddresses gave %q and %q, %v", fresh.PrivateIP, fresh.PublicIP, err)
+			}
+		})
+	}
+}
+
+func TestLogRepository(t *testing.T) {
+	t.Parallel()
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			servers, logs := open()
+
+			server := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Status: service.StatusRunning}
+			if err := servers.Create(&server); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			for _, eventType := range []string{"SERVER_CREATED", "SERVER_STOPPED", "SERVER_STARTED"} {
+				entry := models.ServerLog{ID: uuid.New().String(), ServerID: server.ID, EventType: eventType, Message: eventType}
+				if err := logs.Append(&entry); err != nil {
+					t.Fatalf("Append: %v", err)
+				}
+				if entry.ProjectID != "alpha" {
+					t.Errorf("Append stamped project %q, expected alpha", entry.ProjectID)
+				}
+			}
+
+			events, err := logs.Events(server.ID, nil)
+			if err != nil || len(events) != 3 {
+				t.Fatalf("Events = %d entries, %v; expected 3", len(events), err)
+			}
+			var verifier service.AuditChainVerifier
+			for i := range events {
+				if !verifier.Check(&events[i]) {
+					t.Fatalf("Appended entries do not chain: %s", verifier.Problem.Reason)
+				}
+			}
+
+			until := events[0].CreatedAt
+			if early, _ := logs.Events(server.ID, &until); len(early) != 1 {
+				t.Errorf("Events until the first entry returned %d entries, expected 1", len(early))
+			}
+			latest, _ := logs.ListByServer(server.ID, "alpha", 2)
+			if len(latest) != 2 || latest[0].EventType != "SERVER_STARTED" {
+				t.Errorf("ListByServer = %v, expected the 2 newest entries, newest first", latest)
+			}
+			if hidden, _ := logs.ListByServer(server.ID, "beta", 10); len(hidden) != 0 {
+				t.Errorf("ListByServer in another project returned %d entries", len(hidden))
+			}
+			if archived, err := logs.ArchivedCount(server.ID); err != nil || archived != 0 {
+				t.Errorf("ArchivedCount = %d, %v", archived, err)
+			}
+		})
+	}
+}
+
+// TestConcurrentAppends checks that appends racing through one repository
+// still form a single chain.
+func TestConcurrentAppends(t *testing.T) {
+	t.Parallel()
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			servers, logs := open()
+			server := models.Server{ID: uuid.New().String(), ProjectID: "alpha", Status: service.StatusRunning}
+			if err := servers.Create(&server); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := logs.Append(&models.ServerLog{ID: uuid.New().String(), ServerID: server.ID, EventType: "SERVER_UPDATED"}); err != nil {
+						t.Errorf("Append: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			events, err := logs.Events(server.ID, nil)
+			if err != nil || len(events) != 10 {
+				t.Fatalf("Events = %d entries, %v; expected 10", len(events), err)
+			}
+			var verifier service.AuditChainVerifier
+			for i := range events {
+				if !verifier.Check(&events[i]) {
+					t.Fatalf("Concurrent appends do not chain: %s", verifier.Problem.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestFaultyRepositories(t *testing.T) {
+	t.Parallel()
+	memory := NewMemoryServerRepository()
+	servers := NewFaultyServerRepository(memory)
+	logs := NewFaultyLogRepository(NewMemoryLogRepository(memory))
+	server := models.Server{ID: uuid.New().String(), CreatedAt: time.Now()}
+	servers.Create(&server)
+
+	failure := errors.New("connection reset")
+	servers.Fail("Get", failure)
+	logs.Fail("Append", failure)
+	if _, err := servers.Get(server.ID, ""); err != failure {
+		t.Errorf("Get: expected the injected error, got %v", err)
+	}
+	if err := logs.Append(&models.ServerLog{ID: uuid.New().String(), ServerID: server.ID}); err != failure {
+		t.Errorf("Append: expected the injected error, got %v", err)
+	}
+	if list, err := servers.List(""); err != nil || len(list) != 1 {
+		t.Errorf("List should pass through, got %d servers, %v", len(list), err)
+	}
+
+	servers.Fail("Get", nil)
+	if _, err := servers.Get(server.ID, ""); err != nil {
+		t.Errorf("Get after clearing the fault: %v", err)
+	}
+
+	servers.FailAfter("Get", 1, failure)
+	if _, err := servers.Get(server.ID, ""); err != nil {
+		t.Errorf("Get before the fault: %v", err)
+	}
+	if _, err := servers.Get(server.ID, ""); err != failure {
+		t.Errorf("Get after the first call: expected the injected error, got %v", err)
+	}
+
+	servers.Fail("Update", failure)
+	if err := servers.WithTx(nil).Update(&server, "status"); err != failure {
+		t.Errorf("Update within a transaction: expected the injected error, got %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+	"github.com/gitshubham45/virtualServer/internal/middleware"
+	"github.com/gitshubham45/virtualServer/internal/service"
+)
+
+func AdminRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	admin := api.Group("/admin", middleware.RequireScope(service.ScopeAdmin))
+	admin.POST("/api-keys", handler.CreateAPIKey)
+	admin.GET("/api-keys", handler.ListAPIKeys)
+	admin.DELETE("/api-keys/:id", handler.RevokeAPIKey)
+	admin.POST("/organizations", handler.CreateOrganization)
+	admin.GET("/organizations", handler.ListOrganizations)
+}
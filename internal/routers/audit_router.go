@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func AuditRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	api.GET("/audit/verify", handler.VerifyAuditLog)
+	api.GET("/audit/export", handler.ExportAuditLog)
+	api.GET("/audit/consistency", handler.CheckConsistency)
+	api.GET("/access-logs", handler.ListAccessLogs)
+	api.GET("/logs/search", handler.SearchLogs)
+}
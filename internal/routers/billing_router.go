@@ -0,0 +1,10 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func BillingRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	api.GET("/billing/report", handler.GetBillingReport)
+}
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func DNSRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	api.POST("/dns/zones", handler.CreateDNSZone)
+	api.GET("/dns/zones", handler.ListDNSZones)
+	api.GET("/dns/zones/:id", handler.GetDNSZone)
+	api.DELETE("/dns/zones/:id", handler.DeleteDNSZone)
+	api.POST("/dns/zones/:id/records", handler.CreateDNSRecord)
+	api.DELETE("/dns/zones/:id/records/:recordId", handler.DeleteDNSRecord)
+}
@@ -0,0 +1,13 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func KeyPairRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	api.POST("/key-pairs", handler.CreateKeyPair)
+	api.GET("/key-pairs", handler.ListKeyPairs)
+	api.GET("/key-pairs/:name", handler.GetKeyPair)
+	api.DELETE("/key-pairs/:name", handler.DeleteKeyPair)
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func LoadBalancerRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	api.POST("/load-balancers", handler.CreateLoadBalancer)
+	api.GET("/load-balancers", handler.ListLoadBalancers)
+	api.GET("/load-balancers/:id", handler.GetLoadBalancer)
+	api.DELETE("/load-balancers/:id", handler.DeleteLoadBalancer)
+	api.POST("/load-balancers/:id/listeners", handler.AddListener)
+
+	api.POST("/target-groups", handler.CreateTargetGroup)
+	api.GET("/target-groups", handler.ListTargetGroups)
+	api.GET("/target-groups/:id", handler.GetTargetGroup)
+	api.DELETE("/target-groups/:id", handler.DeleteTargetGroup)
+	api.POST("/target-groups/:id/targets", handler.RegisterTarget)
+	api.DELETE("/target-groups/:id/targets/:serverId", handler.DeregisterTarget)
+	api.PUT("/target-groups/:id/targets/:serverId/health-check", handler.SetTargetHealthCheck)
+}
@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func MetadataRouter(router *gin.Engine, handler *controller.ServerHandler) {
+	metadata := router.Group("/metadata/v1")
+	metadata.PUT("/token", handler.IssueMetadataToken)
+
+	session := metadata.Group("", handler.RequireMetadataSession)
+	session.GET("/", handler.MetadataIndex)
+	session.GET("/:item", handler.GetMetadataItem)
+	session.GET("/public-keys/:index/openssh-key", handler.GetMetadataPublicKey)
+}
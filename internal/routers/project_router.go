@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func ProjectRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	api.POST("/projects", handler.CreateProject)
+	api.GET("/projects", handler.ListProjects)
+	api.GET("/projects/:id", handler.GetProject)
+	api.PATCH("/projects/:id", handler.UpdateProject)
+	api.DELETE("/projects/:id", handler.DeleteProject)
+	api.GET("/projects/:id/quotas", handler.GetProjectQuotas)
+	api.PUT("/projects/:id/quotas", handler.SetProjectQuotas)
+}
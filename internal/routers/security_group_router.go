@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gitshubham45/virtualServer/internal/controller"
+)
+
+func SecurityGroupRouter(api *gin.RouterGroup, handler *controller.ServerHandler) {
+	api.POST("/security-groups", handler.CreateSecurityGroup)
+	api.GET("/security-groups", handler.ListSecurityGroups)
+	api.GET("/security-groups/:id", handler.GetSecurityGroup)
+	api.DELETE("/security-groups/:id", handler.DeleteSecurityGroup)
+	api.POST("/security-groups/:id/rules", handler.AddSecurityGroupRule)
+	api.DELETE("/security-groups/:id/rules/:ruleId", handler.DeleteSecurityGroupRule)
+
+	api.GET("/servers/:id/security-groups", handler.ListServerSecurityGroups)
+	api.POST("/servers/:id/security-groups", handler.AttachSecurityGroup)
+	api.DELETE("/servers/:id/security-groups/:groupId", handler.DetachSecurityGroup)
+	api.POST("/servers/:id/firewall/evaluate", handler.EvaluateFirewall)
+}
@@ -5,10 +5,21 @@ import (
 	"github.com/gitshubham45/virtualServer/internal/controller"
 )
 
-func ServerRouter(api *gin.RouterGroup){
-	api.POST("/server" , controller.CreateServer)
-	api.GET("/servers/:id" , controller.GetServersData)
-	api.POST("/servers/:id/action" , controller.CompleteAction)
-	api.GET("/servers" , controller.ListServers)
-	api.GET("/servers/:id/logs" , controller.GetLogs)
+func ServerRouter(api *gin.RouterGroup, handler *controller.ServerHandler){
+	api.POST("/server" , handler.CreateServer)
+	api.GET("/servers/:id" , handler.GetServer)
+	api.PATCH("/servers/:id", handler.UpdateServer)
+	api.POST("/servers/:id/action" , handler.CompleteAction)
+	api.POST("/servers/actions", handler.BulkServerAction)
+	api.GET("/servers" , handler.ListServers)
+	api.GET("/servers/:id/logs" , handler.GetLogs)
+	api.PUT("/servers/:id/user-data", handler.UpdateUserData)
+	api.GET("/servers/:id/metadata/user-data", handler.GetUserData)
+	api.POST("/servers/:id/metadata-token", handler.RotateMetadataToken)
+	api.PUT("/servers/:id/tags", handler.UpdateTags)
+	api.DELETE("/servers/:id/tags", handler.DeleteTags)
+	api.PUT("/servers/:id/expiry", handler.ExtendServerExpiry)
+	api.POST("/servers/:id/schedules", handler.CreateSchedule)
+	api.GET("/servers/:id/schedules", handler.ListSchedules)
+	api.DELETE("/servers/:id/schedules/:scheduleId", handler.DeleteSchedule)
 }
\ No newline at end of file
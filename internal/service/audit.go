@@ -0,0 +1,112 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+// AuditHash is the SHA-256 of an entry's canonical JSON form, which includes
+// the hash of the previous entry. CreatedAt is hashed in UTC with microsecond
+// precision, which every supported database preserves.
+func AuditHash(entry *models.ServerLog) string {
+	canonical, _ := json.Marshal(struct {
+		Sequence   int64  `json:"sequence"`
+		ID         string `json:"id"`
+		ServerID   string `json:"serverId"`
+		ProjectID  string `json:"projectId"`
+		EventType  string `json:"eventType"`
+		Message    string `json:"message"`
+		OldStatus  string `json:"oldStatus"`
+		NewStatus  string `json:"newStatus"`
+		Actor      string `json:"actor"`
+		SourceIP   string `json:"sourceIp"`
+		RequestID  string `json:"requestId"`
+		BodyDigest string `json:"bodyDigest"`
+		// Left out when empty, so entries written before it existed keep
+		// their hash.
+		Changes   map[string]string `json:"changes,omitempty"`
+		CreatedAt string            `json:"createdAt"`
+		PrevHash  string            `json:"prevHash"`
+	}{
+		entry.Sequence, entry.ID, entry.ServerID, entry.ProjectID, entry.EventType, entry.Message,
+		entry.OldStatus, entry.NewStatus, entry.Actor, entry.SourceIP, entry.RequestID, entry.BodyDigest,
+		entry.Changes, AuditTimestamp(entry.CreatedAt).Format(time.RFC3339Nano), entry.PrevHash,
+	})
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditTimestamp normalises a time the way it is stored in the audit log.
+func AuditTimestamp(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// AuditChainProblem is the first entry at which the chain does not hold.
+type AuditChainProblem struct {
+	Sequence int64  `json:"sequence"`
+	ID       string `json:"id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// AuditChainVerifier checks entries one by one, in sequence order, and stops
+// at the first problem.
+type AuditChainVerifier struct {
+	Entries  int64
+	Archived int64
+	LastSeq  int64
+	LastHash string
+	Problem  *AuditChainProblem
+}
+
+// Check verifies the next entry. It returns false once a problem was found.
+func (v *AuditChainVerifier) Check(entry *models.ServerLog) bool {
+	if v.Problem != nil {
+		return false
+	}
+	fail := func(reason string) bool {
+		v.Problem = &AuditChainProblem{Sequence: entry.Sequence, ID: entry.ID, Reason: reason}
+		return false
+	}
+
+	switch {
+	case entry.Sequence != v.LastSeq+1:
+		return fail(fmt.Sprintf("Expected sequence %d; entries are missing or out of order.", v.LastSeq+1))
+	case entry.PrevHash != v.LastHash:
+		return fail("Previous hash does not match the entry before it.")
+	case AuditHash(entry) != entry.Hash:
+		return fail("Entry hash does not match its contents; the entry was modified.")
+	}
+	v.Entries++
+	v.LastSeq = entry.Sequence
+	v.LastHash = entry.Hash
+	return true
+}
+
+// CheckArchived verifies the place in the chain of an entry moved to an
+// archive. Its contents are not available, so its hash is taken as kept.
+func (v *AuditChainVerifier) CheckArchived(stub *models.ArchivedServerLog) bool {
+	if v.Problem != nil {
+		return false
+	}
+	fail := func(reason string) bool {
+		v.Problem = &AuditChainProblem{Sequence: stub.Sequence, ID: stub.ServerLogID, Reason: reason}
+		return false
+	}
+
+	switch {
+	case stub.Sequence != v.LastSeq+1:
+		return fail(fmt.Sprintf("Expected sequence %d; entries are missing or out of order.", v.LastSeq+1))
+	case stub.PrevHash != v.LastHash:
+		return fail("Previous hash of the archived entry does not match the entry before it.")
+	}
+	v.Entries++
+	v.Archived++
+	v.LastSeq = stub.Sequence
+	v.LastHash = stub.Hash
+	return true
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func auditChain(n int) []models.ServerLog {
+	entries := make([]models.ServerLog, n)
+	prev := ""
+	for i := range entries {
+		entries[i] = models.ServerLog{
+			ID:        fmt.Sprintf("entry-%d", i+1),
+			Sequence:  int64(i + 1),
+			ServerID:  "server-1",
+			EventType: "SERVER_STARTED",
+			Message:   fmt.Sprintf("event %d", i+1),
+			Actor:     "api-key:ops",
+			CreatedAt: AuditTimestamp(time.Date(2026, 1, 1, 0, i, 0, 1500, time.UTC)),
+			PrevHash:  prev,
+		}
+		entries[i].Hash = AuditHash(&entries[i])
+		prev = entries[i].Hash
+	}
+	return entries
+}
+
+func TestAuditChainVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func([]models.ServerLog) []models.ServerLog
+		problem int64
+	}{
+		{"intact", func(e []models.ServerLog) []models.ServerLog { return e }, 0},
+		{"modified", func(e []models.ServerLog) []models.ServerLog { e[2].Message = "nothing happened"; return e }, 3},
+		{"rehashed", func(e []models.ServerLog) []models.ServerLog {
+			e[2].Actor = "api-key:someone-else"
+			e[2].Hash = AuditHash(&e[2])
+			return e
+		}, 4},
+		{"deleted", func(e []models.ServerLog) []models.ServerLog { return append(e[:1], e[2:]...) }, 3},
+		{"reordered", func(e []models.ServerLog) []models.ServerLog { e[1], e[2] = e[2], e[1]; return e }, 3},
+		{"truncated head", func(e []models.ServerLog) []models.ServerLog { return e[1:] }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifier AuditChainVerifier
+			for _, entry := range tt.tamper(auditChain(5)) {
+				if !verifier.Check(&entry) {
+					break
+				}
+			}
+			switch {
+			case tt.problem == 0 && verifier.Problem != nil:
+				t.Errorf("Expected an intact chain, got %+v", verifier.Problem)
+			case tt.problem == 0 && verifier.Entries != 5:
+				t.Errorf("Expected 5 entries, got %d", verifier.Entries)
+			case tt.problem != 0 && (verifier.Problem == nil || verifier.Problem.Sequence != tt.problem):
+				t.Errorf("Expected a problem at sequence %d, got %+v", tt.problem, verifier.Problem)
+			}
+		})
+	}
+}
+
+func TestAuditChainVerifierArchived(t *testing.T) {
+	entries := auditChain(4)
+	stub := func(e models.ServerLog) *models.ArchivedServerLog {
+		return &models.ArchivedServerLog{Sequence: e.Sequence, ServerLogID: e.ID, PrevHash: e.PrevHash, Hash: e.Hash}
+	}
+
+	var verifier AuditChainVerifier
+	ok := verifier.CheckArchived(stub(entries[0])) && verifier.CheckArchived(stub(entries[1])) &&
+		verifier.Check(&entries[2]) && verifier.Check(&entries[3])
+	if !ok || verifier.Entries != 4 || verifier.Archived != 2 {
+		t.Errorf("Expected a valid chain with 2 archived entries, got %+v", verifier)
+	}
+
+	forged := stub(entries[1])
+	forged.Hash = "forged"
+	verifier = AuditChainVerifier{}
+	verifier.CheckArchived(stub(entries[0]))
+	verifier.CheckArchived(forged)
+	if verifier.Check(&entries[2]) || verifier.Problem == nil || verifier.Problem.Sequence != 3 {
+		t.Errorf("Expected a forged archived hash to break the chain at sequence 3, got %+v", verifier.Problem)
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import "time"
+
+// HibernatedRateFraction is the share of the hourly rate charged for keeping a
+// hibernated server's memory image and disks in storage.
+const HibernatedRateFraction = 0.1
+
+// StoppedRateFraction is the share of the hourly rate charged for keeping a
+// stopped server's disks. It is below HibernatedRateFraction because there is
+// no memory image to keep, so stopping never costs more than hibernating.
+const StoppedRateFraction = 0.05
+
+// BilledRate is the hourly rate charged while a server is in the given status.
+func BilledRate(status string, hourlyRate float64) float64 {
+	switch status {
+	case StatusRunning, StatusRescue:
+		return hourlyRate
+	case StatusHibernated:
+		return hourlyRate * HibernatedRateFraction
+	case StatusStopped:
+		return hourlyRate * StoppedRateFraction
+	}
+	return 0
+}
+
+// PeriodCost returns the hours and cost of a billing period that fall inside
+// the [from, to) window. An open period is treated as ending at to.
+func PeriodCost(rate float64, startedAt time.Time, endedAt *time.Time, from, to time.Time) (float64, float64) {
+	end := to
+	if endedAt != nil && endedAt.Before(to) {
+		end = *endedAt
+	}
+	start := startedAt
+	if start.Before(from) {
+		start = from
+	}
+	if !end.After(start) {
+		return 0, 0
+	}
+	hours := end.Sub(start).Hours()
+	return hours, hours * rate
+}
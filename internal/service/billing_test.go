@@ -0,0 +1,64 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPeriodCost(t *testing.T) {
+	from := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	at := func(h int) time.Time { return from.Add(time.Duration(h) * time.Hour) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name      string
+		startedAt time.Time
+		endedAt   *time.Time
+		hours     float64
+	}{
+		{"inside window", at(2), ptr(at(5)), 3},
+		{"open period", at(20), nil, 4},
+		{"started before window", at(-10), ptr(at(1)), 1},
+		{"ended before window", at(-10), ptr(at(-1)), 0},
+		{"started after window", at(30), nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours, cost := PeriodCost(2.5, tt.startedAt, tt.endedAt, from, to)
+			if math.Abs(hours-tt.hours) > 1e-9 || math.Abs(cost-tt.hours*2.5) > 1e-9 {
+				t.Errorf("Expected %v hours, got %v hours costing %v", tt.hours, hours, cost)
+			}
+		})
+	}
+}
+
+func TestBilledRate(t *testing.T) {
+	if BilledRate(StatusRunning, 5) != 5 {
+		t.Errorf("Expected running servers to be billed at their rate")
+	}
+	if rate := BilledRate(StatusHibernated, 5); math.Abs(rate-0.5) > 1e-9 {
+		t.Errorf("Expected hibernated servers to be billed at the storage rate, got %v", rate)
+	}
+	if rate := BilledRate(StatusStopped, 5); math.Abs(rate-0.25) > 1e-9 {
+		t.Errorf("Expected stopped servers to be billed for their disks, got %v", rate)
+	}
+	for _, status := range []string{StatusTerminated, StatusPending, StatusResizing} {
+		if BilledRate(status, 5) != 0 {
+			t.Errorf("Expected '%s' servers not to be billed", status)
+		}
+	}
+}
+
+// TestBilledRateOrdering checks that keeping more of a server costs more:
+// running, then hibernated, then stopped, then nothing.
+func TestBilledRateOrdering(t *testing.T) {
+	ordered := []string{StatusRunning, StatusHibernated, StatusStopped, StatusTerminated}
+	for i := 1; i < len(ordered); i++ {
+		if BilledRate(ordered[i], 5) >= BilledRate(ordered[i-1], 5) {
+			t.Errorf("Expected '%s' servers to be billed less than '%s' servers", ordered[i], ordered[i-1])
+		}
+	}
+}
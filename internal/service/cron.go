@@ -0,0 +1,180 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MissedRunOnce = "run-once"
+	MissedRunSkip = "skip"
+)
+
+// MissedRunGrace is how late a run may start and still count as on time.
+// Anything later was missed, usually because the app was down.
+const MissedRunGrace = 5 * time.Minute
+
+var cronFieldNames = []map[string]int{
+	nil,
+	nil,
+	nil,
+	{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12},
+	{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6},
+}
+
+var cronFieldBounds = [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// CronSchedule is a parsed five-field cron expression:
+// minute hour day-of-month month day-of-week.
+type CronSchedule struct {
+	minutes, hours, days, months, weekdays uint64
+	// As in Vixie cron, when both day fields are restricted a day matches if
+	// either of them does.
+	anyDay, anyWeekday bool
+}
+
+// ParseCron parses a standard five-field cron expression. Fields accept *,
+// lists, ranges and steps; months and weekdays also accept three-letter names.
+// Both 0 and 7 mean Sunday.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, i)
+		if err != nil {
+			return nil, fmt.Errorf("cron field %d (%q): %w", i+1, field, err)
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &CronSchedule{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, index int) (uint64, error) {
+	bounds := cronFieldBounds[index]
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		low, high := bounds[0], bounds[1]
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(from, index); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseCronValue(to, index); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = bounds[1]
+			}
+			if high < low {
+				return 0, fmt.Errorf("range %q is backwards", rangePart)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseCronValue(value string, index int) (int, error) {
+	if names := cronFieldNames[index]; names != nil {
+		if n, ok := names[strings.ToLower(value)]; ok {
+			return n, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	bounds := cronFieldBounds[index]
+	if err != nil || n < bounds[0] || n > bounds[1] {
+		return 0, fmt.Errorf("value %q must be between %d and %d", value, bounds[0], bounds[1])
+	}
+	return n, nil
+}
+
+// Next returns the first time strictly after t that matches the schedule,
+// evaluated in t's location. It returns the zero time if there is none within
+// five years, which only happens for dates like 31 February.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// ValidateMissedRunPolicy defaults an empty policy to running once.
+func ValidateMissedRunPolicy(policy *string) string {
+	if *policy == "" {
+		*policy = MissedRunOnce
+	}
+	if *policy != MissedRunOnce && *policy != MissedRunSkip {
+		return fmt.Sprintf("missedRunPolicy must be '%s' or '%s'.", MissedRunOnce, MissedRunSkip)
+	}
+	return ""
+}
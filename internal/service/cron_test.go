@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// Monday 2026-10-19.
+		{"weekday evening", "0 19 * * MON-FRI", time.Date(2026, 10, 19, 12, 0, 0, 0, kolkata), time.Date(2026, 10, 19, 19, 0, 0, 0, kolkata)},
+		{"strictly after", "0 19 * * 1-5", time.Date(2026, 10, 19, 19, 0, 0, 0, kolkata), time.Date(2026, 10, 20, 19, 0, 0, 0, kolkata)},
+		{"skips weekend", "0 8 * * 1-5", time.Date(2026, 10, 23, 9, 0, 0, 0, kolkata), time.Date(2026, 10, 26, 8, 0, 0, 0, kolkata)},
+		{"steps", "*/15 * * * *", time.Date(2026, 10, 19, 10, 7, 30, 0, time.UTC), time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC)},
+		{"list and range", "30 9,17 1-3 * *", time.Date(2026, 10, 3, 18, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 9, 30, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"day or weekday", "0 0 1 * SUN", time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"month names", "0 0 1 jan *", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"wall clock across DST", "0 8 * * *", time.Date(2026, 10, 31, 9, 0, 0, 0, newYork), time.Date(2026, 11, 1, 8, 0, 0, 0, newYork)},
+		{"never", "0 0 31 2 *", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) failed: %v", tt.expr, err)
+			}
+			if got := cron.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * * funday",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected ParseCron(%q) to fail", expr)
+		}
+	}
+}
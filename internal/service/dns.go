@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	RecordTypeA     = "A"
+	RecordTypeAAAA  = "AAAA"
+	RecordTypeCNAME = "CNAME"
+	RecordTypeTXT   = "TXT"
+)
+
+const (
+	DefaultRecordTTL = 300
+	maxTXTLength     = 4000
+)
+
+// NormalizeDNSName lowercases a name and strips the trailing root dot.
+func NormalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// ValidateDNSName checks the length and label rules of a record or zone name.
+// Underscores are allowed since they are common in TXT record names.
+func ValidateDNSName(name string) string {
+	if name == "" {
+		return "DNS name is required."
+	}
+	if len(name) > 253 {
+		return fmt.Sprintf("DNS name '%s' is longer than 253 characters.", name)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Sprintf("DNS name '%s' has an empty or over-long label.", name)
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Sprintf("DNS label '%s' must not start or end with a hyphen.", label)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return fmt.Sprintf("DNS label '%s' contains invalid character '%c'.", label, r)
+			}
+		}
+	}
+	return ""
+}
+
+// InZone reports whether name is the zone apex or below it.
+func InZone(name, zone string) bool {
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// ValidateDNSRecord normalises the record fields in place and checks the value
+// against the record type.
+func ValidateDNSRecord(recordType, name, value *string, ttl *int, zone string) string {
+	*recordType = strings.ToUpper(strings.TrimSpace(*recordType))
+	*name = NormalizeDNSName(*name)
+
+	if errorMessage := ValidateDNSName(*name); errorMessage != "" {
+		return errorMessage
+	}
+	if !InZone(*name, zone) {
+		return fmt.Sprintf("Record name '%s' is not inside zone '%s'.", *name, zone)
+	}
+
+	if *ttl == 0 {
+		*ttl = DefaultRecordTTL
+	}
+	if *ttl < 0 || *ttl > 86400*7 {
+		return fmt.Sprintf("TTL %d is out of range.", *ttl)
+	}
+
+	switch *recordType {
+	case RecordTypeA:
+		ip := net.ParseIP(*value)
+		if ip == nil || ip.To4() == nil {
+			return fmt.Sprintf("'%s' is not an IPv4 address.", *value)
+		}
+		*value = ip.To4().String()
+	case RecordTypeAAAA:
+		ip := net.ParseIP(*value)
+		if ip == nil || ip.To4() != nil {
+			return fmt.Sprintf("'%s' is not an IPv6 address.", *value)
+		}
+		*value = ip.String()
+	case RecordTypeCNAME:
+		*value = NormalizeDNSName(*value)
+		if errorMessage := ValidateDNSName(*value); errorMessage != "" {
+			return errorMessage
+		}
+		if *value == *name {
+			return "A CNAME record cannot point to itself."
+		}
+	case RecordTypeTXT:
+		if len(*value) == 0 || len(*value) > maxTXTLength {
+			return fmt.Sprintf("TXT value must be between 1 and %d characters.", maxTXTLength)
+		}
+	default:
+		return fmt.Sprintf("Record type '%s' is not supported. Use A, AAAA, CNAME or TXT.", *recordType)
+	}
+
+	return ""
+}
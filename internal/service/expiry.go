@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	TerminationReasonExpired   = "EXPIRED"
+	TerminationReasonScheduled = "SCHEDULED"
+)
+
+// MaxServerTTL bounds how far in the future a server may be set to expire.
+const MaxServerTTL = 365 * 24 * time.Hour
+
+// ResolveExpiry turns either an absolute expiry or a TTL duration such as "2h"
+// into an expiry time. It returns nil when neither is given.
+func ResolveExpiry(expiresAt *time.Time, ttl string, now time.Time) (*time.Time, string) {
+	if expiresAt != nil && ttl != "" {
+		return nil, "Specify either expiresAt or ttl, not both."
+	}
+
+	var expiry time.Time
+	switch {
+	case expiresAt != nil:
+		expiry = *expiresAt
+	case ttl != "":
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Sprintf("Invalid ttl '%s'. Use a duration such as '90m' or '24h'.", ttl)
+		}
+		expiry = now.Add(duration)
+	default:
+		return nil, ""
+	}
+
+	if !expiry.After(now) {
+		return nil, "The expiry must be in the future."
+	}
+	if expiry.Sub(now) > MaxServerTTL {
+		return nil, fmt.Sprintf("The expiry cannot be more than %d days away.", int(MaxServerTTL.Hours()/24))
+	}
+	expiry = expiry.UTC()
+	return &expiry, ""
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		ttl       string
+		want      *time.Time
+		invalid   bool
+	}{
+		{"neither", nil, "", nil, false},
+		{"ttl", nil, "90m", at(90 * time.Minute), false},
+		{"expiresAt", at(2 * time.Hour), "", at(2 * time.Hour), false},
+		{"both", at(2 * time.Hour), "1h", nil, true},
+		{"bad ttl", nil, "two hours", nil, true},
+		{"negative ttl", nil, "-1h", nil, true},
+		{"past", at(-time.Minute), "", nil, true},
+		{"too far", nil, "9000h", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errorMessage := ResolveExpiry(tt.expiresAt, tt.ttl, now)
+			if (errorMessage != "") != tt.invalid {
+				t.Fatalf("Expected invalid = %v, got error %q", tt.invalid, errorMessage)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
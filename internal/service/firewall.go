@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+const (
+	DirectionIngress = "ingress"
+	DirectionEgress  = "egress"
+)
+
+const (
+	ProtocolTCP  = "tcp"
+	ProtocolUDP  = "udp"
+	ProtocolICMP = "icmp"
+	ProtocolAll  = "all"
+)
+
+// Packet describes the first packet of a flow as seen by the server the
+// security groups are attached to. For ingress the remote end is the source,
+// for egress it is the destination.
+type Packet struct {
+	Direction       string
+	Protocol        string
+	SourceIP        string
+	SourcePort      int
+	DestinationIP   string
+	DestinationPort int
+	// Security groups the remote end belongs to, used to match rules that
+	// reference a source group instead of a CIDR.
+	PeerGroupIDs []string
+}
+
+type FirewallDecision struct {
+	Allowed         bool   `json:"allowed"`
+	MatchedRuleID   string `json:"matchedRuleId,omitempty"`
+	SecurityGroupID string `json:"securityGroupId,omitempty"`
+	Reason          string `json:"reason"`
+}
+
+// ValidateSecurityGroupRule normalises the rule in place and returns an error
+// message when it is not acceptable, in the same style as HandleAction.
+func ValidateSecurityGroupRule(rule *models.SecurityGroupRule) string {
+	rule.Direction = strings.ToLower(strings.TrimSpace(rule.Direction))
+	rule.Protocol = strings.ToLower(strings.TrimSpace(rule.Protocol))
+	rule.CIDR = strings.TrimSpace(rule.CIDR)
+
+	switch rule.Direction {
+	case DirectionIngress, DirectionEgress:
+	default:
+		return fmt.Sprintf("Direction '%s' is not supported. Use 'ingress' or 'egress'.", rule.Direction)
+	}
+
+	switch rule.Protocol {
+	case ProtocolTCP, ProtocolUDP:
+		if rule.FromPort < 1 || rule.ToPort > 65535 || rule.FromPort > rule.ToPort {
+			return fmt.Sprintf("Invalid port range %d-%d. Ports must be between 1 and 65535 and fromPort must not exceed toPort.", rule.FromPort, rule.ToPort)
+		}
+	case ProtocolICMP, ProtocolAll:
+		rule.FromPort = 0
+		rule.ToPort = 0
+	default:
+		return fmt.Sprintf("Protocol '%s' is not supported. Use 'tcp', 'udp', 'icmp' or 'all'.", rule.Protocol)
+	}
+
+	if (rule.CIDR == "") == (rule.SourceGroupID == "") {
+		return "Exactly one of 'cidr' or 'sourceGroupId' must be set."
+	}
+
+	if rule.CIDR != "" {
+		_, network, err := net.ParseCIDR(rule.CIDR)
+		if err != nil {
+			return fmt.Sprintf("Invalid CIDR '%s'.", rule.CIDR)
+		}
+		rule.CIDR = network.String()
+	}
+
+	return ""
+}
+
+// EvaluateFirewall applies allow-only, default-deny semantics: a packet is
+// allowed when at least one rule of any attached group matches it. Groups are
+// stateful, so return traffic of an allowed flow is never evaluated.
+func EvaluateFirewall(rules []models.SecurityGroupRule, pkt Packet) FirewallDecision {
+	direction := strings.ToLower(pkt.Direction)
+	protocol := strings.ToLower(pkt.Protocol)
+
+	remote := pkt.SourceIP
+	if direction == DirectionEgress {
+		remote = pkt.DestinationIP
+	}
+	remoteIP := net.ParseIP(remote)
+
+	peerGroups := make(map[string]bool, len(pkt.PeerGroupIDs))
+	for _, id := range pkt.PeerGroupIDs {
+		peerGroups[id] = true
+	}
+
+	for _, rule := range rules {
+		if rule.Direction != direction {
+			continue
+		}
+		if rule.Protocol != ProtocolAll && rule.Protocol != protocol {
+			continue
+		}
+		if rule.Protocol == ProtocolTCP || rule.Protocol == ProtocolUDP {
+			if pkt.DestinationPort < rule.FromPort || pkt.DestinationPort > rule.ToPort {
+				continue
+			}
+		}
+
+		if rule.SourceGroupID != "" {
+			if !peerGroups[rule.SourceGroupID] {
+				continue
+			}
+		} else {
+			_, network, err := net.ParseCIDR(rule.CIDR)
+			if err != nil || remoteIP == nil || !network.Contains(remoteIP) {
+				continue
+			}
+		}
+
+		return FirewallDecision{
+			Allowed:         true,
+			MatchedRuleID:   rule.ID,
+			SecurityGroupID: rule.SecurityGroupID,
+			Reason:          fmt.Sprintf("Allowed by rule: %s.", DescribeSecurityGroupRule(rule)),
+		}
+	}
+
+	return FirewallDecision{
+		Allowed: false,
+		Reason:  fmt.Sprintf("No %s rule allows %s traffic to port %d.", direction, protocol, pkt.DestinationPort),
+	}
+}
+
+func DescribeSecurityGroupRule(rule models.SecurityGroupRule) string {
+	ports := "all ports"
+	if rule.Protocol == ProtocolTCP || rule.Protocol == ProtocolUDP {
+		ports = fmt.Sprintf("ports %d-%d", rule.FromPort, rule.ToPort)
+	}
+
+	peer := rule.CIDR
+	if rule.SourceGroupID != "" {
+		peer = "group " + rule.SourceGroupID
+	}
+
+	preposition := "from"
+	if rule.Direction == DirectionEgress {
+		preposition = "to"
+	}
+
+	return fmt.Sprintf("%s %s %s %s %s", rule.Direction, rule.Protocol, ports, preposition, peer)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestValidateSecurityGroupRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    models.SecurityGroupRule
+		wantErr bool
+	}{
+		{"ssh from cidr", models.SecurityGroupRule{Direction: "ingress", Protocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "10.0.0.0/8"}, false},
+		{"icmp from group", models.SecurityGroupRule{Direction: "ingress", Protocol: "icmp", SourceGroupID: "sg-1"}, false},
+		{"unknown direction", models.SecurityGroupRule{Direction: "inbound", Protocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "10.0.0.0/8"}, true},
+		{"unknown protocol", models.SecurityGroupRule{Direction: "ingress", Protocol: "sctp", CIDR: "10.0.0.0/8"}, true},
+		{"inverted port range", models.SecurityGroupRule{Direction: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 80, CIDR: "10.0.0.0/8"}, true},
+		{"port out of range", models.SecurityGroupRule{Direction: "ingress", Protocol: "udp", FromPort: 1, ToPort: 70000, CIDR: "10.0.0.0/8"}, true},
+		{"cidr and group", models.SecurityGroupRule{Direction: "ingress", Protocol: "all", CIDR: "10.0.0.0/8", SourceGroupID: "sg-1"}, true},
+		{"neither cidr nor group", models.SecurityGroupRule{Direction: "ingress", Protocol: "all"}, true},
+		{"invalid cidr", models.SecurityGroupRule{Direction: "ingress", Protocol: "all", CIDR: "10.0.0.300/8"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			errorMessage := ValidateSecurityGroupRule(&rule)
+			if (errorMessage != "") != tt.wantErr {
+				t.Errorf("Expected error = %v, got %q", tt.wantErr, errorMessage)
+			}
+		})
+	}
+
+	t.Run("normalises cidr and ports", func(t *testing.T) {
+		rule := models.SecurityGroupRule{Direction: "Ingress", Protocol: "ALL", FromPort: 5, ToPort: 9, CIDR: "192.168.1.17/24"}
+		if errorMessage := ValidateSecurityGroupRule(&rule); errorMessage != "" {
+			t.Fatalf("Unexpected error: %s", errorMessage)
+		}
+		if rule.CIDR != "192.168.1.0/24" || rule.FromPort != 0 || rule.ToPort != 0 || rule.Protocol != "all" || rule.Direction != "ingress" {
+			t.Errorf("Rule was not normalised: %+v", rule)
+		}
+	})
+}
+
+func TestEvaluateFirewall(t *testing.T) {
+	rules := []models.SecurityGroupRule{
+		{ID: "ssh", SecurityGroupID: "web", Direction: DirectionIngress, Protocol: ProtocolTCP, FromPort: 22, ToPort: 22, CIDR: "10.0.0.0/8"},
+		{ID: "https", SecurityGroupID: "web", Direction: DirectionIngress, Protocol: ProtocolTCP, FromPort: 443, ToPort: 443, CIDR: "0.0.0.0/0"},
+		{ID: "db", SecurityGroupID: "web", Direction: DirectionIngress, Protocol: ProtocolTCP, FromPort: 5432, ToPort: 5432, SourceGroupID: "app"},
+		{ID: "ping", SecurityGroupID: "web", Direction: DirectionIngress, Protocol: ProtocolICMP, CIDR: "192.168.0.0/16"},
+		{ID: "dns-out", SecurityGroupID: "web", Direction: DirectionEgress, Protocol: ProtocolUDP, FromPort: 53, ToPort: 53, CIDR: "8.8.8.8/32"},
+		{ID: "v6", SecurityGroupID: "web", Direction: DirectionIngress, Protocol: ProtocolAll, CIDR: "2001:db8::/32"},
+	}
+
+	tests := []struct {
+		name      string
+		packet    Packet
+		allowed   bool
+		matchedBy string
+	}{
+		{"ssh from private range", Packet{Direction: "ingress", Protocol: "tcp", SourceIP: "10.1.2.3", DestinationPort: 22}, true, "ssh"},
+		{"ssh from internet", Packet{Direction: "ingress", Protocol: "tcp", SourceIP: "203.0.113.9", DestinationPort: 22}, false, ""},
+		{"https from internet", Packet{Direction: "ingress", Protocol: "tcp", SourceIP: "203.0.113.9", DestinationPort: 443}, true, "https"},
+		{"udp on tcp port", Packet{Direction: "ingress", Protocol: "udp", SourceIP: "203.0.113.9", DestinationPort: 443}, false, ""},
+		{"db from app group", Packet{Direction: "ingress", Protocol: "tcp", SourceIP: "10.9.9.9", DestinationPort: 5432, PeerGroupIDs: []string{"app"}}, true, "db"},
+		{"db from other group", Packet{Direction: "ingress", Protocol: "tcp", SourceIP: "10.9.9.9", DestinationPort: 5432, PeerGroupIDs: []string{"batch"}}, false, ""},
+		{"icmp ignores ports", Packet{Direction: "ingress", Protocol: "icmp", SourceIP: "192.168.4.4"}, true, "ping"},
+		{"egress matches destination", Packet{Direction: "egress", Protocol: "udp", SourceIP: "10.0.0.5", DestinationIP: "8.8.8.8", DestinationPort: 53}, true, "dns-out"},
+		{"egress elsewhere denied", Packet{Direction: "egress", Protocol: "udp", SourceIP: "10.0.0.5", DestinationIP: "1.1.1.1", DestinationPort: 53}, false, ""},
+		{"ingress rule does not allow egress", Packet{Direction: "egress", Protocol: "tcp", DestinationIP: "10.1.2.3", DestinationPort: 22}, false, ""},
+		{"all protocols over ipv6", Packet{Direction: "ingress", Protocol: "udp", SourceIP: "2001:db8::1", DestinationPort: 9999}, true, "v6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := EvaluateFirewall(rules, tt.packet)
+			if decision.Allowed != tt.allowed {
+				t.Fatalf("Expected allowed = %v, got %v (%s)", tt.allowed, decision.Allowed, decision.Reason)
+			}
+			if decision.MatchedRuleID != tt.matchedBy {
+				t.Errorf("Expected rule %q to match, got %q", tt.matchedBy, decision.MatchedRuleID)
+			}
+		})
+	}
+
+	t.Run("no rules denies everything", func(t *testing.T) {
+		decision := EvaluateFirewall(nil, Packet{Direction: "ingress", Protocol: "tcp", SourceIP: "10.0.0.1", DestinationPort: 80})
+		if decision.Allowed {
+			t.Errorf("Expected default deny")
+		}
+	})
+}
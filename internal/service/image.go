@@ -0,0 +1,22 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultImage is installed on servers created without an image.
+const DefaultImage = "ubuntu-24.04"
+
+var imagePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]{0,62}[a-z0-9])?$`)
+
+// ValidateImage defaults an empty image and checks the name of any other.
+func ValidateImage(image *string) string {
+	if *image == "" {
+		*image = DefaultImage
+	}
+	if !imagePattern.MatchString(*image) {
+		return fmt.Sprintf("Invalid image '%s'. Use up to 64 lowercase letters, digits, '.', '_' or '-'.", *image)
+	}
+	return ""
+}
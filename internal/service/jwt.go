@@ -0,0 +1,271 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// JWTLeeway absorbs clock skew between the token issuer and this app.
+const JWTLeeway = 30 * time.Second
+
+// JWKS is a set of public keys for verifying bearer tokens, indexed by kid.
+type JWKS struct {
+	keys map[string]crypto.PublicKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS reads a JSON Web Key Set. RSA, EC (P-256, P-384) and Ed25519 keys
+// are supported; keys of other types or meant for encryption are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	jwks := &JWKS{keys: make(map[string]crypto.PublicKey)}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': %w", k.Kid, err)
+		}
+		if key != nil {
+			jwks.keys[k.Kid] = key
+		}
+	}
+	if len(jwks.keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return jwks, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, nil
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key length")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+// JWTClaims are the registered claims this app checks, plus the scopes.
+type JWTClaims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	Scopes    []string
+	Roles     []string
+	Project   string
+}
+
+// VerifyJWT checks a compact JWS signature against the key set and validates
+// exp, nbf and, when given, the issuer and audience. Scopes are read from a
+// space-separated "scope" claim or a "scopes" array, roles from a "roles"
+// array and the project from a "project" claim.
+func (s *JWKS) VerifyJWT(token, issuer, audience string, now time.Time) (*JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	key, ok := s.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key '%s'", header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Sub    string          `json:"sub"`
+		Iss    string          `json:"iss"`
+		Aud    json.RawMessage `json:"aud"`
+		Exp    *float64        `json:"exp"`
+		Nbf    *float64        `json:"nbf"`
+		Scope  string          `json:"scope"`
+		Scopes []string        `json:"scopes"`
+		Roles  []string        `json:"roles"`
+		Proj   string          `json:"project"`
+	}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+
+	claims := &JWTClaims{Subject: raw.Sub, Issuer: raw.Iss, Scopes: raw.Scopes, Roles: raw.Roles, Project: raw.Proj}
+	if raw.Scope != "" {
+		claims.Scopes = append(claims.Scopes, strings.Fields(raw.Scope)...)
+	}
+	if len(raw.Aud) > 0 {
+		var single string
+		if json.Unmarshal(raw.Aud, &single) == nil {
+			claims.Audience = []string{single}
+		} else if err := json.Unmarshal(raw.Aud, &claims.Audience); err != nil {
+			return nil, errors.New("malformed audience")
+		}
+	}
+
+	if raw.Exp == nil {
+		return nil, errors.New("token has no expiry")
+	}
+	claims.ExpiresAt = time.Unix(int64(*raw.Exp), 0)
+	if !now.Before(claims.ExpiresAt.Add(JWTLeeway)) {
+		return nil, errors.New("token has expired")
+	}
+	if raw.Nbf != nil {
+		claims.NotBefore = time.Unix(int64(*raw.Nbf), 0)
+		if now.Add(JWTLeeway).Before(claims.NotBefore) {
+			return nil, errors.New("token is not valid yet")
+		}
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	if issuer != "" && claims.Issuer != issuer {
+		return nil, errors.New("unexpected issuer")
+	}
+	if audience != "" && !contains(claims.Audience, audience) {
+		return nil, errors.New("unexpected audience")
+	}
+	return claims, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	errInvalid := errors.New("invalid signature")
+
+	var h hash.Hash
+	var hashID crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		h, hashID = sha256.New(), crypto.SHA256
+	case "RS384", "ES384":
+		h, hashID = sha512.New384(), crypto.SHA384
+	case "RS512":
+		h, hashID = sha512.New(), crypto.SHA512
+	case "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, signed, signature) {
+			return errInvalid
+		}
+		return nil
+	default:
+		// This includes "none", which must never be accepted.
+		return fmt.Errorf("unsupported algorithm '%s'", alg)
+	}
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" || rsa.VerifyPKCS1v15(k, hashID, digest, signature) != nil {
+			return errInvalid
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size {
+			return errInvalid
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errInvalid
+		}
+	default:
+		return errInvalid
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwksJSON, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": b64(edPublic)},
+			{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		},
+	})
+	jwks, err := ParseJWKS(jwksJSON)
+	if err != nil {
+		t.Fatalf("ParseJWKS failed: %v", err)
+	}
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	sign := func(alg, kid string, claims map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		signed := b64(header) + "." + b64(payload)
+		var signature []byte
+		switch alg {
+		case "RS256":
+			digest := sha256.Sum256([]byte(signed))
+			signature, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		case "EdDSA":
+			signature = ed25519.Sign(edPrivate, []byte(signed))
+		}
+		return signed + "." + b64(signature)
+	}
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "alice",
+			"iss":   "https://issuer.test",
+			"aud":   "virtual-server",
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "read write",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	t.Run("Valid tokens", func(t *testing.T) {
+		for _, alg := range []string{"RS256", "EdDSA"} {
+			kid := map[string]string{"RS256": "rsa-1", "EdDSA": "ed-1"}[alg]
+			got, err := jwks.VerifyJWT(sign(alg, kid, claims(nil)), "https://issuer.test", "virtual-server", now)
+			if err != nil {
+				t.Fatalf("%s: VerifyJWT failed: %v", alg, err)
+			}
+			if got.Subject != "alice" || len(got.Scopes) != 2 {
+				t.Errorf("%s: unexpected claims %+v", alg, got)
+			}
+		}
+	})
+
+	t.Run("Rejected tokens", func(t *testing.T) {
+		// Another subject's claims under alice's signature.
+		valid := strings.Split(sign("RS256", "rsa-1", claims(nil)), ".")
+		forged := strings.Split(sign("RS256", "rsa-1", claims(map[string]interface{}{"sub": "mallory"})), ".")
+		tampered := valid[0] + "." + forged[1] + "." + valid[2]
+
+		tests := map[string]string{
+			"expired":        sign("RS256", "rsa-1", claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()})),
+			"no expiry":      sign("RS256", "rsa-1", claims(map[string]interface{}{"exp": nil})),
+			"not yet valid":  sign("RS256", "rsa-1", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+			"wrong issuer":   sign("RS256", "rsa-1", claims(map[string]interface{}{"iss": "https://evil.test"})),
+			"wrong audience": sign("RS256", "rsa-1", claims(map[string]interface{}{"aud": []string{"other"}})),
+			"no subject":     sign("RS256", "rsa-1", claims(map[string]interface{}{"sub": nil})),
+			"unknown kid":    sign("RS256", "rsa-2", claims(nil)),
+			"wrong key type": sign("EdDSA", "rsa-1", claims(nil)),
+			"alg none":       sign("none", "rsa-1", claims(nil)),
+			"tampered":       tampered,
+			"malformed":      "not.a.jwt",
+		}
+		for name, token := range tests {
+			if _, err := jwks.VerifyJWT(token, "https://issuer.test", "virtual-server", now); err == nil {
+				t.Errorf("%s: expected token to be rejected", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	KeyTypeED25519 = "ed25519"
+	KeyTypeRSA     = "rsa"
+	KeyTypeECDSA   = "ecdsa"
+)
+
+const minRSAKeyBits = 2048
+
+type ParsedPublicKey struct {
+	Type        string
+	PublicKey   string
+	Fingerprint string
+}
+
+// ParsePublicKey accepts a single key in OpenSSH authorized_keys format and
+// returns it normalised together with its SHA256 fingerprint.
+func ParsePublicKey(publicKey string) (*ParsedPublicKey, string) {
+	key, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(publicKey)))
+	if err != nil {
+		return nil, "Public key is not in a recognised OpenSSH format."
+	}
+	if len(options) > 0 || len(strings.TrimSpace(string(rest))) > 0 {
+		return nil, "Public key must be a single key without authorized_keys options."
+	}
+
+	var keyType string
+	switch key.Type() {
+	case ssh.KeyAlgoED25519:
+		keyType = KeyTypeED25519
+	case ssh.KeyAlgoRSA:
+		keyType = KeyTypeRSA
+		cryptoKey, ok := key.(ssh.CryptoPublicKey)
+		if !ok {
+			return nil, "Unable to inspect RSA public key."
+		}
+		rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+		if !ok || rsaKey.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Sprintf("RSA keys must be at least %d bits.", minRSAKeyBits)
+		}
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		keyType = KeyTypeECDSA
+	default:
+		return nil, fmt.Sprintf("Key type '%s' is not supported. Use ed25519, RSA or ECDSA.", key.Type())
+	}
+
+	normalised := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	if comment != "" {
+		normalised += " " + comment
+	}
+
+	return &ParsedPublicKey{
+		Type:        keyType,
+		PublicKey:   normalised,
+		Fingerprint: ssh.FingerprintSHA256(key),
+	}, ""
+}
+
+// GenerateKeyPair creates a new key pair and returns the public key in
+// authorized_keys format and the private key in OpenSSH PEM format.
+func GenerateKeyPair(keyType, comment string) (*ParsedPublicKey, string, error) {
+	var private interface{}
+	var err error
+
+	switch keyType {
+	case "", KeyTypeED25519:
+		keyType = KeyTypeED25519
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case KeyTypeRSA:
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	case KeyTypeECDSA:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, "", fmt.Errorf("key type '%s' is not supported", keyType)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return nil, "", err
+	}
+
+	block, err := ssh.MarshalPrivateKey(private, comment)
+	if err != nil {
+		return nil, "", err
+	}
+
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if comment != "" {
+		publicKey += " " + comment
+	}
+
+	return &ParsedPublicKey{
+		Type:        keyType,
+		PublicKey:   publicKey,
+		Fingerprint: ssh.FingerprintSHA256(signer.PublicKey()),
+	}, string(pem.EncodeToMemory(block)), nil
+}
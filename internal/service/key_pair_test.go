@@ -0,0 +1,69 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateAndParseKeyPair(t *testing.T) {
+	for _, keyType := range []string{KeyTypeED25519, KeyTypeRSA, KeyTypeECDSA} {
+		t.Run(keyType, func(t *testing.T) {
+			generated, privateKey, err := GenerateKeyPair(keyType, "deploy")
+			if err != nil {
+				t.Fatalf("Failed to generate key pair: %v", err)
+			}
+
+			signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+			if err != nil {
+				t.Fatalf("Generated private key does not parse: %v", err)
+			}
+			if ssh.FingerprintSHA256(signer.PublicKey()) != generated.Fingerprint {
+				t.Errorf("Private and public key fingerprints differ")
+			}
+
+			parsed, errorMessage := ParsePublicKey(generated.PublicKey)
+			if errorMessage != "" {
+				t.Fatalf("Generated public key rejected: %s", errorMessage)
+			}
+			if parsed.Type != keyType {
+				t.Errorf("Expected type %s, got %s", keyType, parsed.Type)
+			}
+			if parsed.Fingerprint != generated.Fingerprint || !strings.HasPrefix(parsed.Fingerprint, "SHA256:") {
+				t.Errorf("Unexpected fingerprint %s", parsed.Fingerprint)
+			}
+			if !strings.HasSuffix(parsed.PublicKey, " deploy") {
+				t.Errorf("Expected comment to be kept, got %q", parsed.PublicKey)
+			}
+		})
+	}
+}
+
+func TestParsePublicKeyRejects(t *testing.T) {
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	weakPublic, err := ssh.NewPublicKey(&weak.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to convert RSA key: %v", err)
+	}
+
+	tests := map[string]string{
+		"garbage":         "not a key",
+		"short rsa":       string(ssh.MarshalAuthorizedKey(weakPublic)),
+		"truncated blob":  "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIB",
+		"with options":    `command="ls" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIMb2E4Ht0LTtDjXbGoLZpX0Cq4Yq1w1hD2QmJYcv6b0F`,
+		"unsupported dsa": "ssh-dss AAAAB3NzaC1kc3MAAACBAP",
+	}
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, errorMessage := ParsePublicKey(key); errorMessage == "" {
+				t.Errorf("Expected %q to be rejected", key)
+			}
+		})
+	}
+}
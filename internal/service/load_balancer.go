@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	TargetHealthy   = "healthy"
+	TargetUnhealthy = "unhealthy"
+)
+
+const (
+	ListenerHTTP  = "HTTP"
+	ListenerHTTPS = "HTTPS"
+	ListenerTCP   = "TCP"
+	ListenerUDP   = "UDP"
+)
+
+// ValidateListener normalises the protocol and returns an error message when
+// the protocol or port is not acceptable.
+func ValidateListener(protocol *string, port int) string {
+	*protocol = strings.ToUpper(strings.TrimSpace(*protocol))
+	switch *protocol {
+	case ListenerHTTP, ListenerHTTPS, ListenerTCP, ListenerUDP:
+	default:
+		return fmt.Sprintf("Protocol '%s' is not supported. Use HTTP, HTTPS, TCP or UDP.", *protocol)
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Sprintf("Port %d is out of range.", port)
+	}
+	return ""
+}
+
+// TargetHealth derives a target's health from its server's status. Only
+// running servers pass, and a simulated failure overrides that.
+func TargetHealth(serverStatus string, simulatedFailure bool) (string, string) {
+	if serverStatus != StatusRunning {
+		return TargetUnhealthy, fmt.Sprintf("Server status is '%s'.", serverStatus)
+	}
+	if simulatedFailure {
+		return TargetUnhealthy, "Health check failing (simulated)."
+	}
+	return TargetHealthy, "Health checks passing."
+}
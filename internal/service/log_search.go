@@ -0,0 +1,212 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+// logSearchFields maps the field qualifiers of a log search to columns.
+var logSearchFields = map[string]string{
+	"eventtype": "event_type",
+	"server":    "server_id",
+	"actor":     "actor",
+	"status":    "new_status",
+	"requestid": "request_id",
+}
+
+// LogQuery is a parsed log search. Terms and Phrases are matched against the
+// message; Fields must match their column exactly.
+type LogQuery struct {
+	Terms   []string
+	Phrases [][]string
+	Fields  map[string]string
+}
+
+// HasText reports whether the query searches the message at all.
+func (q *LogQuery) HasText() bool {
+	return len(q.Terms) > 0 || len(q.Phrases) > 0
+}
+
+// Tokens returns every term and phrase word, without repeats.
+func (q *LogQuery) Tokens() []string {
+	seen := map[string]bool{}
+	var tokens []string
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	for _, term := range q.Terms {
+		add(term)
+	}
+	for _, phrase := range q.Phrases {
+		for _, token := range phrase {
+			add(token)
+		}
+	}
+	return tokens
+}
+
+// ParseLogQuery parses a search such as
+// `eventType:ACTION_DENIED server:<id> "already running"`. Words are matched
+// anywhere in the message, quoted phrases word for word, and field:value
+// pairs against the entry's fields.
+func ParseLogQuery(input string) (*LogQuery, error) {
+	query := &LogQuery{Fields: map[string]string{}}
+	rest := strings.TrimSpace(input)
+	for rest != "" {
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated phrase: %s", rest)
+			}
+			if tokens := Tokenize(rest[1 : end+1]); len(tokens) > 0 {
+				query.Phrases = append(query.Phrases, tokens)
+			}
+			rest = strings.TrimSpace(rest[end+2:])
+			continue
+		}
+
+		word := rest
+		if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+			word = rest[:i]
+		}
+		rest = strings.TrimSpace(rest[len(word):])
+
+		if name, value, ok := strings.Cut(word, ":"); ok {
+			column, known := logSearchFields[strings.ToLower(name)]
+			if !known {
+				return nil, fmt.Errorf("unknown field '%s'; use eventType, server, actor, status or requestId", name)
+			}
+			value = strings.Trim(value, `"`)
+			if value == "" {
+				return nil, fmt.Errorf("field '%s' needs a value", name)
+			}
+			switch column {
+			case "event_type":
+				value = strings.ToUpper(value)
+			case "new_status":
+				value = strings.ToLower(value)
+			}
+			query.Fields[column] = value
+			continue
+		}
+		query.Terms = append(query.Terms, Tokenize(word)...)
+	}
+	if !query.HasText() && len(query.Fields) == 0 {
+		return nil, fmt.Errorf("the search is empty")
+	}
+	return query, nil
+}
+
+// Tokenize splits text into lower-case words of letters and digits. It is the
+// tokenizer of the search index used when PostgreSQL is not available.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ServerLogTokens are the token index rows of an entry, one per distinct word
+// of its message.
+func ServerLogTokens(entry *models.ServerLog) []models.ServerLogToken {
+	seen := map[string]bool{}
+	var tokens []models.ServerLogToken
+	for _, token := range Tokenize(entry.Message) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, models.ServerLogToken{Token: token, ServerLogID: entry.ID})
+		}
+	}
+	return tokens
+}
+
+// MatchLog scores a message against the text of the query. It returns false
+// unless every term and phrase occurs. The score counts occurrences, with
+// phrases weighted by their length, relative to the length of the message.
+func MatchLog(query *LogQuery, message string) (float64, bool) {
+	words := Tokenize(message)
+	if len(words) == 0 {
+		return 0, !query.HasText()
+	}
+	hits := 0
+	for _, term := range query.Terms {
+		count := 0
+		for _, word := range words {
+			if word == term {
+				count++
+			}
+		}
+		if count == 0 {
+			return 0, false
+		}
+		hits += count
+	}
+	for _, phrase := range query.Phrases {
+		count := 0
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			if equalWords(words[i:i+len(phrase)], phrase) {
+				count++
+			}
+		}
+		if count == 0 {
+			return 0, false
+		}
+		hits += count * len(phrase)
+	}
+	return float64(hits) / float64(len(words)), true
+}
+
+// HighlightLog HTML-escapes message and wraps every word of it that the
+// query matched in <mark></mark>. Messages hold caller-supplied text such as
+// names and tags, so only the marks are markup.
+func HighlightLog(query *LogQuery, message string) string {
+	tokens := map[string]bool{}
+	for _, token := range query.Tokens() {
+		tokens[token] = true
+	}
+	if len(tokens) == 0 {
+		return html.EscapeString(message)
+	}
+
+	var out strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := message[start:end]
+		if tokens[strings.ToLower(word)] {
+			out.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			out.WriteString(html.EscapeString(word))
+		}
+		start = -1
+	}
+	for i, r := range message {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		out.WriteString(html.EscapeString(string(r)))
+	}
+	flush(len(message))
+	return out.String()
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
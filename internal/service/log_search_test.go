@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestParseLogQuery(t *testing.T) {
+	query, err := ParseLogQuery(`eventType:action_denied server:abc "Already running" start`)
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	if query.Fields["event_type"] != "ACTION_DENIED" || query.Fields["server_id"] != "abc" {
+		t.Errorf("Unexpected fields %v", query.Fields)
+	}
+	if len(query.Phrases) != 1 || len(query.Phrases[0]) != 2 || query.Phrases[0][1] != "running" {
+		t.Errorf("Unexpected phrases %v", query.Phrases)
+	}
+	if len(query.Terms) != 1 || query.Terms[0] != "start" {
+		t.Errorf("Unexpected terms %v", query.Terms)
+	}
+
+	for _, input := range []string{"", `"unterminated`, "colour:red", "actor:"} {
+		if _, err := ParseLogQuery(input); err == nil {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestMatchLog(t *testing.T) {
+	query, _ := ParseLogQuery(`"already running" start`)
+
+	tests := []struct {
+		message string
+		match   bool
+	}{
+		{"Action 'start' denied: Server is already running. (INVALID_TRANSITION)", true},
+		{"Action 'start' denied: running is already over.", false},
+		{"Server is already running.", false},
+	}
+	for _, tt := range tests {
+		if _, ok := MatchLog(query, tt.message); ok != tt.match {
+			t.Errorf("MatchLog(%q) = %v, expected %v", tt.message, ok, tt.match)
+		}
+	}
+
+	short, _ := MatchLog(query, "start: already running")
+	long, _ := MatchLog(query, "Action 'start' denied: Server is already running, try again later.")
+	if short <= long {
+		t.Errorf("Expected the shorter message to rank higher, got %v and %v", short, long)
+	}
+
+	highlighted := HighlightLog(query, "Start denied: Server is already running.")
+	if highlighted != "<mark>Start</mark> denied: Server is <mark>already</mark> <mark>running</mark>." {
+		t.Errorf("Unexpected highlight %q", highlighted)
+	}
+	highlighted = HighlightLog(query, `Server <script>alert("x")</script> is running & ready.`)
+	if highlighted != `Server &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; is <mark>running</mark> &amp; ready.` {
+		t.Errorf("Highlight did not escape the message: %q", highlighted)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	MinMetadataSessionTTL = time.Second
+	MaxMetadataSessionTTL = 6 * time.Hour
+)
+
+type metadataSession struct {
+	serverID  string
+	expiresAt time.Time
+}
+
+// MetadataSessionStore keeps the short-lived session tokens handed out by the
+// metadata service. Sessions live in memory only and are lost on restart,
+// which callers are expected to handle by requesting a new token.
+type MetadataSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]metadataSession
+	now      func() time.Time
+}
+
+func NewMetadataSessionStore() *MetadataSessionStore {
+	return &MetadataSessionStore{
+		sessions: make(map[string]metadataSession),
+		now:      time.Now,
+	}
+}
+
+func (s *MetadataSessionStore) Issue(serverID string, ttl time.Duration) (string, error) {
+	token, _, err := NewSecretToken()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, session := range s.sessions {
+		if !now.Before(session.expiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	s.sessions[token] = metadataSession{serverID: serverID, expiresAt: now.Add(ttl)}
+	return token, nil
+}
+
+// Lookup returns the server a session token belongs to, if it is still valid.
+func (s *MetadataSessionStore) Lookup(token string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[token]
+	if !ok {
+		return "", false
+	}
+	if !s.now().Before(session.expiresAt) {
+		delete(s.sessions, token)
+		return "", false
+	}
+	return session.serverID, true
+}
+
+// RevokeServer drops every session of a server, e.g. after its token is rotated.
+func (s *MetadataSessionStore) RevokeServer(serverID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, session := range s.sessions {
+		if session.serverID == serverID {
+			delete(s.sessions, key)
+		}
+	}
+}
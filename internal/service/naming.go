@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxServerNameLength  = 64
+	MaxDescriptionLength = 255
+)
+
+var serverNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateHostname checks a host name against RFC 1123: dot-separated labels
+// of letters, digits and hyphens, each 1-63 characters, not starting or ending
+// with a hyphen, 253 characters in total. Labels may start with a digit.
+func ValidateHostname(hostname string) string {
+	if len(hostname) > 253 {
+		return fmt.Sprintf("Hostname '%s' is longer than 253 characters.", hostname)
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Sprintf("Hostname '%s' has an empty or over-long label.", hostname)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Sprintf("Hostname label '%s' must not start or end with a hyphen.", label)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Sprintf("Hostname label '%s' contains invalid character '%c'.", label, r)
+			}
+		}
+	}
+	return ""
+}
+
+// ValidateServerName keeps names short, free of whitespace and distinct from
+// server IDs so that lookups by ID or name are never ambiguous.
+func ValidateServerName(name string) string {
+	return validateName("Server name", name)
+}
+
+// ValidateKeyPairName holds key pair names to the rules of server names. They
+// appear in URLs and in the comment of authorized_keys lines, so a '/' or a
+// line break would make a key pair unreachable or break the file.
+func ValidateKeyPairName(name string) string {
+	return validateName("Key pair name", name)
+}
+
+func validateName(kind, name string) string {
+	if len(name) > MaxServerNameLength {
+		return fmt.Sprintf("%s must be at most %d characters.", kind, MaxServerNameLength)
+	}
+	if !serverNamePattern.MatchString(name) {
+		return kind + " must start with a letter or digit and contain only letters, digits, '.', '_' or '-'."
+	}
+	if _, err := uuid.Parse(name); err == nil {
+		return kind + " must not be a UUID."
+	}
+	return ""
+}
+
+func ValidateDescription(description string) string {
+	if len(description) > MaxDescriptionLength {
+		return fmt.Sprintf("Description must be at most %d characters.", MaxDescriptionLength)
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateHostname(t *testing.T) {
+	valid := []string{"web-1", "3com", "db.prod.example.com", "A1", strings.Repeat("a", 63)}
+	invalid := []string{"", "-web", "web-", "web_1", "web..prod", "web 1", strings.Repeat("a", 64), strings.Repeat("a.", 127) + "ab"}
+
+	for _, hostname := range valid {
+		if errorMessage := ValidateHostname(hostname); errorMessage != "" {
+			t.Errorf("Expected %q to be valid, got %q", hostname, errorMessage)
+		}
+	}
+	for _, hostname := range invalid {
+		if ValidateHostname(hostname) == "" {
+			t.Errorf("Expected %q to be rejected", hostname)
+		}
+	}
+}
+
+func TestValidateServerName(t *testing.T) {
+	valid := []string{"api", "api-1", "billing_worker.2"}
+	invalid := []string{"", " api", "-api", "my server", "a2f1c1a8-5f3e-4f57-9d55-0c8e3f7b2a11", strings.Repeat("x", MaxServerNameLength+1)}
+
+	for _, name := range valid {
+		if errorMessage := ValidateServerName(name); errorMessage != "" {
+			t.Errorf("Expected %q to be valid, got %q", name, errorMessage)
+		}
+	}
+	for _, name := range invalid {
+		if ValidateServerName(name) == "" {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestValidateKeyPairName(t *testing.T) {
+	for _, name := range []string{"laptop", "ci.deploy-2"} {
+		if errorMessage := ValidateKeyPairName(name); errorMessage != "" {
+			t.Errorf("Expected %q to be valid, got %q", name, errorMessage)
+		}
+	}
+	for _, name := range []string{"", "team/laptop", "laptop\nssh-rsa AAAA", "my key"} {
+		if errorMessage := ValidateKeyPairName(name); !strings.HasPrefix(errorMessage, "Key pair name") {
+			t.Errorf("Expected %q to be rejected as a key pair name, got %q", name, errorMessage)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// RandomPrivateIP picks an address in 10.0.0.0/8, avoiding network and
+// broadcast-looking host parts.
+func RandomPrivateIP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<24))
+	if err != nil {
+		return "", err
+	}
+	v := n.Int64()
+	if v&0xff == 0 || v&0xff == 0xff {
+		v ^= 1
+	}
+	return fmt.Sprintf("10.%d.%d.%d", (v>>16)&0xff, (v>>8)&0xff, v&0xff), nil
+}
+
+// RandomPublicIP picks an address in 198.18.0.0/15, a range reserved for
+// testing that will never collide with a real public address.
+func RandomPublicIP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<17))
+	if err != nil {
+		return "", err
+	}
+	v := n.Int64()
+	if v&0xff == 0 || v&0xff == 0xff {
+		v ^= 1
+	}
+	return fmt.Sprintf("198.%d.%d.%d", 18+((v>>16)&0x1), (v>>8)&0xff, v&0xff), nil
+}
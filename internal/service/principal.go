@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	PrincipalAPIKey    = "api-key"
+	PrincipalJWT       = "jwt"
+	PrincipalAnonymous = "anonymous"
+	PrincipalSystem    = "system"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// SystemActor is recorded on log entries written by the app itself rather
+// than on behalf of a caller.
+const SystemActor = PrincipalSystem
+
+// APIKeyPrefix starts every API key so leaked keys are easy to spot.
+const APIKeyPrefix = "vs_"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Type    string   `json:"type"`
+	Subject string   `json:"subject"`
+	Scopes  []string `json:"scopes"`
+	Roles   []string `json:"roles"`
+	// ProjectID confines the principal to one project. Without it the
+	// principal is global and sees every project.
+	ProjectID string `json:"projectId,omitempty"`
+}
+
+// Actor identifies the principal in the lifecycle log.
+func (p *Principal) Actor() string {
+	if p == nil {
+		return PrincipalAnonymous
+	}
+	if p.Subject == "" {
+		return p.Type
+	}
+	return p.Type + ":" + p.Subject
+}
+
+// Project is the project the principal is confined to, or "" when it is
+// global or nil.
+func (p *Principal) Project() string {
+	if p == nil {
+		return ""
+	}
+	return p.ProjectID
+}
+
+// HasScope reports whether the principal holds scope. admin implies write and
+// write implies read.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		switch {
+		case s == scope, s == ScopeAdmin:
+			return true
+		case s == ScopeWrite && scope == ScopeRead:
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeForMethod is the scope an HTTP method requires.
+func ScopeForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	}
+	return ScopeWrite
+}
+
+// ValidateScopes checks that every scope is known and that there is at least one.
+func ValidateScopes(scopes []string) string {
+	if len(scopes) == 0 {
+		return "At least one scope is required."
+	}
+	for _, scope := range scopes {
+		if scope != ScopeRead && scope != ScopeWrite && scope != ScopeAdmin {
+			return fmt.Sprintf("Unknown scope '%s'. Use one of %s.", scope, strings.Join([]string{ScopeRead, ScopeWrite, ScopeAdmin}, ", "))
+		}
+	}
+	return ""
+}
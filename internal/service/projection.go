@@ -0,0 +1,158 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+// The server fields recorded in ServerLog.Changes.
+const (
+	FieldStatus                = "status"
+	FieldName                  = "name"
+	FieldHostname              = "hostname"
+	FieldRegion                = "region"
+	FieldType                  = "type"
+	FieldImage                 = "image"
+	FieldTags                  = "tags"
+	FieldTerminationProtection = "terminationProtection"
+	FieldExpiresAt             = "expiresAt"
+)
+
+// ServerFields encodes the given fields of a server as they are recorded in
+// ServerLog.Changes. Without fields it encodes every projected field except
+// the status, which events carry in NewStatus.
+func ServerFields(server *models.Server, fields ...string) map[string]string {
+	if len(fields) == 0 {
+		fields = []string{FieldName, FieldHostname, FieldRegion, FieldType, FieldImage, FieldTags, FieldTerminationProtection, FieldExpiresAt}
+	}
+	encoded := make(map[string]string, len(fields))
+	for _, field := range fields {
+		switch field {
+		case FieldStatus:
+			encoded[field] = server.Status
+		case FieldName:
+			encoded[field] = server.Name
+		case FieldHostname:
+			encoded[field] = server.Hostname
+		case FieldRegion:
+			encoded[field] = server.Region
+		case FieldType:
+			encoded[field] = server.Type
+		case FieldImage:
+			encoded[field] = server.Image
+		case FieldTags:
+			encoded[field] = EncodeTags(server.Tags)
+		case FieldTerminationProtection:
+			encoded[field] = strconv.FormatBool(server.TerminationProtection)
+		case FieldExpiresAt:
+			encoded[field] = EncodeExpiry(server.ExpiresAt)
+		}
+	}
+	return encoded
+}
+
+// EncodeTags encodes tags for ServerLog.Changes. Keys are sorted, so equal
+// tags encode the same.
+func EncodeTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return "{}"
+	}
+	encoded, _ := json.Marshal(tags)
+	return string(encoded)
+}
+
+// EncodeExpiry encodes an expiry for ServerLog.Changes; no expiry is "".
+func EncodeExpiry(expiresAt *time.Time) string {
+	if expiresAt == nil {
+		return ""
+	}
+	return expiresAt.UTC().Format(time.RFC3339)
+}
+
+// ServerProjection is a server's state rebuilt from its events. Fields lists
+// the fields the events determined; servers created before events recorded
+// their fields only know those changed since.
+type ServerProjection struct {
+	ID                    string            `json:"id"`
+	Status                string            `json:"status,omitempty"`
+	Name                  string            `json:"name,omitempty"`
+	Hostname              string            `json:"hostname,omitempty"`
+	Region                string            `json:"region,omitempty"`
+	Type                  string            `json:"type,omitempty"`
+	Image                 string            `json:"image,omitempty"`
+	Tags                  map[string]string `json:"tags,omitempty"`
+	TerminationProtection bool              `json:"terminationProtection"`
+	ExpiresAt             *time.Time        `json:"expiresAt,omitempty"`
+	CreatedAt             *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt             time.Time         `json:"updatedAt"`
+	Events                int               `json:"events"`
+	LastSequence          int64             `json:"lastSequence"`
+	Fields                []string          `json:"fields"`
+
+	state map[string]string
+}
+
+// ProjectServer replays a server's events, oldest first.
+func ProjectServer(serverID string, events []models.ServerLog) *ServerProjection {
+	projection := &ServerProjection{ID: serverID, state: map[string]string{}}
+	for _, event := range events {
+		if event.EventType == "SERVER_CREATED" {
+			createdAt := event.CreatedAt
+			projection.CreatedAt = &createdAt
+		}
+		if event.NewStatus != "" {
+			projection.state[FieldStatus] = event.NewStatus
+		}
+		for field, value := range event.Changes {
+			projection.state[field] = value
+		}
+		projection.Events++
+		projection.UpdatedAt = event.CreatedAt
+		if event.Sequence > 0 {
+			projection.LastSequence = event.Sequence
+		}
+	}
+
+	state := projection.state
+	projection.Status = state[FieldStatus]
+	projection.Name = state[FieldName]
+	projection.Hostname = state[FieldHostname]
+	projection.Region = state[FieldRegion]
+	projection.Type = state[FieldType]
+	projection.Image = state[FieldImage]
+	if tags, ok := state[FieldTags]; ok {
+		json.Unmarshal([]byte(tags), &projection.Tags)
+	}
+	projection.TerminationProtection = state[FieldTerminationProtection] == "true"
+	if expiresAt, err := time.Parse(time.RFC3339, state[FieldExpiresAt]); err == nil {
+		projection.ExpiresAt = &expiresAt
+	}
+	for field := range state {
+		projection.Fields = append(projection.Fields, field)
+	}
+	sort.Strings(projection.Fields)
+	return projection
+}
+
+// ServerDrift is a field whose projected value differs from the servers table.
+type ServerDrift struct {
+	Field     string `json:"field"`
+	Projected string `json:"projected"`
+	Actual    string `json:"actual"`
+}
+
+// Drift compares the fields the projection knows with the server as stored.
+func (p *ServerProjection) Drift(server *models.Server) []ServerDrift {
+	actual := ServerFields(server, p.Fields...)
+	var drift []ServerDrift
+	for _, field := range p.Fields {
+		if p.state[field] != actual[field] {
+			drift = append(drift, ServerDrift{Field: field, Projected: p.state[field], Actual: actual[field]})
+		}
+	}
+	return drift
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestProjectServer(t *testing.T) {
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []models.ServerLog{
+		{Sequence: 1, EventType: "SERVER_CREATED", NewStatus: StatusRunning, CreatedAt: start,
+			Changes: map[string]string{FieldName: "web", FieldRegion: "India", FieldType: "basic", FieldTags: "{}", FieldTerminationProtection: "false"}},
+		{Sequence: 2, EventType: "TAGS_UPDATED", CreatedAt: start.Add(time.Minute),
+			Changes: map[string]string{FieldTags: `{"env":"prod"}`}},
+		{Sequence: 3, EventType: "ACTION_DENIED", OldStatus: StatusRunning, CreatedAt: start.Add(2 * time.Minute)},
+		{Sequence: 4, EventType: "STATUS_CHANGE", OldStatus: StatusRunning, NewStatus: StatusStopped, CreatedAt: start.Add(3 * time.Minute)},
+		{Sequence: 5, EventType: "SERVER_RESIZED", CreatedAt: start.Add(4 * time.Minute),
+			Changes: map[string]string{FieldType: "plus"}},
+	}
+
+	projection := ProjectServer("server-1", events)
+	if projection.Status != StatusStopped || projection.Type != "plus" || projection.Name != "web" || projection.Tags["env"] != "prod" {
+		t.Errorf("Unexpected projection %+v", projection)
+	}
+	if projection.CreatedAt == nil || !projection.CreatedAt.Equal(start) || projection.Events != 5 || projection.LastSequence != 5 {
+		t.Errorf("Unexpected projection history %+v", projection)
+	}
+
+	earlier := ProjectServer("server-1", events[:2])
+	if earlier.Status != StatusRunning || earlier.Type != "basic" {
+		t.Errorf("Unexpected earlier projection %+v", earlier)
+	}
+
+	server := &models.Server{ID: "server-1", Name: "web", Region: "India", Type: "plus", Status: StatusStopped,
+		Tags: map[string]string{"env": "prod"}, Hostname: "ignored"}
+	if drift := projection.Drift(server); len(drift) != 0 {
+		t.Errorf("Expected no drift, got %+v", drift)
+	}
+	server.Type = "prime"
+	server.Tags = nil
+	drift := projection.Drift(server)
+	if len(drift) != 2 || drift[0].Field != FieldTags || drift[1].Field != FieldType || drift[1].Projected != "plus" || drift[1].Actual != "prime" {
+		t.Errorf("Expected tags and type to drift, got %+v", drift)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	CodeTerminationProtected = "TERMINATION_PROTECTED"
+	CodeConfirmationRequired = "CONFIRMATION_REQUIRED"
+)
+
+// DefaultProductionSelector picks the servers whose termination must be
+// confirmed when no other selector is configured.
+const DefaultProductionSelector = "env in (prod,production)"
+
+const TerminationConfirmationTTL = 5 * time.Minute
+
+type confirmation struct {
+	serverID  string
+	expiresAt time.Time
+}
+
+// ConfirmationStore holds single-use tokens confirming the termination of one
+// particular server. Like metadata sessions they live in memory only.
+type ConfirmationStore struct {
+	mu     sync.Mutex
+	tokens map[string]confirmation
+	now    func() time.Time
+}
+
+func NewConfirmationStore() *ConfirmationStore {
+	return &ConfirmationStore{
+		tokens: make(map[string]confirmation),
+		now:    time.Now,
+	}
+}
+
+func (s *ConfirmationStore) Issue(serverID string) (string, time.Time, error) {
+	token, _, err := NewSecretToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, c := range s.tokens {
+		if !now.Before(c.expiresAt) {
+			delete(s.tokens, key)
+		}
+	}
+	expiresAt := now.Add(TerminationConfirmationTTL)
+	s.tokens[token] = confirmation{serverID: serverID, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+// Valid reports whether token confirms the termination of serverID, without
+// using it up.
+func (s *ConfirmationStore) Valid(serverID, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.tokens[token]
+	return ok && c.serverID == serverID && s.now().Before(c.expiresAt)
+}
+
+// Consume reports whether token confirms the termination of serverID. A token
+// can only be used once, whether or not it matched.
+func (s *ConfirmationStore) Consume(serverID, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.tokens[token]
+	if !ok {
+		return false
+	}
+	delete(s.tokens, token)
+	return c.serverID == serverID && s.now().Before(c.expiresAt)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfirmationStore(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := NewConfirmationStore()
+	store.now = func() time.Time { return now }
+
+	token, _, err := store.Issue("server-a")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if store.Consume("server-b", token) {
+		t.Errorf("Token confirmed the wrong server")
+	}
+	if store.Consume("server-a", token) {
+		t.Errorf("Token was reusable after a failed attempt")
+	}
+
+	token, _, _ = store.Issue("server-a")
+	if store.Valid("server-b", token) || store.Valid("server-a", "invalid") {
+		t.Errorf("Valid accepted the wrong server or token")
+	}
+	if !store.Valid("server-a", token) || !store.Valid("server-a", token) {
+		t.Errorf("Valid should accept the token without using it up")
+	}
+	if !store.Consume("server-a", token) {
+		t.Errorf("Expected token to confirm server-a")
+	}
+	if store.Consume("server-a", token) {
+		t.Errorf("Token was usable twice")
+	}
+
+	token, _, _ = store.Issue("server-a")
+	now = now.Add(TerminationConfirmationTTL)
+	if store.Consume("server-a", token) {
+		t.Errorf("Expired token was accepted")
+	}
+}
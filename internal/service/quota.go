@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+// CodeQuotaExceeded marks a request refused because it would take a project
+// over one of its quotas.
+const CodeQuotaExceeded = "QUOTA_EXCEEDED"
+
+// instanceVCPUs is the number of vCPUs of each instance type.
+var instanceVCPUs = map[string]int{
+	"basic": 1,
+	"plus":  2,
+	"prime": 4,
+}
+
+// VCPUs returns the number of vCPUs of an instance type.
+func VCPUs(instanceType string) int {
+	return instanceVCPUs[instanceType]
+}
+
+// QuotaUsage is what a project currently holds. Every server that is not
+// terminated counts, whether or not it is running. MonthlySpend is the
+// projected spend for the month: what has been billed so far plus the
+// current rates until the end of the month.
+type QuotaUsage struct {
+	Servers        int            `json:"servers"`
+	ServersPerType map[string]int `json:"serversPerType"`
+	VCPUsPerRegion map[string]int `json:"vcpusPerRegion"`
+	MonthlySpend   float64        `json:"monthlySpend"`
+}
+
+func NewQuotaUsage() QuotaUsage {
+	return QuotaUsage{ServersPerType: map[string]int{}, VCPUsPerRegion: map[string]int{}}
+}
+
+// AddServer counts a server of the given type in the given region.
+func (u *QuotaUsage) AddServer(instanceType, region string, count int) {
+	u.Servers += count
+	u.ServersPerType[instanceType] += count
+	u.VCPUsPerRegion[region] += count * VCPUs(instanceType)
+}
+
+func (u QuotaUsage) Clone() QuotaUsage {
+	clone := u
+	clone.ServersPerType = make(map[string]int, len(u.ServersPerType))
+	for k, v := range u.ServersPerType {
+		clone.ServersPerType[k] = v
+	}
+	clone.VCPUsPerRegion = make(map[string]int, len(u.VCPUsPerRegion))
+	for k, v := range u.VCPUsPerRegion {
+		clone.VCPUsPerRegion[k] = v
+	}
+	return clone
+}
+
+// QuotaStatus is one limit of a project next to its usage.
+type QuotaStatus struct {
+	Quota string  `json:"quota"`
+	Limit float64 `json:"limit"`
+	Usage float64 `json:"usage"`
+}
+
+// QuotaViolation describes the limit a change would exceed.
+type QuotaViolation struct {
+	QuotaStatus
+	Requested float64 `json:"requested"`
+}
+
+func (v *QuotaViolation) Message() string {
+	return fmt.Sprintf("Quota '%s' exceeded: limit %s, current usage %s, requested %s.",
+		v.Quota, formatQuantity(v.Limit), formatQuantity(v.Usage), formatQuantity(v.Requested))
+}
+
+// QuotaStatuses lists every limit the quota defines with its current usage,
+// in a stable order.
+func QuotaStatuses(quota *models.ProjectQuota, usage QuotaUsage) []QuotaStatus {
+	var statuses []QuotaStatus
+	if quota == nil {
+		return statuses
+	}
+	if quota.MaxServers != nil {
+		statuses = append(statuses, QuotaStatus{"servers", float64(*quota.MaxServers), float64(usage.Servers)})
+	}
+	for _, instanceType := range sortedKeys(quota.MaxServersPerType) {
+		statuses = append(statuses, QuotaStatus{"servers." + instanceType,
+			float64(quota.MaxServersPerType[instanceType]), float64(usage.ServersPerType[instanceType])})
+	}
+	if quota.MaxVCPUsPerRegion != nil {
+		for _, region := range sortedKeys(usage.VCPUsPerRegion) {
+			statuses = append(statuses, QuotaStatus{"vcpus." + region,
+				float64(*quota.MaxVCPUsPerRegion), float64(usage.VCPUsPerRegion[region])})
+		}
+	}
+	if quota.MaxMonthlySpend != nil {
+		statuses = append(statuses, QuotaStatus{"monthlySpend", *quota.MaxMonthlySpend, roundCents(usage.MonthlySpend)})
+	}
+	return statuses
+}
+
+// CheckQuota compares usage before and after a change. Only usage that grows
+// past a limit is refused, so a project already over a lowered quota can
+// still shrink.
+func CheckQuota(quota *models.ProjectQuota, before, after QuotaUsage) *QuotaViolation {
+	if quota == nil {
+		return nil
+	}
+	check := func(name string, limit, was, will float64) *QuotaViolation {
+		if will > limit && will > was {
+			return &QuotaViolation{QuotaStatus{name, limit, was}, will - was}
+		}
+		return nil
+	}
+
+	if quota.MaxServers != nil {
+		if v := check("servers", float64(*quota.MaxServers), float64(before.Servers), float64(after.Servers)); v != nil {
+			return v
+		}
+	}
+	for _, instanceType := range sortedKeys(quota.MaxServersPerType) {
+		limit := float64(quota.MaxServersPerType[instanceType])
+		if v := check("servers."+instanceType, limit, float64(before.ServersPerType[instanceType]), float64(after.ServersPerType[instanceType])); v != nil {
+			return v
+		}
+	}
+	if quota.MaxVCPUsPerRegion != nil {
+		for _, region := range sortedKeys(after.VCPUsPerRegion) {
+			if v := check("vcpus."+region, float64(*quota.MaxVCPUsPerRegion), float64(before.VCPUsPerRegion[region]), float64(after.VCPUsPerRegion[region])); v != nil {
+				return v
+			}
+		}
+	}
+	if quota.MaxMonthlySpend != nil {
+		if v := check("monthlySpend", *quota.MaxMonthlySpend, roundCents(before.MonthlySpend), roundCents(after.MonthlySpend)); v != nil {
+			v.Requested = roundCents(v.Requested)
+			return v
+		}
+	}
+	return nil
+}
+
+// ValidateQuota rejects negative limits and unknown instance types.
+func ValidateQuota(quota *models.ProjectQuota) string {
+	if quota.MaxServers != nil && *quota.MaxServers < 0 {
+		return "maxServers cannot be negative."
+	}
+	for instanceType, limit := range quota.MaxServersPerType {
+		if _, ok := instanceVCPUs[instanceType]; !ok {
+			return fmt.Sprintf("Unknown instance type '%s' in maxServersPerType.", instanceType)
+		}
+		if limit < 0 {
+			return fmt.Sprintf("maxServersPerType for '%s' cannot be negative.", instanceType)
+		}
+	}
+	if quota.MaxVCPUsPerRegion != nil && *quota.MaxVCPUsPerRegion < 0 {
+		return "maxVcpusPerRegion cannot be negative."
+	}
+	if quota.MaxMonthlySpend != nil && *quota.MaxMonthlySpend < 0 {
+		return "maxMonthlySpend cannot be negative."
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func formatQuantity(quantity float64) string {
+	if quantity == math.Trunc(quantity) {
+		return fmt.Sprintf("%d", int64(quantity))
+	}
+	return fmt.Sprintf("%.2f", quantity)
+}
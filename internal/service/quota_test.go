@@ -0,0 +1,88 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestCheckQuota(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+	spend := 100.0
+	quota := &models.ProjectQuota{
+		MaxServers:        intPtr(3),
+		MaxServersPerType: map[string]int{"prime": 1},
+		MaxVCPUsPerRegion: intPtr(6),
+		MaxMonthlySpend:   &spend,
+	}
+
+	before := NewQuotaUsage()
+	before.AddServer("prime", "India", 1)
+	before.AddServer("basic", "India", 1)
+	before.MonthlySpend = 60
+
+	tests := []struct {
+		name   string
+		change func(*QuotaUsage)
+		quota  string
+	}{
+		{"within limits", func(u *QuotaUsage) { u.AddServer("basic", "India", 1) }, ""},
+		{"too many servers", func(u *QuotaUsage) { u.AddServer("basic", "US", 2) }, "servers"},
+		{"too many of a type", func(u *QuotaUsage) { u.AddServer("prime", "US", 1) }, "servers.prime"},
+		{"too many vCPUs", func(u *QuotaUsage) {
+			u.AddServer("plus", "India", 1)
+			u.AddServer("basic", "India", -1)
+			u.AddServer("plus", "India", 1)
+		}, "vcpus.India"},
+		{"over budget", func(u *QuotaUsage) { u.AddServer("basic", "US", 1); u.MonthlySpend += 50 }, "monthlySpend"},
+		{"shrinking", func(u *QuotaUsage) { u.AddServer("prime", "India", -1) }, ""},
+	}
+	for _, tt := range tests {
+		after := before.Clone()
+		tt.change(&after)
+		violation := CheckQuota(quota, before, after)
+		switch {
+		case tt.quota == "" && violation != nil:
+			t.Errorf("%s: unexpected violation %s", tt.name, violation.Message())
+		case tt.quota != "" && (violation == nil || violation.Quota != tt.quota):
+			t.Errorf("%s: expected a violation of %s, got %+v", tt.name, tt.quota, violation)
+		}
+	}
+
+	if before.ServersPerType["basic"] != 1 {
+		t.Error("Clone shared maps with the original usage")
+	}
+
+	violation := CheckQuota(quota, before, func() QuotaUsage { u := before.Clone(); u.AddServer("prime", "US", 1); return u }())
+	if violation.Limit != 1 || violation.Usage != 1 || violation.Requested != 1 {
+		t.Errorf("Unexpected violation %+v", violation)
+	}
+
+	// A project already over a lowered limit may still shrink.
+	lowered := &models.ProjectQuota{MaxServers: intPtr(1)}
+	after := before.Clone()
+	after.AddServer("basic", "India", -1)
+	if v := CheckQuota(lowered, before, after); v != nil {
+		t.Errorf("Expected shrinking to be allowed over quota, got %s", v.Message())
+	}
+	if v := CheckQuota(nil, before, after); v != nil {
+		t.Error("Expected no violation without a quota")
+	}
+}
+
+func TestValidateQuota(t *testing.T) {
+	negative := -1
+	for _, quota := range []models.ProjectQuota{
+		{MaxServers: &negative},
+		{MaxServersPerType: map[string]int{"huge": 1}},
+		{MaxServersPerType: map[string]int{"basic": -1}},
+		{MaxVCPUsPerRegion: &negative},
+	} {
+		if ValidateQuota(&quota) == "" {
+			t.Errorf("Expected %+v to be invalid", quota)
+		}
+	}
+	if msg := ValidateQuota(&models.ProjectQuota{MaxServersPerType: map[string]int{"prime": 0}}); msg != "" {
+		t.Errorf("Unexpected error: %s", msg)
+	}
+}
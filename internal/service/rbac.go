@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+const (
+	PermissionServersRead     = "servers:read"
+	PermissionServersCreate   = "servers:create"
+	PermissionServersUpdate   = "servers:update"
+	PermissionServersSchedule = "servers:schedule"
+	PermissionLogsRead        = "logs:read"
+	PermissionProjectsRead    = "projects:read"
+	PermissionProjectsWrite   = "projects:write"
+	PermissionAuditRead       = "audit:read"
+	PermissionAccessLogsRead  = "access-logs:read"
+	PermissionAPIKeysWrite    = "apikeys:write"
+
+	PermissionSecurityGroupsRead  = "securitygroups:read"
+	PermissionSecurityGroupsWrite = "securitygroups:write"
+	PermissionLoadBalancersRead   = "loadbalancers:read"
+	PermissionLoadBalancersWrite  = "loadbalancers:write"
+	PermissionDNSRead             = "dns:read"
+	PermissionDNSWrite            = "dns:write"
+	PermissionKeyPairsRead        = "keypairs:read"
+	PermissionKeyPairsWrite       = "keypairs:write"
+	PermissionBillingRead         = "billing:read"
+)
+
+// CodePermissionDenied marks a request refused by the role policy.
+const CodePermissionDenied = "PERMISSION_DENIED"
+
+// ActionPermission is the permission needed to perform a server action, such
+// as servers:action:terminate.
+func ActionPermission(action string) string {
+	return "servers:action:" + action
+}
+
+// Policy maps roles to the permissions they grant, and optionally binds roles
+// to principals by actor (e.g. "jwt:alice"). A permission ending in "*"
+// grants every permission with that prefix.
+type Policy struct {
+	Roles    map[string][]string `yaml:"roles"`
+	Bindings map[string][]string `yaml:"bindings"`
+}
+
+// DefaultPolicy lets viewers read, operators manage servers and their
+// networking without terminating them, and admins do everything.
+func DefaultPolicy() *Policy {
+	viewer := []string{
+		PermissionServersRead,
+		PermissionLogsRead,
+		PermissionProjectsRead,
+		PermissionSecurityGroupsRead,
+		PermissionLoadBalancersRead,
+		PermissionDNSRead,
+		PermissionKeyPairsRead,
+		PermissionBillingRead,
+	}
+	operator := append(append([]string{}, viewer...),
+		PermissionServersCreate,
+		PermissionServersUpdate,
+		PermissionServersSchedule,
+		PermissionSecurityGroupsWrite,
+		PermissionLoadBalancersWrite,
+		PermissionDNSWrite,
+		PermissionKeyPairsWrite,
+	)
+	for _, action := range []string{ActionStart, ActionStop, ActionReboot, ActionHibernate, ActionResume, ActionResize, ActionRebuild, ActionRescue, ActionUnrescue} {
+		operator = append(operator, ActionPermission(action))
+	}
+	return &Policy{
+		Roles: map[string][]string{
+			RoleViewer:   viewer,
+			RoleOperator: operator,
+			RoleAdmin:    {"*"},
+		},
+	}
+}
+
+// ParsePolicy reads a YAML policy file. Every bound role must be defined.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if len(policy.Roles) == 0 {
+		return nil, fmt.Errorf("policy defines no roles")
+	}
+	for actor, roles := range policy.Bindings {
+		if errorMessage := policy.ValidateRoles(roles); errorMessage != "" {
+			return nil, fmt.Errorf("binding '%s': %s", actor, errorMessage)
+		}
+	}
+	return &policy, nil
+}
+
+// ValidateRoles checks that every role is defined by the policy.
+func (p *Policy) ValidateRoles(roles []string) string {
+	for _, role := range roles {
+		if _, ok := p.Roles[role]; !ok {
+			known := make([]string, 0, len(p.Roles))
+			for name := range p.Roles {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return fmt.Sprintf("Unknown role '%s'. Use one of %s.", role, strings.Join(known, ", "))
+		}
+	}
+	return ""
+}
+
+// RolesOf returns the principal's own roles plus any bound to it by the policy.
+func (p *Policy) RolesOf(principal *Principal) []string {
+	roles := append([]string{}, principal.Roles...)
+	for _, role := range p.Bindings[principal.Actor()] {
+		if !contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// Allows reports whether any of the principal's roles grants permission.
+func (p *Policy) Allows(principal *Principal, permission string) bool {
+	for _, role := range p.RolesOf(principal) {
+		for _, granted := range p.Roles[role] {
+			if granted == permission ||
+				(strings.HasSuffix(granted, "*") && strings.HasPrefix(permission, strings.TrimSuffix(granted, "*"))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanGrant reports whether the principal already holds every permission of
+// role, so handing the role on to a new credential gives nothing away.
+func (p *Policy) CanGrant(principal *Principal, role string) bool {
+	if contains(p.RolesOf(principal), role) {
+		return true
+	}
+	for _, permission := range p.Roles[role] {
+		if !p.Allows(principal, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// RolesForScopes gives credentials issued without roles the role matching
+// their broadest scope.
+func RolesForScopes(scopes []string) []string {
+	switch {
+	case contains(scopes, ScopeAdmin):
+		return []string{RoleAdmin}
+	case contains(scopes, ScopeWrite):
+		return []string{RoleOperator}
+	case contains(scopes, ScopeRead):
+		return []string{RoleViewer}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import "testing"
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{RoleViewer, PermissionServersRead, true},
+		{RoleViewer, PermissionLogsRead, true},
+		{RoleViewer, PermissionServersCreate, false},
+		{RoleViewer, ActionPermission(ActionStart), false},
+		{RoleOperator, PermissionServersCreate, true},
+		{RoleOperator, ActionPermission(ActionStart), true},
+		{RoleOperator, ActionPermission(ActionStop), true},
+		{RoleOperator, ActionPermission(ActionTerminate), false},
+		{RoleViewer, PermissionBillingRead, true},
+		{RoleViewer, PermissionSecurityGroupsRead, true},
+		{RoleViewer, PermissionSecurityGroupsWrite, false},
+		{RoleViewer, PermissionDNSWrite, false},
+		{RoleOperator, PermissionSecurityGroupsWrite, true},
+		{RoleViewer, PermissionAPIKeysWrite, false},
+		{RoleOperator, PermissionAPIKeysWrite, false},
+		{RoleAdmin, ActionPermission(ActionTerminate), true},
+		{RoleAdmin, "anything:at-all", true},
+		{"unknown", PermissionServersRead, false},
+	}
+	for _, tt := range tests {
+		principal := &Principal{Type: PrincipalAPIKey, Subject: "k", Roles: []string{tt.role}}
+		if got := policy.Allows(principal, tt.permission); got != tt.want {
+			t.Errorf("%s allowed %s = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestCanGrant(t *testing.T) {
+	policy := DefaultPolicy()
+	tests := []struct {
+		holds string
+		role  string
+		want  bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleOperator, false},
+		{RoleViewer, RoleAdmin, false},
+		{RoleOperator, RoleViewer, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleAdmin, RoleOperator, true},
+	}
+	for _, tt := range tests {
+		principal := &Principal{Type: PrincipalAPIKey, Subject: "k", Roles: []string{tt.holds}}
+		if got := policy.CanGrant(principal, tt.role); got != tt.want {
+			t.Errorf("%s can grant %s = %v, want %v", tt.holds, tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+roles:
+  support: ["servers:read", "servers:action:*"]
+  viewer: ["servers:read"]
+bindings:
+  "jwt:alice": [support]
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+
+	alice := &Principal{Type: PrincipalJWT, Subject: "alice", Roles: []string{RoleViewer}}
+	if !policy.Allows(alice, ActionPermission(ActionTerminate)) {
+		t.Error("Expected the bound role to grant servers:action:terminate through the wildcard")
+	}
+	if policy.Allows(alice, PermissionServersCreate) {
+		t.Error("Expected servers:create to be denied")
+	}
+	bob := &Principal{Type: PrincipalJWT, Subject: "bob", Roles: []string{RoleViewer}}
+	if policy.Allows(bob, ActionPermission(ActionStart)) {
+		t.Error("Expected bob to have only the viewer role")
+	}
+	if msg := policy.ValidateRoles([]string{RoleOperator}); msg == "" {
+		t.Error("Expected operator to be unknown in a policy that does not define it")
+	}
+
+	for _, data := range []string{
+		"roles: [",
+		"bindings:\n  \"jwt:alice\": [support]\n",
+		"roles:\n  viewer: [\"servers:read\"]\nbindings:\n  \"jwt:alice\": [missing]\n",
+	} {
+		if _, err := ParsePolicy([]byte(data)); err == nil {
+			t.Errorf("Expected an error for policy %q", data)
+		}
+	}
+}
+
+func TestRolesForScopes(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		want   string
+	}{
+		{[]string{ScopeRead}, RoleViewer},
+		{[]string{ScopeRead, ScopeWrite}, RoleOperator},
+		{[]string{ScopeAdmin}, RoleAdmin},
+	}
+	for _, tt := range tests {
+		if got := RolesForScopes(tt.scopes); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("RolesForScopes(%v) = %v, want [%s]", tt.scopes, got, tt.want)
+		}
+	}
+	if got := RolesForScopes(nil); got != nil {
+		t.Errorf("RolesForScopes(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import "fmt"
+
+// instanceTypeOrder lists the instance types from smallest to largest.
+var instanceTypeOrder = []string{"basic", "plus", "prime"}
+
+func instanceTypeRank(instanceType string) int {
+	for i, t := range instanceTypeOrder {
+		if t == instanceType {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateResize checks the target type of a resize.
+func ValidateResize(fromType, toType string) string {
+	if toType == "" {
+		return "A target type is required to resize."
+	}
+	if instanceTypeRank(toType) < 0 {
+		return fmt.Sprintf("Unknown instance type '%s'.", toType)
+	}
+	if fromType == toType {
+		return fmt.Sprintf("Server is already of type '%s'.", toType)
+	}
+	return ""
+}
+
+// CanResizeOnline reports whether a running server can change type without
+// stopping. Only growing to a larger type is supported online, as capacity
+// can be hot-added but not removed.
+func CanResizeOnline(fromType, toType string) bool {
+	from, to := instanceTypeRank(fromType), instanceTypeRank(toType)
+	return from >= 0 && to > from
+}
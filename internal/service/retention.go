@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// RetentionRule says how long log entries are kept. MaxAge removes entries
+// older than it; MaxPerServer keeps only that many of the newest entries of
+// each server. Zero means no limit.
+type RetentionRule struct {
+	MaxAge       time.Duration `yaml:"maxAge" json:"maxAge"`
+	MaxPerServer int           `yaml:"maxPerServer" json:"maxPerServer"`
+}
+
+// RetentionPolicy holds a rule per event type. Event types without their own
+// rule share the default rule, and are counted together for MaxPerServer.
+type RetentionPolicy struct {
+	Default    RetentionRule            `yaml:"default" json:"default"`
+	EventTypes map[string]RetentionRule `yaml:"eventTypes" json:"eventTypes"`
+}
+
+// ParseRetentionPolicy reads a YAML retention policy.
+func ParseRetentionPolicy(data []byte) (*RetentionPolicy, error) {
+	var policy RetentionPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid retention policy: %w", err)
+	}
+	rules := map[string]RetentionRule{"default": policy.Default}
+	for eventType, rule := range policy.EventTypes {
+		rules[eventType] = rule
+	}
+	for name, rule := range rules {
+		if rule.MaxAge < 0 || rule.MaxPerServer < 0 {
+			return nil, fmt.Errorf("retention rule '%s' cannot have negative limits", name)
+		}
+	}
+	return &policy, nil
+}
+
+// ruleFor returns the rule of an event type and the group it is counted in.
+func (p *RetentionPolicy) ruleFor(eventType string) (RetentionRule, string) {
+	if rule, ok := p.EventTypes[eventType]; ok {
+		return rule, eventType
+	}
+	return p.Default, ""
+}
+
+// ExpiredLogs picks the entries of one server that the policy no longer
+// keeps. entries must be ordered newest first.
+func (p *RetentionPolicy) ExpiredLogs(entries []models.ServerLog, now time.Time) []models.ServerLog {
+	var expired []models.ServerLog
+	kept := map[string]int{}
+	for _, entry := range entries {
+		rule, group := p.ruleFor(entry.EventType)
+		switch {
+		case rule.MaxAge > 0 && entry.CreatedAt.Before(now.Add(-rule.MaxAge)):
+			expired = append(expired, entry)
+		case rule.MaxPerServer > 0 && kept[group] >= rule.MaxPerServer:
+			expired = append(expired, entry)
+		default:
+			kept[group]++
+		}
+	}
+	return expired
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gitshubham45/virtualServer/internal/models"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	policy, err := ParseRetentionPolicy([]byte(`
+default:
+  maxAge: 720h
+  maxPerServer: 3
+eventTypes:
+  EXPIRY_WARNING:
+    maxAge: 24h
+  SERVER_CREATED: {}
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	if _, err := ParseRetentionPolicy([]byte("default:\n  maxPerServer: -1\n")); err == nil {
+		t.Error("Expected a negative limit to be rejected")
+	}
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(seq int64, eventType string, age time.Duration) models.ServerLog {
+		return models.ServerLog{Sequence: seq, EventType: eventType, CreatedAt: now.Add(-age)}
+	}
+	// Newest first, as the policy expects.
+	entries := []models.ServerLog{
+		entry(9, "STATUS_CHANGE", time.Hour),
+		entry(8, "EXPIRY_WARNING", 2*time.Hour),
+		entry(7, "STATUS_CHANGE", 3*time.Hour),
+		entry(6, "EXPIRY_WARNING", 48*time.Hour),
+		entry(5, "TAGS_UPDATED", 50*time.Hour),
+		entry(4, "STATUS_CHANGE", 60*time.Hour),
+		entry(3, "STATUS_CHANGE", 800*time.Hour),
+		entry(2, "SERVER_CREATED", 900*time.Hour),
+	}
+
+	var expired []int64
+	for _, e := range policy.ExpiredLogs(entries, now) {
+		expired = append(expired, e.Sequence)
+	}
+	want := []int64{6, 4, 3}
+	if len(expired) != len(want) {
+		t.Fatalf("Expected %v to expire, got %v", want, expired)
+	}
+	for i := range want {
+		if expired[i] != want[i] {
+			t.Fatalf("Expected %v to expire, got %v", want, expired)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	selectorEquals       = "="
+	selectorNotEquals    = "!="
+	selectorIn           = "in"
+	selectorNotIn        = "notin"
+	selectorExists       = "exists"
+	selectorDoesNotExist = "!"
+)
+
+type selectorRequirement struct {
+	key      string
+	operator string
+	values   []string
+}
+
+// Selector is a parsed Kubernetes-style label selector. All requirements must
+// hold for a set of tags to match. The zero value matches everything.
+type Selector struct {
+	requirements []selectorRequirement
+}
+
+// ParseSelector understands equality (`env=prod`, `env==prod`, `env!=prod`),
+// set (`team in (a,b)`, `team notin (a,b)`) and existence (`legacy`,
+// `!legacy`) requirements separated by commas.
+func ParseSelector(input string) (Selector, error) {
+	var selector Selector
+
+	parts, err := splitSelector(input)
+	if err != nil {
+		return selector, err
+	}
+
+	for _, part := range parts {
+		requirement, err := parseRequirement(part)
+		if err != nil {
+			return Selector{}, err
+		}
+		selector.requirements = append(selector.requirements, requirement)
+	}
+	return selector, nil
+}
+
+func (s Selector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+func (s Selector) Matches(tags map[string]string) bool {
+	for _, r := range s.requirements {
+		value, ok := tags[r.key]
+		switch r.operator {
+		case selectorEquals:
+			if !ok || value != r.values[0] {
+				return false
+			}
+		case selectorNotEquals:
+			if ok && value == r.values[0] {
+				return false
+			}
+		case selectorIn:
+			if !ok || !contains(r.values, value) {
+				return false
+			}
+		case selectorNotIn:
+			if ok && contains(r.values, value) {
+				return false
+			}
+		case selectorExists:
+			if !ok {
+				return false
+			}
+		case selectorDoesNotExist:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s.requirements))
+	for _, r := range s.requirements {
+		switch r.operator {
+		case selectorExists:
+			parts = append(parts, r.key)
+		case selectorDoesNotExist:
+			parts = append(parts, "!"+r.key)
+		case selectorIn, selectorNotIn:
+			parts = append(parts, fmt.Sprintf("%s %s (%s)", r.key, r.operator, strings.Join(r.values, ",")))
+		default:
+			parts = append(parts, r.key+r.operator+r.values[0])
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitSelector splits on commas that are not inside a value list.
+func splitSelector(input string) ([]string, error) {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, r := range input {
+		switch r {
+		case '(':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("nested parentheses in selector at position %d", i)
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced ')' in selector at position %d", i)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, input[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced '(' in selector")
+	}
+	parts = append(parts, input[start:])
+
+	out := parts[:0]
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if len(parts) > 1 {
+				return nil, fmt.Errorf("empty requirement in selector %q", input)
+			}
+			continue
+		}
+		out = append(out, part)
+	}
+	return out, nil
+}
+
+func parseRequirement(part string) (selectorRequirement, error) {
+	if strings.HasPrefix(part, "!") && !strings.HasPrefix(part, "!=") {
+		key := strings.TrimSpace(part[1:])
+		if err := checkSelectorKey(key); err != nil {
+			return selectorRequirement{}, err
+		}
+		return selectorRequirement{key: key, operator: selectorDoesNotExist}, nil
+	}
+
+	if open := strings.Index(part, "("); open >= 0 {
+		if !strings.HasSuffix(part, ")") {
+			return selectorRequirement{}, fmt.Errorf("expected ')' at end of %q", part)
+		}
+		fields := strings.Fields(part[:open])
+		if len(fields) != 2 || (fields[1] != selectorIn && fields[1] != selectorNotIn) {
+			return selectorRequirement{}, fmt.Errorf("expected '<key> in (...)' or '<key> notin (...)', got %q", part)
+		}
+		if err := checkSelectorKey(fields[0]); err != nil {
+			return selectorRequirement{}, err
+		}
+
+		var values []string
+		for _, v := range strings.Split(part[open+1:len(part)-1], ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				return selectorRequirement{}, fmt.Errorf("empty value in %q", part)
+			}
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		return selectorRequirement{key: fields[0], operator: fields[1], values: values}, nil
+	}
+
+	for _, operator := range []string{"!=", "==", "="} {
+		if i := strings.Index(part, operator); i >= 0 {
+			key := strings.TrimSpace(part[:i])
+			value := strings.TrimSpace(part[i+len(operator):])
+			if err := checkSelectorKey(key); err != nil {
+				return selectorRequirement{}, err
+			}
+			if strings.ContainsAny(value, "=!() ") {
+				return selectorRequirement{}, fmt.Errorf("invalid value %q in selector", value)
+			}
+			normalized := selectorEquals
+			if operator == "!=" {
+				normalized = selectorNotEquals
+			}
+			return selectorRequirement{key: key, operator: normalized, values: []string{value}}, nil
+		}
+	}
+
+	if err := checkSelectorKey(part); err != nil {
+		return selectorRequirement{}, err
+	}
+	return selectorRequirement{key: part, operator: selectorExists}, nil
+}
+
+func checkSelectorKey(key string) error {
+	if key == "" || len(key) > MaxTagKeyLength || !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid key %q in selector", key)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
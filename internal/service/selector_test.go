@@ -0,0 +1,85 @@
+package service
+
+import "testing"
+
+func TestSelectorMatches(t *testing.T) {
+	prodA := map[string]string{"env": "prod", "team": "a"}
+	devB := map[string]string{"env": "dev", "team": "b", "legacy": ""}
+	untagged := map[string]string{}
+
+	tests := []struct {
+		selector string
+		matches  []map[string]string
+		misses   []map[string]string
+	}{
+		{"", []map[string]string{prodA, devB, untagged}, nil},
+		{"env=prod", []map[string]string{prodA}, []map[string]string{devB, untagged}},
+		{"env==prod", []map[string]string{prodA}, []map[string]string{devB}},
+		{"env!=prod", []map[string]string{devB, untagged}, []map[string]string{prodA}},
+		{"team in (a, b)", []map[string]string{prodA, devB}, []map[string]string{untagged}},
+		{"team notin (a)", []map[string]string{devB, untagged}, []map[string]string{prodA}},
+		{"legacy", []map[string]string{devB}, []map[string]string{prodA, untagged}},
+		{"!legacy", []map[string]string{prodA, untagged}, []map[string]string{devB}},
+		{"env=prod,team in (a,b),!legacy", []map[string]string{prodA}, []map[string]string{devB, untagged}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("Failed to parse selector: %v", err)
+			}
+			for _, tags := range tt.matches {
+				if !selector.Matches(tags) {
+					t.Errorf("Expected %v to match", tags)
+				}
+			}
+			for _, tags := range tt.misses {
+				if selector.Matches(tags) {
+					t.Errorf("Expected %v not to match", tags)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, input := range []string{
+		"env=prod,",
+		"team in (a,b",
+		"team in a,b)",
+		"team within (a)",
+		"team in (a,,b)",
+		"=prod",
+		"env=(prod)",
+		"bad key=1",
+	} {
+		if _, err := ParseSelector(input); err == nil {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	if errorMessage := ValidateTags(map[string]string{"env": "prod", "cost-center": "cc.42", "example.com/owner": "team_a", "legacy": ""}); errorMessage != "" {
+		t.Errorf("Expected tags to be valid, got %q", errorMessage)
+	}
+	for _, tags := range []map[string]string{
+		{"": "x"},
+		{"env": "has space"},
+		{"-env": "prod"},
+		{"env": "prod-"},
+	} {
+		if ValidateTags(tags) == "" {
+			t.Errorf("Expected %v to be rejected", tags)
+		}
+	}
+
+	many := make(map[string]string)
+	for i := 0; i <= MaxTagsPerServer; i++ {
+		many[string(rune('a'+i%26))+string(rune('a'+i/26))] = "x"
+	}
+	if ValidateTags(many) == "" {
+		t.Errorf("Expected more than %d tags to be rejected", MaxTagsPerServer)
+	}
+}
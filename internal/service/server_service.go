@@ -10,6 +10,9 @@ const (
 	StatusRunning    = "running"
 	StatusStopped    = "stopped"
 	StatusTerminated = "terminated"
+	StatusResizing   = "resizing"
+	StatusHibernated = "hibernated"
+	StatusRescue     = "rescue"
 )
 
 const (
@@ -17,8 +20,18 @@ const (
 	ActionStop      = "stop"
 	ActionReboot    = "reboot"
 	ActionTerminate = "terminate"
+	ActionResize    = "resize"
+	ActionHibernate = "hibernate"
+	ActionResume    = "resume"
+	ActionRebuild   = "rebuild"
+	ActionRescue    = "rescue"
+	ActionUnrescue  = "unrescue"
 )
 
+// CodeStatusConflict marks an action refused because the server's status
+// changed while it was being applied.
+const CodeStatusConflict = "STATUS_CONFLICT"
+
 func HandleAction(action string, originalStatus string) (string, string) {
 	var newStatus string
 	var errorMessage string
@@ -30,6 +43,10 @@ func HandleAction(action string, originalStatus string) (string, string) {
 			newStatus = StatusRunning
 		case StatusRunning:
 			errorMessage = "Server is already running."
+		case StatusHibernated:
+			errorMessage = "Server is hibernated. Resume it instead."
+		case StatusRescue:
+			errorMessage = "Server is in rescue mode. Unrescue it instead."
 		case StatusTerminated:
 			errorMessage = "Cannot start a terminated server."
 		case StatusPending:
@@ -40,10 +57,15 @@ func HandleAction(action string, originalStatus string) (string, string) {
 
 	case ActionStop:
 		switch originalStatus {
-		case StatusRunning:
+		case StatusRunning, StatusHibernated:
+			newStatus = StatusStopped
+		case StatusResizing:
+			// Abandons a resize that could not be completed, keeping the old type.
 			newStatus = StatusStopped
 		case StatusStopped:
 			errorMessage = "Server is already stopped."
+		case StatusRescue:
+			errorMessage = "Server is in rescue mode. Unrescue it first."
 		case StatusTerminated:
 			errorMessage = "Cannot stop a terminated server."
 		case StatusPending:
@@ -59,6 +81,10 @@ func HandleAction(action string, originalStatus string) (string, string) {
 			log.Printf("Server is being rebooted. This is often a transient operation.")
 		case StatusStopped:
 			errorMessage = "Cannot reboot a stopped server. Start it first."
+		case StatusHibernated:
+			errorMessage = "Cannot reboot a hibernated server. Resume it first."
+		case StatusRescue:
+			errorMessage = "Cannot reboot a server in rescue mode. Unrescue it first."
 		case StatusTerminated:
 			errorMessage = "Cannot reboot a terminated server."
 		case StatusPending:
@@ -69,7 +95,7 @@ func HandleAction(action string, originalStatus string) (string, string) {
 
 	case ActionTerminate:
 		switch originalStatus {
-		case StatusRunning, StatusStopped, StatusPending:
+		case StatusRunning, StatusStopped, StatusPending, StatusHibernated, StatusRescue:
 			newStatus = StatusTerminated
 		case StatusTerminated:
 			errorMessage = "Server is already terminated."
@@ -77,10 +103,139 @@ func HandleAction(action string, originalStatus string) (string, string) {
 			errorMessage = fmt.Sprintf("Cannot terminate server from '%s' status.", originalStatus)
 		}
 
+	case ActionResize:
+		switch originalStatus {
+		case StatusStopped:
+			newStatus = StatusResizing
+		case StatusRunning:
+			errorMessage = "Cannot resize a running server. Stop it first, or resize online to a larger type."
+		case StatusResizing:
+			errorMessage = "Server is already being resized."
+		case StatusHibernated:
+			errorMessage = "Cannot resize a hibernated server. Stop it first."
+		case StatusRescue:
+			errorMessage = "Cannot resize a server in rescue mode. Unrescue it first."
+		case StatusTerminated:
+			errorMessage = "Cannot resize a terminated server."
+		case StatusPending:
+			errorMessage = "Server is in pending state and cannot be resized."
+		default:
+			errorMessage = fmt.Sprintf("Cannot resize server from '%s' status.", originalStatus)
+		}
+
+	case ActionHibernate:
+		switch originalStatus {
+		case StatusRunning:
+			newStatus = StatusHibernated
+		case StatusHibernated:
+			errorMessage = "Server is already hibernated."
+		case StatusStopped:
+			errorMessage = "Cannot hibernate a stopped server. Start it first."
+		case StatusRescue:
+			errorMessage = "Cannot hibernate a server in rescue mode. Unrescue it first."
+		case StatusTerminated:
+			errorMessage = "Cannot hibernate a terminated server."
+		case StatusPending:
+			errorMessage = "Server is in pending state and cannot be hibernated."
+		default:
+			errorMessage = fmt.Sprintf("Cannot hibernate server from '%s' status.", originalStatus)
+		}
+
+	case ActionResume:
+		switch originalStatus {
+		case StatusHibernated:
+			newStatus = StatusRunning
+		case StatusRunning:
+			errorMessage = "Server is already running."
+		case StatusStopped:
+			errorMessage = "Server is stopped, not hibernated. Start it instead."
+		case StatusRescue:
+			errorMessage = "Server is in rescue mode, not hibernated. Unrescue it instead."
+		case StatusTerminated:
+			errorMessage = "Cannot resume a terminated server."
+		case StatusPending:
+			errorMessage = "Server is in pending state and cannot be resumed."
+		default:
+			errorMessage = fmt.Sprintf("Cannot resume server from '%s' status.", originalStatus)
+		}
+
+	case ActionRebuild:
+		// A rebuild reinstalls the root disk and leaves the server as it was.
+		switch originalStatus {
+		case StatusRunning, StatusStopped:
+			newStatus = originalStatus
+		case StatusHibernated:
+			errorMessage = "Cannot rebuild a hibernated server. Resume or stop it first."
+		case StatusRescue:
+			errorMessage = "Cannot rebuild a server in rescue mode. Unrescue it first."
+		case StatusTerminated:
+			errorMessage = "Cannot rebuild a terminated server."
+		case StatusPending:
+			errorMessage = "Server is in pending state and cannot be rebuilt."
+		default:
+			errorMessage = fmt.Sprintf("Cannot rebuild server from '%s' status.", originalStatus)
+		}
+
+	case ActionRescue:
+		switch originalStatus {
+		case StatusRunning, StatusStopped:
+			newStatus = StatusRescue
+		case StatusRescue:
+			errorMessage = "Server is already in rescue mode."
+		case StatusHibernated:
+			errorMessage = "Cannot rescue a hibernated server. Resume or stop it first."
+		case StatusTerminated:
+			errorMessage = "Cannot rescue a terminated server."
+		case StatusPending:
+			errorMessage = "Server is in pending state and cannot be rescued."
+		default:
+			errorMessage = fmt.Sprintf("Cannot rescue server from '%s' status.", originalStatus)
+		}
+
+	case ActionUnrescue:
+		switch originalStatus {
+		case StatusRescue:
+			newStatus = StatusRunning
+		case StatusTerminated:
+			errorMessage = "Cannot unrescue a terminated server."
+		default:
+			errorMessage = "Server is not in rescue mode."
+		}
+
 	default:
 		errorMessage = fmt.Sprintf("Action '%s' is not supported.", action)
 		newStatus = ""
 	}
 
 	return newStatus, errorMessage
-}
\ No newline at end of file
+}
+
+// AlreadyInState reports whether a server in the given status is already where
+// the action would leave it, so applying it again changes nothing.
+func AlreadyInState(action string, status string) bool {
+	switch action {
+	case ActionStart, ActionResume, ActionUnrescue:
+		return status == StatusRunning
+	case ActionRescue:
+		return status == StatusRescue
+	case ActionHibernate:
+		return status == StatusHibernated
+	case ActionStop:
+		return status == StatusStopped
+	case ActionTerminate:
+		return status == StatusTerminated
+	}
+	return false
+}
+
+// IsAction reports whether action needs nothing but its name, which is what
+// bulk actions and schedules can carry. Resize and rebuild also need a target
+// type or image.
+func IsAction(action string) bool {
+	switch action {
+	case ActionStart, ActionStop, ActionReboot, ActionTerminate, ActionHibernate, ActionResume,
+		ActionRescue, ActionUnrescue:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package service
+
+import "testing"
+
+func TestHandleActionHibernation(t *testing.T) {
+	tests := []struct {
+		action    string
+		status    string
+		newStatus string
+	}{
+		{ActionHibernate, StatusRunning, StatusHibernated},
+		{ActionHibernate, StatusStopped, ""},
+		{ActionHibernate, StatusPending, ""},
+		{ActionHibernate, StatusHibernated, ""},
+		{ActionHibernate, StatusTerminated, ""},
+		{ActionHibernate, StatusResizing, ""},
+		{ActionResume, StatusHibernated, StatusRunning},
+		{ActionResume, StatusRunning, ""},
+		{ActionResume, StatusStopped, ""},
+		{ActionResume, StatusPending, ""},
+		{ActionResume, StatusTerminated, ""},
+		{ActionResume, StatusResizing, ""},
+		{ActionStart, StatusHibernated, ""},
+		{ActionStop, StatusHibernated, StatusStopped},
+		{ActionReboot, StatusHibernated, ""},
+		{ActionResize, StatusHibernated, ""},
+		{ActionTerminate, StatusHibernated, StatusTerminated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" from "+tt.status, func(t *testing.T) {
+			newStatus, errorMessage := HandleAction(tt.action, tt.status)
+			if newStatus != tt.newStatus {
+				t.Errorf("Expected new status %q, got %q", tt.newStatus, newStatus)
+			}
+			if (errorMessage == "") != (tt.newStatus != "") {
+				t.Errorf("Unexpected error message %q", errorMessage)
+			}
+		})
+	}
+}
+
+// TestHandleActionResizing checks that stop is the only way out of a resize
+// that was never completed.
+func TestHandleActionResizing(t *testing.T) {
+	tests := []struct {
+		action    string
+		newStatus string
+	}{
+		{ActionStop, StatusStopped},
+		{ActionStart, ""},
+		{ActionReboot, ""},
+		{ActionResize, ""},
+		{ActionTerminate, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			newStatus, errorMessage := HandleAction(tt.action, StatusResizing)
+			if newStatus != tt.newStatus {
+				t.Errorf("Expected new status %q, got %q", tt.newStatus, newStatus)
+			}
+			if (errorMessage == "") != (tt.newStatus != "") {
+				t.Errorf("Unexpected error message %q", errorMessage)
+			}
+		})
+	}
+}
+
+func TestHandleActionRebuildAndRescue(t *testing.T) {
+	tests := []struct {
+		action    string
+		status    string
+		newStatus string
+	}{
+		{ActionRebuild, StatusRunning, StatusRunning},
+		{ActionRebuild, StatusStopped, StatusStopped},
+		{ActionRebuild, StatusPending, ""},
+		{ActionRebuild, StatusHibernated, ""},
+		{ActionRebuild, StatusRescue, ""},
+		{ActionRebuild, StatusTerminated, ""},
+		{ActionRescue, StatusRunning, StatusRescue},
+		{ActionRescue, StatusStopped, StatusRescue},
+		{ActionRescue, StatusRescue, ""},
+		{ActionRescue, StatusHibernated, ""},
+		{ActionRescue, StatusPending, ""},
+		{ActionRescue, StatusTerminated, ""},
+		{ActionUnrescue, StatusRescue, StatusRunning},
+		{ActionUnrescue, StatusRunning, ""},
+		{ActionUnrescue, StatusStopped, ""},
+		{ActionUnrescue, StatusTerminated, ""},
+		{ActionStart, StatusRescue, ""},
+		{ActionStop, StatusRescue, ""},
+		{ActionReboot, StatusRescue, ""},
+		{ActionHibernate, StatusRescue, ""},
+		{ActionResume, StatusRescue, ""},
+		{ActionResize, StatusRescue, ""},
+		{ActionTerminate, StatusRescue, StatusTerminated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" from "+tt.status, func(t *testing.T) {
+			newStatus, errorMessage := HandleAction(tt.action, tt.status)
+			if newStatus != tt.newStatus {
+				t.Errorf("Expected new status %q, got %q", tt.newStatus, newStatus)
+			}
+			if (errorMessage == "") != (tt.newStatus != "") {
+				t.Errorf("Unexpected error message %q", errorMessage)
+			}
+		})
+	}
+}
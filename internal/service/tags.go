@@ -0,0 +1,34 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	MaxTagsPerServer = 50
+	MaxTagKeyLength  = 128
+	MaxTagValueLen   = 256
+)
+
+var (
+	tagKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_./-]*[A-Za-z0-9])?$`)
+	tagValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9_.-]*[A-Za-z0-9])?)?$`)
+)
+
+// ValidateTags checks keys and values against the same character rules as
+// Kubernetes labels, so every tag can be addressed by a selector.
+func ValidateTags(tags map[string]string) string {
+	if len(tags) > MaxTagsPerServer {
+		return fmt.Sprintf("A server can have at most %d tags.", MaxTagsPerServer)
+	}
+	for key, value := range tags {
+		if len(key) > MaxTagKeyLength || !tagKeyPattern.MatchString(key) {
+			return fmt.Sprintf("Tag key '%s' is invalid. Keys are 1-%d characters of letters, digits, '-', '_', '.' or '/', starting and ending with a letter or digit.", key, MaxTagKeyLength)
+		}
+		if len(value) > MaxTagValueLen || !tagValuePattern.MatchString(value) {
+			return fmt.Sprintf("Tag value '%s' for key '%s' is invalid. Values are up to %d characters of letters, digits, '-', '_' or '.', starting and ending with a letter or digit.", value, key, MaxTagValueLen)
+		}
+	}
+	return ""
+}
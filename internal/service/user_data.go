@@ -0,0 +1,60 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxUserDataSize matches the limit most clouds place on decoded user data.
+const MaxUserDataSize = 16 * 1024
+
+// DecodeUserData decodes a base64 user-data payload and checks that it is a
+// cloud-config document or a script.
+func DecodeUserData(encoded string) ([]byte, string) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "User data must be base64 encoded."
+	}
+	if len(decoded) > MaxUserDataSize {
+		return nil, fmt.Sprintf("User data is %d bytes, the limit is %d bytes.", len(decoded), MaxUserDataSize)
+	}
+	if !utf8.Valid(decoded) {
+		return nil, "User data must be UTF-8 text."
+	}
+
+	switch {
+	case bytes.HasPrefix(decoded, []byte("#cloud-config")):
+		var document map[string]interface{}
+		if err := yaml.Unmarshal(decoded, &document); err != nil {
+			return nil, fmt.Sprintf("User data is not valid cloud-config YAML: %v", err)
+		}
+	case bytes.HasPrefix(decoded, []byte("#!")):
+		firstLine, _, _ := bytes.Cut(decoded, []byte("\n"))
+		if len(bytes.TrimSpace(firstLine[2:])) == 0 {
+			return nil, "User data script must name an interpreter after '#!'."
+		}
+	default:
+		return nil, "User data must start with '#cloud-config' or a '#!' interpreter line."
+	}
+
+	return decoded, ""
+}
+
+func CanModifyUserData(status string) string {
+	switch status {
+	case StatusStopped:
+		return ""
+	case StatusRunning:
+		return "Cannot change user data of a running server. Stop it first."
+	case StatusTerminated:
+		return "Cannot change user data of a terminated server."
+	case StatusPending:
+		return "Server is in pending state and its user data cannot be changed."
+	default:
+		return fmt.Sprintf("Cannot change user data from '%s' status.", status)
+	}
+}
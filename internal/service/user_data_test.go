@@ -0,0 +1,46 @@
+package service
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestDecodeUserData(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{"cloud-config", encode("#cloud-config\npackages:\n  - nginx\n"), false},
+		{"shell script", encode("#!/bin/bash\necho hello\n"), false},
+		{"not base64", "#!/bin/bash", true},
+		{"plain text", encode("echo hello"), true},
+		{"broken yaml", encode("#cloud-config\npackages: [nginx\n"), true},
+		{"yaml list", encode("#cloud-config\n- a\n- b\n"), true},
+		{"missing interpreter", encode("#!\necho hello"), true},
+		{"too large", encode("#!/bin/sh\n" + strings.Repeat("#", MaxUserDataSize)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errorMessage := DecodeUserData(tt.payload)
+			if (errorMessage != "") != tt.wantErr {
+				t.Errorf("Expected error = %v, got %q", tt.wantErr, errorMessage)
+			}
+		})
+	}
+}
+
+func TestCanModifyUserData(t *testing.T) {
+	for _, status := range []string{StatusRunning, StatusPending, StatusTerminated} {
+		if CanModifyUserData(status) == "" {
+			t.Errorf("Expected user data change to be denied in '%s'", status)
+		}
+	}
+	if errorMessage := CanModifyUserData(StatusStopped); errorMessage != "" {
+		t.Errorf("Expected user data change to be allowed when stopped, got %q", errorMessage)
+	}
+}